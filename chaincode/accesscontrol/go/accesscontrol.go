@@ -0,0 +1,466 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"blockchain-security/chaincode/common/clock"
+)
+
+// SmartContract представляє смарт-контракт управління доступом
+type SmartContract struct {
+	contractapi.Contract
+}
+
+// User структура користувача системи
+type User struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Organization string   `json:"organization"`
+	Roles        []string `json:"roles"`
+	Status       string   `json:"status"` // active, disabled
+	CreatedAt    int64    `json:"createdAt"`
+}
+
+// Role структура ролі з переліком дозволів
+type Role struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+// Resource структура захищеного ресурсу
+type Resource struct {
+	ID                  string   `json:"id"`
+	Name                string   `json:"name"`
+	Type                string   `json:"type"`
+	Owner               string   `json:"owner"`
+	RequiredPermissions []string `json:"requiredPermissions"`
+	CreatedAt           int64    `json:"createdAt"`
+}
+
+// Permission структура дозволу на дію
+type Permission struct {
+	ID          string `json:"id"`
+	Action      string `json:"action"` // read, write, execute, admin
+	Description string `json:"description"`
+}
+
+// Префікси для ключів у world state
+const (
+	userPrefix       = "user:"
+	rolePrefix       = "role:"
+	resourcePrefix   = "resource:"
+	permissionPrefix = "permission:"
+)
+
+// Ключ позначки ініціалізації реєстру
+const initializedKey = "config:initialized"
+
+// Організаційний підрозділ сертифікатів адміністраторів організації (Fabric NodeOUs)
+const adminOU = "admin"
+
+// Статуси користувача
+const (
+	userStatusActive   = "active"
+	userStatusDisabled = "disabled"
+)
+
+// InitLedger ініціалізує базові дозволи та ролі. Повторний виклик нічого не змінює,
+// щоб не скинути ролі, змінені після ініціалізації.
+func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
+	if err := authorizeAdmin(ctx); err != nil {
+		return err
+	}
+	initialized, err := stateExists(ctx, initializedKey)
+	if err != nil {
+		return err
+	}
+	if initialized {
+		return nil
+	}
+
+	permissions := []Permission{
+		{ID: "read", Action: "read", Description: "Читання ресурсу"},
+		{ID: "write", Action: "write", Description: "Зміна ресурсу"},
+		{ID: "admin", Action: "admin", Description: "Адміністрування ресурсу"},
+	}
+	for _, permission := range permissions {
+		if err := putJSON(ctx, permissionPrefix+permission.ID, permission); err != nil {
+			return err
+		}
+	}
+
+	roles := []Role{
+		{ID: "admin", Name: "Адміністратор", Permissions: []string{"read", "write", "admin"}},
+		{ID: "user", Name: "Користувач", Permissions: []string{"read", "write"}},
+		{ID: "auditor", Name: "Аудитор", Permissions: []string{"read"}},
+	}
+	for _, role := range roles {
+		if err := putJSON(ctx, rolePrefix+role.ID, role); err != nil {
+			return err
+		}
+	}
+
+	if err := ctx.GetStub().PutState(initializedKey, []byte("true")); err != nil {
+		return fmt.Errorf("помилка збереження стану: %v", err)
+	}
+
+	fmt.Println("Контракт управління доступом ініціалізовано")
+	return nil
+}
+
+// CreatePermission створює новий дозвіл. Адміністративні транзакції доступні лише адміністратору організації.
+func (s *SmartContract) CreatePermission(ctx contractapi.TransactionContextInterface, id string, action string, description string) error {
+	if err := authorizeAdmin(ctx); err != nil {
+		return err
+	}
+	if id == "" || action == "" {
+		return fmt.Errorf("ідентифікатор та дія дозволу є обов'язковими")
+	}
+
+	exists, err := stateExists(ctx, permissionPrefix+id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("дозвіл %s вже існує", id)
+	}
+
+	permission := Permission{
+		ID:          id,
+		Action:      action,
+		Description: description,
+	}
+
+	return putJSON(ctx, permissionPrefix+id, permission)
+}
+
+// CreateRole створює нову роль з переліком дозволів
+func (s *SmartContract) CreateRole(ctx contractapi.TransactionContextInterface, id string, name string, permissionIDs string) error {
+	if err := authorizeAdmin(ctx); err != nil {
+		return err
+	}
+	if id == "" {
+		return fmt.Errorf("ідентифікатор ролі є обов'язковим")
+	}
+
+	exists, err := stateExists(ctx, rolePrefix+id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("роль %s вже існує", id)
+	}
+
+	// Парсимо дозволи з JSON рядка
+	var permissionsList []string
+	if err := json.Unmarshal([]byte(permissionIDs), &permissionsList); err != nil {
+		return fmt.Errorf("помилка при розборі дозволів: %v", err)
+	}
+
+	// Перевіряємо, що всі дозволи зареєстровані
+	for _, permissionID := range permissionsList {
+		exists, err := stateExists(ctx, permissionPrefix+permissionID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("дозвіл %s не існує", permissionID)
+		}
+	}
+
+	role := Role{
+		ID:          id,
+		Name:        name,
+		Permissions: permissionsList,
+	}
+
+	return putJSON(ctx, rolePrefix+id, role)
+}
+
+// AssignPermissionToRole додає дозвіл до існуючої ролі
+func (s *SmartContract) AssignPermissionToRole(ctx contractapi.TransactionContextInterface, roleID string, permissionID string) error {
+	if err := authorizeAdmin(ctx); err != nil {
+		return err
+	}
+	role, err := s.GetRole(ctx, roleID)
+	if err != nil {
+		return err
+	}
+
+	exists, err := stateExists(ctx, permissionPrefix+permissionID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("дозвіл %s не існує", permissionID)
+	}
+
+	if contains(role.Permissions, permissionID) {
+		return nil
+	}
+	role.Permissions = append(role.Permissions, permissionID)
+
+	return putJSON(ctx, rolePrefix+roleID, role)
+}
+
+// RegisterResource реєструє ресурс та дозволи, необхідні для доступу до нього
+func (s *SmartContract) RegisterResource(ctx contractapi.TransactionContextInterface, id string, name string, resourceType string, owner string, requiredPermissions string) error {
+	if err := authorizeAdmin(ctx); err != nil {
+		return err
+	}
+	if id == "" {
+		return fmt.Errorf("ідентифікатор ресурсу є обов'язковим")
+	}
+
+	exists, err := stateExists(ctx, resourcePrefix+id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("ресурс %s вже існує", id)
+	}
+
+	// Парсимо необхідні дозволи з JSON рядка
+	var permissionsList []string
+	if err := json.Unmarshal([]byte(requiredPermissions), &permissionsList); err != nil {
+		return fmt.Errorf("помилка при розборі дозволів: %v", err)
+	}
+	if len(permissionsList) == 0 {
+		return fmt.Errorf("ресурс має вимагати хоча б один дозвіл")
+	}
+
+	now, err := clock.Now(ctx.GetStub())
+	if err != nil {
+		return err
+	}
+
+	resource := Resource{
+		ID:                  id,
+		Name:                name,
+		Type:                resourceType,
+		Owner:               owner,
+		RequiredPermissions: permissionsList,
+		CreatedAt:           now,
+	}
+
+	return putJSON(ctx, resourcePrefix+id, resource)
+}
+
+// CreateUser створює нового користувача з переліком ролей
+func (s *SmartContract) CreateUser(ctx contractapi.TransactionContextInterface, id string, name string, org string, roles string) error {
+	if err := authorizeAdmin(ctx); err != nil {
+		return err
+	}
+	if id == "" || name == "" || org == "" {
+		return fmt.Errorf("ідентифікатор, ім'я та організація користувача є обов'язковими")
+	}
+
+	exists, err := stateExists(ctx, userPrefix+id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("користувач %s вже існує", id)
+	}
+
+	// Парсимо ролі з JSON рядка
+	var rolesList []string
+	if err := json.Unmarshal([]byte(roles), &rolesList); err != nil {
+		return fmt.Errorf("помилка при розборі ролей: %v", err)
+	}
+
+	// Перевіряємо, що всі ролі існують
+	for _, roleID := range rolesList {
+		exists, err := stateExists(ctx, rolePrefix+roleID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("роль %s не існує", roleID)
+		}
+	}
+
+	now, err := clock.Now(ctx.GetStub())
+	if err != nil {
+		return err
+	}
+
+	user := User{
+		ID:           id,
+		Name:         name,
+		Organization: org,
+		Roles:        rolesList,
+		Status:       userStatusActive,
+		CreatedAt:    now,
+	}
+
+	return putJSON(ctx, userPrefix+id, user)
+}
+
+// DisableUser блокує користувача, після чого йому забороняється будь-який доступ
+func (s *SmartContract) DisableUser(ctx contractapi.TransactionContextInterface, id string) error {
+	if err := authorizeAdmin(ctx); err != nil {
+		return err
+	}
+	user, err := s.GetUser(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	user.Status = userStatusDisabled
+
+	return putJSON(ctx, userPrefix+id, user)
+}
+
+// GetUser повертає користувача за ідентифікатором
+func (s *SmartContract) GetUser(ctx contractapi.TransactionContextInterface, id string) (*User, error) {
+	var user User
+	if err := getJSON(ctx, userPrefix+id, &user); err != nil {
+		return nil, fmt.Errorf("користувач %s: %v", id, err)
+	}
+	return &user, nil
+}
+
+// GetRole повертає роль за ідентифікатором
+func (s *SmartContract) GetRole(ctx contractapi.TransactionContextInterface, id string) (*Role, error) {
+	var role Role
+	if err := getJSON(ctx, rolePrefix+id, &role); err != nil {
+		return nil, fmt.Errorf("роль %s: %v", id, err)
+	}
+	return &role, nil
+}
+
+// GetResource повертає ресурс за ідентифікатором
+func (s *SmartContract) GetResource(ctx contractapi.TransactionContextInterface, id string) (*Resource, error) {
+	var resource Resource
+	if err := getJSON(ctx, resourcePrefix+id, &resource); err != nil {
+		return nil, fmt.Errorf("ресурс %s: %v", id, err)
+	}
+	return &resource, nil
+}
+
+// CheckAccess перевіряє, чи мають ролі користувача всі дозволи, необхідні для ресурсу.
+// До ресурсу без необхідних дозволів доступ заборонено.
+func (s *SmartContract) CheckAccess(ctx contractapi.TransactionContextInterface, userID string, resourceID string) (bool, error) {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if user.Status != userStatusActive {
+		return false, nil
+	}
+
+	resource, err := s.GetResource(ctx, resourceID)
+	if err != nil {
+		return false, err
+	}
+	if len(resource.RequiredPermissions) == 0 {
+		return false, nil
+	}
+
+	// Збираємо всі дозволи користувача з його ролей
+	granted := make(map[string]bool)
+	for _, roleID := range user.Roles {
+		role, err := s.GetRole(ctx, roleID)
+		if err != nil {
+			return false, err
+		}
+		for _, permissionID := range role.Permissions {
+			granted[permissionID] = true
+		}
+	}
+
+	for _, permissionID := range resource.RequiredPermissions {
+		if !granted[permissionID] {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// authorizeAdmin дозволяє операцію лише адміністратору організації
+func authorizeAdmin(ctx contractapi.TransactionContextInterface) error {
+	clientIdentity := ctx.GetClientIdentity()
+	if clientIdentity == nil {
+		return fmt.Errorf("ідентичність клієнта недоступна")
+	}
+
+	mspID, err := clientIdentity.GetMSPID()
+	if err != nil {
+		return fmt.Errorf("помилка отримання MSP ID клієнта: %v", err)
+	}
+	if !isAdmin(clientIdentity) {
+		return fmt.Errorf("операція доступна лише адміністратору організації %s", mspID)
+	}
+	return nil
+}
+
+// isAdmin перевіряє, чи є сертифікат клієнта сертифікатом адміністратора організації
+func isAdmin(clientIdentity cid.ClientIdentity) bool {
+	cert, err := clientIdentity.GetX509Certificate()
+	if err != nil || cert == nil {
+		return false
+	}
+	return contains(cert.Subject.OrganizationalUnit, adminOU)
+}
+
+// stateExists перевіряє наявність запису у world state
+func stateExists(ctx contractapi.TransactionContextInterface, key string) (bool, error) {
+	data, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, fmt.Errorf("помилка читання стану: %v", err)
+	}
+	return data != nil, nil
+}
+
+// getJSON читає запис з world state і десеріалізує його
+func getJSON(ctx contractapi.TransactionContextInterface, key string, value interface{}) error {
+	data, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("помилка читання стану: %v", err)
+	}
+	if data == nil {
+		return fmt.Errorf("не існує")
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("помилка десеріалізації даних: %v", err)
+	}
+	return nil
+}
+
+// putJSON серіалізує значення і зберігає його у world state
+func putJSON(ctx contractapi.TransactionContextInterface, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, data)
+}
+
+// contains перевіряє наявність рядка у списку
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func main() {
+	chaincode, err := contractapi.NewChaincode(new(SmartContract))
+	if err != nil {
+		fmt.Printf("Помилка створення чейнкоду: %s", err.Error())
+		return
+	}
+
+	if err := chaincode.Start(); err != nil {
+		fmt.Printf("Помилка запуску чейнкоду: %s", err.Error())
+	}
+}
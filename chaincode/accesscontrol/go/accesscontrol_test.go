@@ -0,0 +1,295 @@
+// Файл: chaincode/accesscontrol/go/accesscontrol_test.go
+package main
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockStub імітує ChainCodeStubInterface
+type MockStub struct {
+	mock.Mock
+	shim.ChaincodeStubInterface
+}
+
+func (s *MockStub) GetState(key string) ([]byte, error) {
+	args := s.Called(key)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (s *MockStub) PutState(key string, value []byte) error {
+	args := s.Called(key, value)
+	return args.Error(0)
+}
+
+func (s *MockStub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	args := s.Called()
+	return args.Get(0).(*timestamp.Timestamp), args.Error(1)
+}
+
+// MockContext імітує TransactionContextInterface
+type MockContext struct {
+	mock.Mock
+	contractapi.TransactionContextInterface
+}
+
+func (c *MockContext) GetStub() shim.ChaincodeStubInterface {
+	args := c.Called()
+	return args.Get(0).(shim.ChaincodeStubInterface)
+}
+
+func (c *MockContext) GetClientIdentity() cid.ClientIdentity {
+	args := c.Called()
+	return args.Get(0).(cid.ClientIdentity)
+}
+
+// MockClientIdentity імітує ClientIdentity
+type MockClientIdentity struct {
+	mock.Mock
+	cid.ClientIdentity
+}
+
+func (i *MockClientIdentity) GetMSPID() (string, error) {
+	args := i.Called()
+	return args.String(0), args.Error(1)
+}
+
+func (i *MockClientIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	args := i.Called()
+	return args.Get(0).(*x509.Certificate), args.Error(1)
+}
+
+// newMockIdentity створює ідентичність клієнта з організаційними підрозділами ous
+func newMockIdentity(mspID string, commonName string, ous ...string) *MockClientIdentity {
+	identity := new(MockClientIdentity)
+	identity.On("GetMSPID").Return(mspID, nil)
+	identity.On("GetX509Certificate").Return(&x509.Certificate{Subject: pkix.Name{CommonName: commonName, OrganizationalUnit: ous}}, nil)
+	return identity
+}
+
+// Тестування CreateUser
+func TestCreateUser(t *testing.T) {
+	// Ініціалізація мок-об'єктів
+	mockStub := new(MockStub)
+	mockContext := new(MockContext)
+	mockContext.On("GetStub").Return(mockStub)
+	mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "admin", "admin"))
+
+	// Підготовка даних для тесту
+	roleJSON := []byte(`{"id":"user","name":"Користувач","permissions":["read","write"]}`)
+
+	// Очікуємо виклики методів
+	mockStub.On("GetState", "user:user1").Return([]byte(nil), nil)
+	mockStub.On("GetState", "role:user").Return(roleJSON, nil)
+	mockStub.On("GetTxTimestamp").Return(&timestamp.Timestamp{Seconds: 1620000000}, nil)
+	mockStub.On("PutState", "user:user1", mock.Anything).Return(nil)
+
+	// Створення об'єкту смарт-контракту і виклик методу
+	contract := new(SmartContract)
+	err := contract.CreateUser(mockContext, "user1", "Іван", "Org1MSP", `["user"]`)
+
+	// Перевірка результатів
+	assert.Nil(t, err)
+	mockStub.AssertExpectations(t)
+	mockContext.AssertExpectations(t)
+
+	// Перевірка збереженого користувача
+	call := mockStub.Calls[3] // Четвертий виклик - це PutState
+	var user User
+	err = json.Unmarshal(call.Arguments[1].([]byte), &user)
+	assert.Nil(t, err)
+	assert.Equal(t, "user1", user.ID)
+	assert.Equal(t, "Org1MSP", user.Organization)
+	assert.Equal(t, []string{"user"}, user.Roles)
+	assert.Equal(t, "active", user.Status)
+	assert.Equal(t, int64(1620000000), user.CreatedAt)
+}
+
+// Тестування CreateUser з неіснуючою роллю
+func TestCreateUserUnknownRole(t *testing.T) {
+	// Ініціалізація мок-об'єктів
+	mockStub := new(MockStub)
+	mockContext := new(MockContext)
+	mockContext.On("GetStub").Return(mockStub)
+	mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "admin", "admin"))
+
+	// Очікуємо виклики методів
+	mockStub.On("GetState", "user:user1").Return([]byte(nil), nil)
+	mockStub.On("GetState", "role:superuser").Return([]byte(nil), nil)
+
+	// Створення об'єкту смарт-контракту і виклик методу
+	contract := new(SmartContract)
+	err := contract.CreateUser(mockContext, "user1", "Іван", "Org1MSP", `["superuser"]`)
+
+	// Перевірка результатів
+	assert.NotNil(t, err)
+	mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+}
+
+// Тестування RegisterResource
+func TestRegisterResource(t *testing.T) {
+	// Ініціалізація мок-об'єктів
+	mockStub := new(MockStub)
+	mockContext := new(MockContext)
+	mockContext.On("GetStub").Return(mockStub)
+	mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "admin", "admin"))
+
+	// Очікуємо виклики методів
+	mockStub.On("GetState", "resource:db1").Return([]byte(nil), nil)
+	mockStub.On("GetTxTimestamp").Return(&timestamp.Timestamp{Seconds: 1620000000}, nil)
+	mockStub.On("PutState", "resource:db1", mock.Anything).Return(nil)
+
+	// Створення об'єкту смарт-контракту і виклик методу
+	contract := new(SmartContract)
+	err := contract.RegisterResource(mockContext, "db1", "База даних", "database", "Org1MSP", `["read","write"]`)
+
+	// Перевірка результатів
+	assert.Nil(t, err)
+	mockStub.AssertExpectations(t)
+
+	call := mockStub.Calls[2] // Третій виклик - це PutState
+	var resource Resource
+	err = json.Unmarshal(call.Arguments[1].([]byte), &resource)
+	assert.Nil(t, err)
+	assert.Equal(t, "db1", resource.ID)
+	assert.Equal(t, []string{"read", "write"}, resource.RequiredPermissions)
+}
+
+// Тестування CheckAccess
+func TestCheckAccess(t *testing.T) {
+	testCases := []struct {
+		name     string
+		userJSON string
+		expected bool
+	}{
+		{
+			name:     "Ролі покривають усі дозволи",
+			userJSON: `{"id":"user1","name":"Іван","organization":"Org1MSP","roles":["reader","writer"],"status":"active"}`,
+			expected: true,
+		},
+		{
+			name:     "Бракує дозволу на запис",
+			userJSON: `{"id":"user1","name":"Іван","organization":"Org1MSP","roles":["reader"],"status":"active"}`,
+			expected: false,
+		},
+		{
+			name:     "Заблокований користувач",
+			userJSON: `{"id":"user1","name":"Іван","organization":"Org1MSP","roles":["reader","writer"],"status":"disabled"}`,
+			expected: false,
+		},
+	}
+
+	// Ресурс без необхідних дозволів недоступний
+	t.Run("Ресурс без дозволів", func(t *testing.T) {
+		mockStub := new(MockStub)
+		mockContext := new(MockContext)
+		mockContext.On("GetStub").Return(mockStub)
+		mockStub.On("GetState", "user:user1").Return([]byte(`{"id":"user1","roles":["reader"],"status":"active"}`), nil)
+		mockStub.On("GetState", "resource:open").Return([]byte(`{"id":"open","requiredPermissions":[]}`), nil)
+
+		contract := new(SmartContract)
+		result, err := contract.CheckAccess(mockContext, "user1", "open")
+		assert.Nil(t, err)
+		assert.False(t, result)
+	})
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Ініціалізація мок-об'єктів
+			mockStub := new(MockStub)
+			mockContext := new(MockContext)
+			mockContext.On("GetStub").Return(mockStub)
+
+			// Налаштування поведінки мок-об'єкта
+			mockStub.On("GetState", "user:user1").Return([]byte(tc.userJSON), nil)
+			mockStub.On("GetState", "resource:db1").Return([]byte(`{"id":"db1","requiredPermissions":["read","write"]}`), nil)
+			mockStub.On("GetState", "role:reader").Return([]byte(`{"id":"reader","permissions":["read"]}`), nil)
+			mockStub.On("GetState", "role:writer").Return([]byte(`{"id":"writer","permissions":["write"]}`), nil)
+
+			// Виклик методу
+			contract := new(SmartContract)
+			result, err := contract.CheckAccess(mockContext, "user1", "db1")
+
+			// Перевірка результатів
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+// Тестування CheckAccess для неіснуючого ресурсу
+func TestCheckAccessUnknownResource(t *testing.T) {
+	// Ініціалізація мок-об'єктів
+	mockStub := new(MockStub)
+	mockContext := new(MockContext)
+	mockContext.On("GetStub").Return(mockStub)
+
+	// Налаштування поведінки мок-об'єкта
+	mockStub.On("GetState", "user:user1").Return([]byte(`{"id":"user1","roles":[],"status":"active"}`), nil)
+	mockStub.On("GetState", "resource:missing").Return([]byte(nil), nil)
+
+	// Виклик методу
+	contract := new(SmartContract)
+	result, err := contract.CheckAccess(mockContext, "user1", "missing")
+
+	// Перевірка результатів
+	assert.NotNil(t, err)
+	assert.False(t, result)
+}
+
+// Тестування заборони адміністративних транзакцій для звичайного клієнта
+func TestAdminTransactionsRequireAdmin(t *testing.T) {
+	mockStub := new(MockStub)
+	mockContext := new(MockContext)
+	mockContext.On("GetStub").Return(mockStub)
+	mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+
+	contract := new(SmartContract)
+	assert.NotNil(t, contract.InitLedger(mockContext))
+	assert.NotNil(t, contract.CreatePermission(mockContext, "execute", "execute", ""))
+	assert.NotNil(t, contract.CreateRole(mockContext, "operator", "Оператор", `["read"]`))
+	assert.NotNil(t, contract.AssignPermissionToRole(mockContext, "user", "admin"))
+	assert.NotNil(t, contract.RegisterResource(mockContext, "db1", "База даних", "database", "Org1MSP", `["read"]`))
+	assert.NotNil(t, contract.CreateUser(mockContext, "user2", "Петро", "Org1MSP", `["admin"]`))
+	assert.NotNil(t, contract.DisableUser(mockContext, "user1"))
+	mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+}
+
+// Тестування повторного виклику InitLedger
+func TestInitLedgerOnce(t *testing.T) {
+	mockStub := new(MockStub)
+	mockContext := new(MockContext)
+	mockContext.On("GetStub").Return(mockStub)
+	mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "admin", "admin"))
+	mockStub.On("GetState", "config:initialized").Return([]byte("true"), nil)
+
+	contract := new(SmartContract)
+	err := contract.InitLedger(mockContext)
+
+	// Ролі, змінені після ініціалізації, не скидаються
+	assert.Nil(t, err)
+	mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+}
+
+// Тестування реєстрації ресурсу без необхідних дозволів
+func TestRegisterResourceWithoutPermissions(t *testing.T) {
+	mockStub := new(MockStub)
+	mockContext := new(MockContext)
+	mockContext.On("GetStub").Return(mockStub)
+	mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "admin", "admin"))
+	mockStub.On("GetState", "resource:db1").Return([]byte(nil), nil)
+
+	contract := new(SmartContract)
+	err := contract.RegisterResource(mockContext, "db1", "База даних", "database", "Org1MSP", `[]`)
+	assert.NotNil(t, err)
+	mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+}
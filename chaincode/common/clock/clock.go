@@ -0,0 +1,32 @@
+// Package clock надає детермінований час для смарт-контрактів.
+//
+// Усі часові поля у world state мають обчислюватися з часу транзакції,
+// а не з годинника піра, інакше endorsement-и різних організацій
+// розходяться і транзакція відхиляється.
+package clock
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// SecondsPerDay кількість секунд у добі
+const SecondsPerDay = 24 * 60 * 60
+
+// Now повертає час транзакції в секундах Unix, однаковий для всіх пірів
+func Now(stub shim.ChaincodeStubInterface) (int64, error) {
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return 0, fmt.Errorf("помилка отримання часу транзакції: %v", err)
+	}
+	if timestamp == nil {
+		return 0, fmt.Errorf("час транзакції відсутній")
+	}
+	return timestamp.GetSeconds(), nil
+}
+
+// AfterDays повертає момент, що настає через вказану кількість діб
+func AfterDays(from int64, days int) int64 {
+	return from + int64(days)*SecondsPerDay
+}
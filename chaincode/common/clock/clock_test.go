@@ -0,0 +1,51 @@
+// Файл: chaincode/common/clock/clock_test.go
+package clock
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockStub імітує ChainCodeStubInterface
+type MockStub struct {
+	mock.Mock
+	shim.ChaincodeStubInterface
+}
+
+func (s *MockStub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	args := s.Called()
+	return args.Get(0).(*timestamp.Timestamp), args.Error(1)
+}
+
+// Тестування Now
+func TestNow(t *testing.T) {
+	mockStub := new(MockStub)
+	mockStub.On("GetTxTimestamp").Return(&timestamp.Timestamp{Seconds: 1620000000, Nanos: 500}, nil)
+
+	now, err := Now(mockStub)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1620000000), now)
+	mockStub.AssertExpectations(t)
+}
+
+// Тестування Now при помилці отримання часу
+func TestNowError(t *testing.T) {
+	mockStub := new(MockStub)
+	mockStub.On("GetTxTimestamp").Return((*timestamp.Timestamp)(nil), errors.New("немає заголовка"))
+
+	_, err := Now(mockStub)
+
+	assert.NotNil(t, err)
+}
+
+// Тестування AfterDays
+func TestAfterDays(t *testing.T) {
+	assert.Equal(t, int64(1620000000+30*SecondsPerDay), AfterDays(1620000000, 30))
+	assert.Equal(t, int64(1620000000), AfterDays(1620000000, 0))
+}
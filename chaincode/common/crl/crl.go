@@ -0,0 +1,194 @@
+// Package crl формує списки відкликаних сертифікатів X.509 (RFC 5280).
+//
+// Смарт-контракт не має доступу до закритого ключа видавця, тому він
+// детерміновано будує лише тіло списку (TBSCertList) зі стану реєстру.
+// Видавець підписує його поза мережею функцією Sign і отримує CRL у DER.
+package crl
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// ReasonCodes коди причин відкликання CRLReason (RFC 5280, розділ 5.3.1)
+var ReasonCodes = map[string]int{
+	"unspecified":          0,
+	"keyCompromise":        1,
+	"cACompromise":         2,
+	"affiliationChanged":   3,
+	"superseded":           4,
+	"cessationOfOperation": 5,
+	"certificateHold":      6,
+	"removeFromCRL":        8,
+	"privilegeWithdrawn":   9,
+	"aACompromise":         10,
+}
+
+// Ідентифікатори розширень та алгоритмів підпису
+var (
+	oidExtensionCRLNumber       = asn1.ObjectIdentifier{2, 5, 29, 20}
+	oidExtensionReasonCode      = asn1.ObjectIdentifier{2, 5, 29, 21}
+	oidExtensionAuthorityKeyID  = asn1.ObjectIdentifier{2, 5, 29, 35}
+	oidSignatureSHA256WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSignatureECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidSignatureECDSAWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
+	oidSignatureECDSAWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+	oidSignatureEd25519         = asn1.ObjectIdentifier{1, 3, 101, 112}
+)
+
+// Entry запис про відкликаний сертифікат
+type Entry struct {
+	SerialNumber *big.Int
+	RevokedAt    time.Time
+	Reason       string // назва причини з ReasonCodes
+}
+
+// tbsCertList тіло списку відкликаних сертифікатів
+type tbsCertList struct {
+	Version             int
+	Signature           pkix.AlgorithmIdentifier
+	Issuer              asn1.RawValue
+	ThisUpdate          time.Time
+	NextUpdate          time.Time                 `asn1:"optional"`
+	RevokedCertificates []pkix.RevokedCertificate `asn1:"optional,omitempty"`
+	Extensions          []pkix.Extension          `asn1:"tag:0,optional,explicit"`
+}
+
+// certificateList підписаний список відкликаних сертифікатів
+type certificateList struct {
+	TBSCertList        asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	SignatureValue     asn1.BitString
+}
+
+// authorityKeyID розширення AuthorityKeyIdentifier з ідентифікатором ключа видавця
+type authorityKeyID struct {
+	ID []byte `asn1:"optional,tag:0"`
+}
+
+// BuildTBS формує DER тіла CRL версії 2 для видавця issuer.
+// Алгоритм підпису визначається відкритим ключем видавця; результат залежить лише від аргументів.
+func BuildTBS(issuer *x509.Certificate, entries []Entry, thisUpdate time.Time, nextUpdate time.Time, number *big.Int) ([]byte, error) {
+	algorithm, err := signatureAlgorithm(issuer.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked := make([]pkix.RevokedCertificate, 0, len(entries))
+	for _, entry := range entries {
+		code, known := ReasonCodes[entry.Reason]
+		if !known {
+			return nil, fmt.Errorf("невідома причина відкликання: %s", entry.Reason)
+		}
+		item := pkix.RevokedCertificate{SerialNumber: entry.SerialNumber, RevocationTime: entry.RevokedAt.UTC()}
+		// Причину unspecified не слід вказувати явно (RFC 5280, розділ 5.3.1)
+		if code != 0 {
+			value, err := asn1.Marshal(asn1.Enumerated(code))
+			if err != nil {
+				return nil, err
+			}
+			item.Extensions = []pkix.Extension{{Id: oidExtensionReasonCode, Value: value}}
+		}
+		revoked = append(revoked, item)
+	}
+
+	numberValue, err := asn1.Marshal(number)
+	if err != nil {
+		return nil, err
+	}
+	extensions := []pkix.Extension{{Id: oidExtensionCRLNumber, Value: numberValue}}
+	if len(issuer.SubjectKeyId) > 0 {
+		value, err := asn1.Marshal(authorityKeyID{ID: issuer.SubjectKeyId})
+		if err != nil {
+			return nil, err
+		}
+		extensions = append(extensions, pkix.Extension{Id: oidExtensionAuthorityKeyID, Value: value})
+	}
+
+	return asn1.Marshal(tbsCertList{
+		Version:             1, // v2
+		Signature:           algorithm,
+		Issuer:              asn1.RawValue{FullBytes: issuer.RawSubject},
+		ThisUpdate:          thisUpdate.UTC(),
+		NextUpdate:          nextUpdate.UTC(),
+		RevokedCertificates: revoked,
+		Extensions:          extensions,
+	})
+}
+
+// Sign підписує тіло CRL ключем видавця і повертає CRL у DER.
+// Ключ має відповідати алгоритму, вказаному в тілі.
+func Sign(tbs []byte, signer crypto.Signer) ([]byte, error) {
+	var body tbsCertList
+	if rest, err := asn1.Unmarshal(tbs, &body); err != nil || len(rest) > 0 {
+		return nil, errors.New("некоректне тіло CRL")
+	}
+
+	expected, err := signatureAlgorithm(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	if !expected.Algorithm.Equal(body.Signature.Algorithm) {
+		return nil, fmt.Errorf("ключ не відповідає алгоритму підпису CRL %v", body.Signature.Algorithm)
+	}
+
+	var digest []byte
+	var opts crypto.SignerOpts
+	switch {
+	case body.Signature.Algorithm.Equal(oidSignatureSHA256WithRSA), body.Signature.Algorithm.Equal(oidSignatureECDSAWithSHA256):
+		sum := sha256.Sum256(tbs)
+		digest, opts = sum[:], crypto.SHA256
+	case body.Signature.Algorithm.Equal(oidSignatureECDSAWithSHA384):
+		sum := sha512.Sum384(tbs)
+		digest, opts = sum[:], crypto.SHA384
+	case body.Signature.Algorithm.Equal(oidSignatureECDSAWithSHA512):
+		sum := sha512.Sum512(tbs)
+		digest, opts = sum[:], crypto.SHA512
+	default:
+		digest, opts = tbs, crypto.Hash(0) // Ed25519 підписує повідомлення без попереднього хешування
+	}
+
+	signature, err := signer.Sign(rand.Reader, digest, opts)
+	if err != nil {
+		return nil, fmt.Errorf("помилка підпису CRL: %v", err)
+	}
+
+	return asn1.Marshal(certificateList{
+		TBSCertList:        asn1.RawValue{FullBytes: tbs},
+		SignatureAlgorithm: body.Signature,
+		SignatureValue:     asn1.BitString{Bytes: signature, BitLength: len(signature) * 8},
+	})
+}
+
+// signatureAlgorithm повертає алгоритм підпису CRL для відкритого ключа видавця
+func signatureAlgorithm(publicKey crypto.PublicKey) (pkix.AlgorithmIdentifier, error) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return pkix.AlgorithmIdentifier{Algorithm: oidSignatureSHA256WithRSA, Parameters: asn1.NullRawValue}, nil
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return pkix.AlgorithmIdentifier{Algorithm: oidSignatureECDSAWithSHA256}, nil
+		case elliptic.P384():
+			return pkix.AlgorithmIdentifier{Algorithm: oidSignatureECDSAWithSHA384}, nil
+		case elliptic.P521():
+			return pkix.AlgorithmIdentifier{Algorithm: oidSignatureECDSAWithSHA512}, nil
+		}
+	case ed25519.PublicKey:
+		return pkix.AlgorithmIdentifier{Algorithm: oidSignatureEd25519}, nil
+	}
+	return pkix.AlgorithmIdentifier{}, fmt.Errorf("непідтримуваний ключ видавця %T", publicKey)
+}
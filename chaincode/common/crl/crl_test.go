@@ -0,0 +1,107 @@
+// Файл: chaincode/common/crl/crl_test.go
+package crl
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newIssuer створює самопідписаний сертифікат видавця з правом підпису CRL
+func newIssuer(t *testing.T, signer crypto.Signer) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Org1 CA", Organization: []string{"Org1"}},
+		NotBefore:             time.Unix(1600000000, 0),
+		NotAfter:              time.Unix(1900000000, 0),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		SubjectKeyId:          []byte{1, 2, 3, 4},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, signer.Public(), signer)
+	assert.Nil(t, err)
+	issuer, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	return issuer
+}
+
+// Тестування побудови та підпису CRL, який розбирає стандартна бібліотека
+func TestBuildTBSSign(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+
+	thisUpdate := time.Unix(1630000000, 0).UTC()
+	nextUpdate := thisUpdate.Add(7 * 24 * time.Hour)
+	entries := []Entry{
+		{SerialNumber: big.NewInt(100), RevokedAt: time.Unix(1629000000, 0), Reason: "keyCompromise"},
+		{SerialNumber: big.NewInt(101), RevokedAt: time.Unix(1629500000, 0), Reason: "unspecified"},
+	}
+
+	for name, signer := range map[string]crypto.Signer{"ECDSA": ecKey, "RSA": rsaKey, "Ed25519": edKey} {
+		t.Run(name, func(t *testing.T) {
+			issuer := newIssuer(t, signer)
+
+			tbs, err := BuildTBS(issuer, entries, thisUpdate, nextUpdate, big.NewInt(1630000000))
+			assert.Nil(t, err)
+
+			// Тіло детерміноване: однакові аргументи дають однакові байти на всіх пірах
+			again, err := BuildTBS(issuer, entries, thisUpdate, nextUpdate, big.NewInt(1630000000))
+			assert.Nil(t, err)
+			assert.Equal(t, tbs, again)
+
+			der, err := Sign(tbs, signer)
+			assert.Nil(t, err)
+
+			list, err := x509.ParseRevocationList(der)
+			assert.Nil(t, err)
+			assert.Nil(t, list.CheckSignatureFrom(issuer))
+			assert.Equal(t, issuer.RawSubject, list.RawIssuer)
+			assert.Equal(t, big.NewInt(1630000000), list.Number)
+			assert.Equal(t, []byte{1, 2, 3, 4}, list.AuthorityKeyId)
+			assert.True(t, thisUpdate.Equal(list.ThisUpdate))
+			assert.True(t, nextUpdate.Equal(list.NextUpdate))
+			assert.Len(t, list.RevokedCertificateEntries, 2)
+			assert.Equal(t, big.NewInt(100), list.RevokedCertificateEntries[0].SerialNumber)
+			assert.Equal(t, 1, list.RevokedCertificateEntries[0].ReasonCode)
+			assert.Equal(t, 0, list.RevokedCertificateEntries[1].ReasonCode)
+		})
+	}
+}
+
+// Тестування відмови підписати тіло ключем іншого алгоритму
+func TestSignAlgorithmMismatch(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.Nil(t, err)
+
+	tbs, err := BuildTBS(newIssuer(t, ecKey), nil, time.Unix(1630000000, 0), time.Unix(1630600000, 0), big.NewInt(1))
+	assert.Nil(t, err)
+
+	_, err = Sign(tbs, otherKey)
+	assert.NotNil(t, err)
+}
+
+// Тестування невідомої причини відкликання
+func TestBuildTBSUnknownReason(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	_, err = BuildTBS(newIssuer(t, ecKey), []Entry{{SerialNumber: big.NewInt(1), RevokedAt: time.Unix(1, 0), Reason: "lost"}}, time.Unix(1630000000, 0), time.Unix(1630600000, 0), big.NewInt(1))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,194 @@
+// Package merkle обчислює дерева Меркла за схемою RFC 6962 (розділ 2.1)
+// та будує докази включення і узгодженості.
+//
+// Листок і внутрішній вузол хешуються з різними префіксами (0x00 і 0x01),
+// тому значення внутрішнього вузла не можна видати за листок. Дерево з n
+// листків ділиться на ліве піддерево з k листків, де k - найбільший степінь
+// двійки, менший за n, і праве з рештою листків. Докази будуються з коренів
+// піддерев, які повертає SubtreeHashFunc, тож сховище може зберігати лише
+// вузли повних піддерев і не читати всі листки.
+package merkle
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// Префікси доменів хешування RFC 6962
+const (
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+)
+
+// LeafHash повертає хеш листка з даними data
+func LeafHash(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{leafPrefix})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// NodeHash повертає хеш внутрішнього вузла з хешами лівого і правого піддерев
+func NodeHash(left []byte, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{nodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// RootHash повертає корінь дерева (MTH) над даними листків
+func RootHash(leaves [][]byte) []byte {
+	hashes := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		hashes[i] = LeafHash(leaf)
+	}
+	return rootFromLeafHashes(hashes)
+}
+
+// rootFromLeafHashes повертає корінь дерева над уже обчисленими хешами листків
+func rootFromLeafHashes(hashes [][]byte) []byte {
+	switch len(hashes) {
+	case 0:
+		empty := sha256.Sum256(nil)
+		return empty[:]
+	case 1:
+		return hashes[0]
+	}
+	k := splitPoint(uint64(len(hashes)))
+	return NodeHash(rootFromLeafHashes(hashes[:k]), rootFromLeafHashes(hashes[k:]))
+}
+
+// splitPoint повертає найбільший степінь двійки, менший за n (n > 1)
+func splitPoint(n uint64) uint64 {
+	k := uint64(1)
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// SubtreeHashFunc повертає корінь піддерева над листками з індексами start..end-1
+type SubtreeHashFunc func(start uint64, end uint64) ([]byte, error)
+
+// InclusionProof будує доказ включення листка index у дерево з size листків (PATH, RFC 6962, 2.1.1).
+// Хеші доказу впорядковані від листка до кореня.
+func InclusionProof(index uint64, size uint64, subtree SubtreeHashFunc) ([][]byte, error) {
+	if index >= size {
+		return nil, fmt.Errorf("листок %d поза деревом з %d листків", index, size)
+	}
+	return inclusionPath(index, 0, size, subtree)
+}
+
+// inclusionPath будує доказ включення листка index у піддерево над листками start..end-1
+func inclusionPath(index uint64, start uint64, end uint64, subtree SubtreeHashFunc) ([][]byte, error) {
+	if end-start == 1 {
+		return [][]byte{}, nil
+	}
+	k := start + splitPoint(end-start)
+	var path [][]byte
+	var sibling []byte
+	var err error
+	if index < k {
+		if path, err = inclusionPath(index, start, k, subtree); err != nil {
+			return nil, err
+		}
+		sibling, err = subtree(k, end)
+	} else {
+		if path, err = inclusionPath(index, k, end, subtree); err != nil {
+			return nil, err
+		}
+		sibling, err = subtree(start, k)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(path, sibling), nil
+}
+
+// ConsistencyProof будує доказ того, що дерево з oldSize листків є префіксом дерева з newSize листків
+// (PROOF, RFC 6962, 2.1.2). Для oldSize 0 або oldSize, що дорівнює newSize, доказ порожній.
+func ConsistencyProof(oldSize uint64, newSize uint64, subtree SubtreeHashFunc) ([][]byte, error) {
+	if oldSize > newSize {
+		return nil, fmt.Errorf("дерево з %d листків не може бути префіксом дерева з %d листків", oldSize, newSize)
+	}
+	if oldSize == 0 || oldSize == newSize {
+		return [][]byte{}, nil
+	}
+	return consistencySubproof(oldSize, 0, newSize, true, subtree)
+}
+
+// consistencySubproof реалізує SUBPROOF(m, D[start:end], complete) з RFC 6962
+func consistencySubproof(m uint64, start uint64, end uint64, complete bool, subtree SubtreeHashFunc) ([][]byte, error) {
+	if m == end-start {
+		if complete {
+			return [][]byte{}, nil
+		}
+		hash, err := subtree(start, end)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{hash}, nil
+	}
+
+	k := splitPoint(end - start)
+	var proof [][]byte
+	var sibling []byte
+	var err error
+	if m <= k {
+		if proof, err = consistencySubproof(m, start, start+k, complete, subtree); err != nil {
+			return nil, err
+		}
+		sibling, err = subtree(start+k, end)
+	} else {
+		if proof, err = consistencySubproof(m-k, start+k, end, false, subtree); err != nil {
+			return nil, err
+		}
+		sibling, err = subtree(start, start+k)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(proof, sibling), nil
+}
+
+// NodeFunc повертає збережений вузол повного піддерева на рівні level з індексом index;
+// листки мають рівень 0
+type NodeFunc func(level uint, index uint64) ([]byte, error)
+
+// StoredSubtreeHash повертає SubtreeHashFunc, що обчислює корені піддерев зі збережених вузлів.
+// Повне вирівняне піддерево читається одним вузлом, неповне ділиться так само, як у MTH.
+func StoredSubtreeHash(node NodeFunc) SubtreeHashFunc {
+	var subtree SubtreeHashFunc
+	subtree = func(start uint64, end uint64) ([]byte, error) {
+		if level, ok := perfectSubtree(start, end); ok {
+			return node(level, start>>level)
+		}
+		k := start + splitPoint(end-start)
+		left, err := subtree(start, k)
+		if err != nil {
+			return nil, err
+		}
+		right, err := subtree(k, end)
+		if err != nil {
+			return nil, err
+		}
+		return NodeHash(left, right), nil
+	}
+	return subtree
+}
+
+// perfectSubtree повертає рівень піддерева над листками start..end-1, якщо воно повне
+// і вирівняне за своїм розміром, тобто є вузлом дерева на цьому рівні з індексом start>>level
+func perfectSubtree(start uint64, end uint64) (uint, bool) {
+	size := end - start
+	if size == 0 || size&(size-1) != 0 || start%size != 0 {
+		return 0, false
+	}
+	level := uint(0)
+	for size > 1 {
+		size >>= 1
+		level++
+	}
+	return level, true
+}
@@ -0,0 +1,137 @@
+// Файл: chaincode/common/merkle/merkle_test.go
+package merkle
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testLeaves дані листків з тестових векторів Certificate Transparency
+var testLeaves = [][]byte{
+	{},
+	{0x00},
+	{0x10},
+	{0x20, 0x21},
+	{0x30, 0x31},
+	{0x40, 0x41, 0x42, 0x43},
+	{0x50, 0x51, 0x52, 0x53, 0x54, 0x55, 0x56, 0x57},
+	{0x60, 0x61, 0x62, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68, 0x69, 0x6a, 0x6b, 0x6c, 0x6d, 0x6e, 0x6f},
+}
+
+// Тестування кореня дерева на тестових векторах
+func TestRootHash(t *testing.T) {
+	testCases := []struct {
+		name   string
+		leaves [][]byte
+		root   string
+	}{
+		{name: "Порожнє дерево", leaves: nil, root: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{name: "Один порожній листок", leaves: testLeaves[:1], root: "6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d"},
+		{name: "Вісім листків", leaves: testLeaves, root: "5dc9da79a70659a9ad559cb701ded9a2ab9d823aad2f4960cfe370eff4604328"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.root, hex.EncodeToString(RootHash(tc.leaves)))
+		})
+	}
+}
+
+// Тестування поділу дерева з кількістю листків, що не є степенем двійки
+func TestRootHashUnbalanced(t *testing.T) {
+	leaves := testLeaves[:5]
+	left := NodeHash(NodeHash(LeafHash(leaves[0]), LeafHash(leaves[1])), NodeHash(LeafHash(leaves[2]), LeafHash(leaves[3])))
+	assert.Equal(t, NodeHash(left, LeafHash(leaves[4])), RootHash(leaves))
+}
+
+// memorySubtree повертає корені піддерев над листками в пам'яті
+func memorySubtree(leaves [][]byte) SubtreeHashFunc {
+	return func(start uint64, end uint64) ([]byte, error) {
+		return RootHash(leaves[start:end]), nil
+	}
+}
+
+// Тестування доказів включення для дерева з п'яти листків
+func TestInclusionProof(t *testing.T) {
+	leaves := testLeaves[:5]
+	subtree := memorySubtree(leaves)
+
+	proof, err := InclusionProof(0, 5, subtree)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{LeafHash(leaves[1]), RootHash(leaves[2:4]), LeafHash(leaves[4])}, proof)
+
+	proof, err = InclusionProof(4, 5, subtree)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{RootHash(leaves[:4])}, proof)
+
+	proof, err = InclusionProof(0, 1, subtree)
+	assert.Nil(t, err)
+	assert.Empty(t, proof)
+
+	_, err = InclusionProof(5, 5, subtree)
+	assert.NotNil(t, err)
+}
+
+// Тестування доказів узгодженості
+func TestConsistencyProof(t *testing.T) {
+	leaves := testLeaves[:5]
+	subtree := memorySubtree(leaves)
+
+	// Старе дерево з 3 листків не є повним піддеревом: доказ містить хеші його складових
+	proof, err := ConsistencyProof(3, 5, subtree)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{LeafHash(leaves[2]), LeafHash(leaves[3]), RootHash(leaves[:2]), LeafHash(leaves[4])}, proof)
+
+	// Старе дерево - повне піддерево нового: його корінь у доказ не входить
+	proof, err = ConsistencyProof(4, 5, subtree)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{LeafHash(leaves[4])}, proof)
+
+	proof, err = ConsistencyProof(5, 5, subtree)
+	assert.Nil(t, err)
+	assert.Empty(t, proof)
+
+	_, err = ConsistencyProof(6, 5, subtree)
+	assert.NotNil(t, err)
+}
+
+// Тестування розпізнавання вузлів повних піддерев
+func TestPerfectSubtree(t *testing.T) {
+	level, ok := perfectSubtree(8, 12)
+	assert.True(t, ok)
+	assert.Equal(t, uint(2), level)
+
+	level, ok = perfectSubtree(5, 6)
+	assert.True(t, ok)
+	assert.Equal(t, uint(0), level)
+
+	_, ok = perfectSubtree(4, 7)
+	assert.False(t, ok)
+	_, ok = perfectSubtree(2, 6)
+	assert.False(t, ok)
+}
+
+// Тестування обчислення коренів піддерев зі збережених вузлів
+func TestStoredSubtreeHash(t *testing.T) {
+	nodes := map[[2]uint64][]byte{}
+	for level := uint(0); 1<<level <= len(testLeaves); level++ {
+		size := 1 << level
+		for index := 0; (index+1)*size <= len(testLeaves); index++ {
+			nodes[[2]uint64{uint64(level), uint64(index)}] = RootHash(testLeaves[index*size : (index+1)*size])
+		}
+	}
+	subtree := StoredSubtreeHash(func(level uint, index uint64) ([]byte, error) {
+		return nodes[[2]uint64{uint64(level), index}], nil
+	})
+
+	for size := 1; size <= len(testLeaves); size++ {
+		root, err := subtree(0, uint64(size))
+		assert.Nil(t, err)
+		assert.Equal(t, RootHash(testLeaves[:size]), root)
+	}
+	root, err := subtree(3, 7)
+	assert.Nil(t, err)
+	assert.Equal(t, RootHash(testLeaves[3:7]), root)
+}
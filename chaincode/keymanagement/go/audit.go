@@ -0,0 +1,265 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+
+    "github.com/hyperledger/fabric-chaincode-go/shim"
+    "github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+    "blockchain-security/chaincode/common/clock"
+)
+
+// Ключ налаштувань аудиту у world state
+const auditConfigKey = "config:audit"
+
+// Смарт-контракт аудиту та канал, у якому він розгорнутий, за замовчуванням.
+// Записи виклику чейнкоду фіксуються лише в межах того самого каналу, тому
+// keymanagement і securityaudit мають бути розгорнуті в одному каналі;
+// порожній канал означає канал транзакції.
+const (
+    defaultAuditChaincode = "securityaudit"
+    defaultAuditChannel   = ""
+)
+
+// Кількість організацій, адміністратори яких мають схвалити зміну налаштувань аудиту
+const auditConfigApprovals = 2
+
+// Тип подій аудиту операцій з ключами; збігається з типом подій виконавця ротацій
+const auditEventType = "key_operation"
+
+// Дії з ключами, що фіксуються в журналі аудиту
+const (
+    auditActionGenerate     = "generate"
+    auditActionGrant        = "grant"
+    auditActionRevokeAccess = "revoke_access"
+    auditActionRevoke       = "revoke"
+    auditActionRotate       = "rotate"
+    auditActionDestroy      = "destroy"
+    auditActionStatus       = "status" // зворотна зміна стану: активація, призупинення
+)
+
+// auditActions перелік дій, невдалі спроби яких можна зафіксувати через RecordFailedKeyOperation
+var auditActions = []string{auditActionGenerate, auditActionGrant, auditActionRevokeAccess, auditActionRevoke, auditActionRotate, auditActionDestroy, auditActionStatus}
+
+// Результати операцій у подіях аудиту
+const (
+    auditResultSuccess = "success"
+    auditResultFailure = "failure"
+    auditResultDenied  = "denied"
+)
+
+// Максимальна довжина опису помилки невдалої спроби
+const maxAuditReasonLength = 1024
+
+// AuditConfig налаштування запису подій аудиту в смарт-контракт securityaudit
+type AuditConfig struct {
+    ChaincodeName string `json:"chaincodeName"` // порожня назва вимикає виклик, події лише публікуються локально
+    Channel       string `json:"channel"`       // порожній - канал транзакції
+    ProposalID    string `json:"proposalId,omitempty"` // схвалена пропозиція, що встановила налаштування
+    UpdatedBy     string `json:"updatedBy,omitempty"`
+    UpdatedAt     int64  `json:"updatedAt,omitempty"`
+}
+
+// KeyAuditRecord подія аудиту операції з ключем; відповідає аргументам RecordEvent смарт-контракту securityaudit
+type KeyAuditRecord struct {
+    EventType string            `json:"eventType"`
+    Actor     string            `json:"actor"`
+    Resource  string            `json:"resource"`
+    Action    string            `json:"action"`
+    Result    string            `json:"result"`
+    Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// ProposeAuditConfig створює пропозицію змінити смарт-контракт і канал, у які записуються
+// події аудиту. Налаштування змінюються лише після схвалення адміністраторами
+// auditConfigApprovals різних організацій; голос організації ініціатора зараховується одразу.
+func (s *SmartContract) ProposeAuditConfig(ctx contractapi.TransactionContextInterface, chaincodeName string, channel string) (*KeyOperationProposal, error) {
+    mspID, err := authorizeOrgAdmin(ctx)
+    if err != nil {
+        return nil, err
+    }
+    if channel != "" && channel != ctx.GetStub().GetChannelID() {
+        return nil, invalidArgument("channel", "смарт-контракт аудиту має бути розгорнутий у каналі %s: записи в інших каналах не фіксуються", ctx.GetStub().GetChannelID())
+    }
+
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return nil, err
+    }
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return nil, err
+    }
+
+    parameter, err := json.Marshal(AuditConfig{ChaincodeName: chaincodeName, Channel: channel})
+    if err != nil {
+        return nil, err
+    }
+    proposal := &KeyOperationProposal{
+        ID:         ctx.GetStub().GetTxID(),
+        Operation:  proposalAuditConfig,
+        Parameter:  string(parameter),
+        Threshold:  auditConfigApprovals,
+        Status:     proposalPending,
+        ProposedBy: caller,
+        CreatedAt:  now,
+        ExpiresAt:  clock.AfterDays(now, proposalLifetimeDays),
+        Votes:      []ProposalVote{},
+    }
+
+    return recordAuditConfigVote(ctx, proposal, mspID, now)
+}
+
+// ApproveAuditConfig додає голос організації адміністратора; при досягненні кворуму
+// налаштування аудиту змінюються
+func (s *SmartContract) ApproveAuditConfig(ctx contractapi.TransactionContextInterface, proposalID string) (*KeyOperationProposal, error) {
+    mspID, err := authorizeOrgAdmin(ctx)
+    if err != nil {
+        return nil, err
+    }
+    proposal, err := readProposal(ctx, proposalID)
+    if err != nil {
+        return nil, err
+    }
+    if proposal.Operation != proposalAuditConfig {
+        return nil, fmt.Errorf("пропозиція %s не змінює налаштування аудиту", proposalID)
+    }
+
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return nil, err
+    }
+    if status := effectiveProposalStatus(proposal, now); status != proposalPending {
+        return nil, fmt.Errorf("пропозиція %s має стан %s", proposalID, status)
+    }
+    for _, vote := range proposal.Votes {
+        if vote.Voter == mspID {
+            return nil, fmt.Errorf("організація %s вже проголосувала за пропозицію %s", mspID, proposalID)
+        }
+    }
+
+    return recordAuditConfigVote(ctx, proposal, mspID, now)
+}
+
+// recordAuditConfigVote зараховує голос організації і зберігає нові налаштування аудиту,
+// якщо пропозицію схвалили auditConfigApprovals організацій
+func recordAuditConfigVote(ctx contractapi.TransactionContextInterface, proposal *KeyOperationProposal, mspID string, now int64) (*KeyOperationProposal, error) {
+    proposal.Votes = append(proposal.Votes, ProposalVote{
+        Voter:   mspID,
+        VotedAt: now,
+        TxID:    ctx.GetStub().GetTxID(),
+    })
+
+    if len(proposal.Votes) >= proposal.Threshold {
+        var config AuditConfig
+        if err := json.Unmarshal([]byte(proposal.Parameter), &config); err != nil {
+            return nil, fmt.Errorf("помилка при розборі налаштувань аудиту: %v", err)
+        }
+        config.ProposalID = proposal.ID
+        config.UpdatedBy = proposal.ProposedBy
+        config.UpdatedAt = now
+        configJSON, err := json.Marshal(config)
+        if err != nil {
+            return nil, err
+        }
+        if err := ctx.GetStub().PutState(auditConfigKey, configJSON); err != nil {
+            return nil, fmt.Errorf("помилка збереження налаштувань аудиту: %v", err)
+        }
+        proposal.Status = proposalExecuted
+        proposal.ExecutedAt = now
+    }
+
+    if err := putProposal(ctx, proposal); err != nil {
+        return nil, err
+    }
+    return proposal, nil
+}
+
+// GetAuditConfig повертає чинні налаштування аудиту
+func (s *SmartContract) GetAuditConfig(ctx contractapi.TransactionContextInterface) (*AuditConfig, error) {
+    return readAuditConfig(ctx)
+}
+
+// RecordFailedKeyOperation фіксує невдалу або відхилену спробу операції з ключем.
+// Fabric не фіксує записи транзакції, що завершилася помилкою, тому клієнт, чию
+// транзакцію відхилено, повідомляє про спробу окремою транзакцією. Актором події
+// завжди є сам клієнт.
+func (s *SmartContract) RecordFailedKeyOperation(ctx contractapi.TransactionContextInterface, action string, keyID string, result string, reason string) error {
+    if !containsString(auditActions, action) {
+        return invalidArgument("action", "невідома дія: %s", action)
+    }
+    if err := validateKeyID(keyID); err != nil {
+        return err
+    }
+    if result != auditResultFailure && result != auditResultDenied {
+        return invalidArgument("result", "результат має бути %s або %s", auditResultFailure, auditResultDenied)
+    }
+    if len(reason) > maxAuditReasonLength {
+        return invalidArgument("reason", "опис помилки довший за %d символів", maxAuditReasonLength)
+    }
+
+    var metadata map[string]string
+    if reason != "" {
+        metadata = map[string]string{"error": reason}
+    }
+    return recordKeyAudit(ctx, action, keyID, result, metadata)
+}
+
+// recordKeyAudit записує подію аудиту операції з ключем у смарт-контракт securityaudit.
+// Якщо смарт-контракт аудиту вимкнено або він недоступний, подія публікується разом
+// з подіями ключів транзакції, щоб її міг отримати зовнішній слухач.
+// Смарт-контракт аудиту ідентифікує подію за транзакцією, тому транзакція записує одну подію.
+func recordKeyAudit(ctx contractapi.TransactionContextInterface, action string, keyID string, result string, metadata map[string]string) error {
+    actor, err := callerIdentity(ctx)
+    if err != nil {
+        return err
+    }
+    record := KeyAuditRecord{EventType: auditEventType, Actor: actor, Resource: keyID, Action: action, Result: result, Metadata: metadata}
+
+    config, err := readAuditConfig(ctx)
+    if err != nil {
+        return err
+    }
+    if config.ChaincodeName != "" {
+        var metadataJSON []byte
+        if len(record.Metadata) > 0 {
+            metadataJSON, err = json.Marshal(record.Metadata)
+            if err != nil {
+                return err
+            }
+        }
+        args := [][]byte{
+            []byte("RecordEvent"),
+            []byte(record.EventType),
+            []byte(record.Actor),
+            []byte(record.Resource),
+            []byte(record.Action),
+            []byte(record.Result),
+            metadataJSON,
+        }
+        response := ctx.GetStub().InvokeChaincode(config.ChaincodeName, args, config.Channel)
+        if response.Status == shim.OK {
+            return nil
+        }
+    }
+
+    return emitKeyAudit(ctx, record)
+}
+
+// readAuditConfig читає налаштування аудиту; без збережених налаштувань діють значення за замовчуванням
+func readAuditConfig(ctx contractapi.TransactionContextInterface) (*AuditConfig, error) {
+    configJSON, err := ctx.GetStub().GetState(auditConfigKey)
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання налаштувань аудиту: %v", err)
+    }
+    if configJSON == nil {
+        return &AuditConfig{ChaincodeName: defaultAuditChaincode, Channel: defaultAuditChannel}, nil
+    }
+
+    var config AuditConfig
+    if err := json.Unmarshal(configJSON, &config); err != nil {
+        return nil, fmt.Errorf("помилка десеріалізації налаштувань аудиту: %v", err)
+    }
+    return &config, nil
+}
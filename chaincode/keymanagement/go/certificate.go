@@ -0,0 +1,673 @@
+package main
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "crypto/x509"
+    "encoding/base64"
+    "encoding/hex"
+    "encoding/json"
+    "encoding/pem"
+    "fmt"
+    "math/big"
+    "time"
+
+    "github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+    "blockchain-security/chaincode/common/clock"
+    "blockchain-security/chaincode/common/crl"
+)
+
+// Префікс для сертифікатів центрів сертифікації у world state
+const caCertPrefix = "cacert:"
+
+// Кінець діапазону сертифікатів центрів сертифікації (наступний символ після ':')
+const caCertRangeEnd = "cacert;"
+
+// Стани сертифіката
+const (
+    certStatusValid   = "valid"
+    certStatusRevoked = "revoked"
+    certStatusUnknown = "unknown" // сертифікат не зареєстровано
+)
+
+// Строк дії CRL у днях: видавець має опублікувати новий список до NextUpdate
+const crlValidityDays = 7
+
+// CACertificate сертифікат центру сертифікації організації
+type CACertificate struct {
+    ID           string `json:"id"`    // SHA-256 від DER сертифіката, hex
+    MSPID        string `json:"mspId"` // організація, якій належить центр сертифікації
+    Subject      string `json:"subject"`
+    PEM          string `json:"pem"`
+    Root         bool   `json:"root"` // false - проміжний центр, зареєстрований разом із сертифікатом
+    NotAfter     int64  `json:"notAfter"`
+    RegisteredBy string `json:"registeredBy"`
+    RegisteredAt int64  `json:"registeredAt"`
+}
+
+// CertificateRecord сертифікат X.509, виданий для CryptoKey
+type CertificateRecord struct {
+    IssuerID         string `json:"issuerId"`     // ID сертифіката видавця
+    SerialNumber     string `json:"serialNumber"` // серійний номер, hex
+    KeyID            string `json:"keyId"`
+    Subject          string `json:"subject"`
+    Fingerprint      string `json:"fingerprint"` // SHA-256 від DER сертифіката, hex
+    NotBefore        int64  `json:"notBefore"`
+    NotAfter         int64  `json:"notAfter"`
+    PEM              string `json:"pem"`
+    Status           string `json:"status"` // valid, revoked
+    RevokedAt        int64  `json:"revokedAt,omitempty"`
+    RevocationReason string `json:"revocationReason,omitempty"` // код причини за RFC 5280
+    RegisteredBy     string `json:"registeredBy"`
+    RegisteredAt     int64  `json:"registeredAt"`
+}
+
+// CertificateStatus стан сертифіката для служб перевірки статусу (OCSP)
+type CertificateStatus struct {
+    IssuerID         string `json:"issuerId"`
+    SerialNumber     string `json:"serialNumber"`
+    Status           string `json:"status"` // valid, revoked, unknown
+    RevokedAt        int64  `json:"revokedAt,omitempty"`
+    RevocationReason string `json:"revocationReason,omitempty"`
+    CheckedAt        int64  `json:"checkedAt"` // час транзакції, на який визначено стан
+}
+
+// CertificateRevocationList список відкликаних сертифікатів видавця
+type CertificateRevocationList struct {
+    IssuerID    string               `json:"issuerId"`
+    Number      int64                `json:"number"` // номер CRL - час транзакції, тому він зростає
+    ThisUpdate  int64                `json:"thisUpdate"`
+    NextUpdate  int64                `json:"nextUpdate"`
+    Entries     []*CertificateRecord `json:"entries"`
+    TBSCertList string               `json:"tbsCertList"` // DER тіла CRL у base64
+}
+
+// RegisterCARoot реєструє самопідписаний кореневий сертифікат центру сертифікації.
+// Реєструє лише адміністратор організації; центр закріплюється за його MSP.
+func (s *SmartContract) RegisterCARoot(ctx contractapi.TransactionContextInterface, certPEM string) (*CACertificate, error) {
+    cert, err := parseCertificatePEM("certPEM", certPEM)
+    if err != nil {
+        return nil, err
+    }
+    if !cert.IsCA || !cert.BasicConstraintsValid {
+        return nil, invalidArgument("certPEM", "сертифікат %s не є сертифікатом центру сертифікації", cert.Subject)
+    }
+    if !bytes.Equal(cert.RawIssuer, cert.RawSubject) || cert.CheckSignatureFrom(cert) != nil {
+        return nil, invalidArgument("certPEM", "сертифікат %s не є самопідписаним", cert.Subject)
+    }
+
+    mspID, err := authorizeOrgAdmin(ctx)
+    if err != nil {
+        return nil, err
+    }
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return nil, err
+    }
+    if !certificateValidAt(cert, now) {
+        return nil, invalidArgument("certPEM", "сертифікат %s недійсний на час транзакції", cert.Subject)
+    }
+
+    existing, err := readCACertificateIfExists(ctx, certificateID(cert))
+    if err != nil {
+        return nil, err
+    }
+    if existing != nil {
+        return nil, &ValidationError{Kind: ErrAlreadyExists, Field: "certPEM", Message: fmt.Sprintf("сертифікат %s вже зареєстровано", existing.ID)}
+    }
+
+    record := newCACertificate(cert, mspID, true, caller, now)
+    if err := putCACertificate(ctx, record); err != nil {
+        return nil, err
+    }
+    return record, nil
+}
+
+// RegisterCertificate закріплює сертифікат X.509 за асиметричним CryptoKey.
+// Сертифікат має містити зареєстрований відкритий ключ і підтверджуватися ланцюжком
+// до зареєстрованого кореневого сертифіката; chainPEM містить проміжні сертифікати.
+func (s *SmartContract) RegisterCertificate(ctx contractapi.TransactionContextInterface, keyID string, certPEM string, chainPEM string) (*CertificateRecord, error) {
+    cert, err := parseCertificatePEM("certPEM", certPEM)
+    if err != nil {
+        return nil, err
+    }
+    intermediates, err := parseCertificatePool("chainPEM", chainPEM)
+    if err != nil {
+        return nil, err
+    }
+
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return nil, err
+    }
+    if key.Type != keyTypeAsymmetric {
+        return nil, invalidArgument("keyID", "ключ %s не є асиметричним", keyID)
+    }
+
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return nil, err
+    }
+    if err := authorizeKeyOwner(key, caller); err != nil {
+        return nil, err
+    }
+
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return nil, err
+    }
+    if status := effectiveKeyStatus(key, now); status != statusPreActivation && status != statusActive {
+        return nil, fmt.Errorf("ключ %s у стані %s не приймає сертифікати", keyID, status)
+    }
+
+    // Сертифікат має бути виданий саме для закріпленого відкритого ключа
+    publicKey, err := readPublicKey(ctx, keyID)
+    if err != nil {
+        return nil, err
+    }
+    fingerprint := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+    if hex.EncodeToString(fingerprint[:]) != publicKey.Fingerprint {
+        return nil, invalidArgument("certPEM", "сертифікат видано не для відкритого ключа %s", keyID)
+    }
+
+    chain, roots, err := verifyCertificateChain(ctx, cert, intermediates, now)
+    if err != nil {
+        return nil, err
+    }
+
+    // Проміжний видавець реєструється разом із сертифікатом і належить організації кореневого центру
+    issuer := chain[1]
+    issuerID := certificateID(issuer)
+    if _, isRoot := roots[issuerID]; !isRoot {
+        existing, err := readCACertificateIfExists(ctx, issuerID)
+        if err != nil {
+            return nil, err
+        }
+        if existing == nil {
+            root := roots[certificateID(chain[len(chain)-1])]
+            if err := putCACertificate(ctx, newCACertificate(issuer, root.MSPID, false, caller, now)); err != nil {
+                return nil, err
+            }
+        }
+    }
+
+    serialNumber := cert.SerialNumber.Text(16)
+    certKey, err := ctx.GetStub().CreateCompositeKey(certObjectType, []string{issuerID, serialNumber})
+    if err != nil {
+        return nil, fmt.Errorf("помилка створення ключа сертифіката: %v", err)
+    }
+    existing, err := ctx.GetStub().GetState(certKey)
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання сертифіката: %v", err)
+    }
+    if existing != nil {
+        return nil, &ValidationError{Kind: ErrAlreadyExists, Field: "certPEM", Message: fmt.Sprintf("сертифікат %s видавця %s вже зареєстровано", serialNumber, issuerID)}
+    }
+
+    certFingerprint := sha256.Sum256(cert.Raw)
+    record := &CertificateRecord{
+        IssuerID:     issuerID,
+        SerialNumber: serialNumber,
+        KeyID:        keyID,
+        Subject:      cert.Subject.String(),
+        Fingerprint:  hex.EncodeToString(certFingerprint[:]),
+        NotBefore:    cert.NotBefore.Unix(),
+        NotAfter:     cert.NotAfter.Unix(),
+        PEM:          string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})),
+        Status:       certStatusValid,
+        RegisteredBy: caller,
+        RegisteredAt: now,
+    }
+    if err := putCertificate(ctx, record); err != nil {
+        return nil, err
+    }
+
+    indexKey, err := ctx.GetStub().CreateCompositeKey(certByKeyIndex, []string{keyID, issuerID, serialNumber})
+    if err != nil {
+        return nil, fmt.Errorf("помилка створення індексу сертифікатів: %v", err)
+    }
+    if err := ctx.GetStub().PutState(indexKey, indexValue); err != nil {
+        return nil, err
+    }
+
+    return record, nil
+}
+
+// RevokeCertificate відкликає сертифікат з причиною за RFC 5280.
+// Відкликати може власник ключа або адміністратор організації центру сертифікації.
+func (s *SmartContract) RevokeCertificate(ctx contractapi.TransactionContextInterface, issuerID string, serialNumber string, reasonCode string) error {
+    if !containsString(revocationReasons, reasonCode) {
+        return invalidArgument("reasonCode", "невідома причина відкликання: %s", reasonCode)
+    }
+
+    record, err := readCertificate(ctx, issuerID, serialNumber)
+    if err != nil {
+        return err
+    }
+    key, err := readKey(ctx, record.KeyID)
+    if err != nil {
+        return err
+    }
+    if effectiveCertificateStatus(record, key).Status == certStatusRevoked {
+        return fmt.Errorf("сертифікат %s видавця %s вже відкликано", serialNumber, issuerID)
+    }
+
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return err
+    }
+    if !isKeyOwner(key, caller) {
+        ca, err := readCACertificate(ctx, issuerID)
+        if err != nil {
+            return err
+        }
+        mspID, err := authorizeOrgAdmin(ctx)
+        if err != nil || mspID != ca.MSPID {
+            return fmt.Errorf("клієнт %s не може відкликати сертифікат %s видавця %s", caller, serialNumber, issuerID)
+        }
+    }
+
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return err
+    }
+
+    record.Status = certStatusRevoked
+    record.RevokedAt = now
+    record.RevocationReason = reasonCode
+    return putCertificate(ctx, record)
+}
+
+// GetCACertificate повертає зареєстрований сертифікат центру сертифікації
+func (s *SmartContract) GetCACertificate(ctx contractapi.TransactionContextInterface, issuerID string) (*CACertificate, error) {
+    return readCACertificate(ctx, issuerID)
+}
+
+// GetCertificate повертає сертифікат зі станом, що враховує стан його ключа
+func (s *SmartContract) GetCertificate(ctx contractapi.TransactionContextInterface, issuerID string, serialNumber string) (*CertificateRecord, error) {
+    record, err := readCertificate(ctx, issuerID, serialNumber)
+    if err != nil {
+        return nil, err
+    }
+    key, err := readKey(ctx, record.KeyID)
+    if err != nil {
+        return nil, err
+    }
+    return effectiveCertificateStatus(record, key), nil
+}
+
+// GetCertificateStatus повертає стан сертифіката на час транзакції.
+// На відміну від GetCertificate, незареєстрований сертифікат має стан unknown, а не помилку.
+func (s *SmartContract) GetCertificateStatus(ctx contractapi.TransactionContextInterface, issuerID string, serialNumber string) (*CertificateStatus, error) {
+    if _, err := parseSerialNumber(serialNumber); err != nil {
+        return nil, err
+    }
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return nil, err
+    }
+
+    status := &CertificateStatus{IssuerID: issuerID, SerialNumber: serialNumber, Status: certStatusUnknown, CheckedAt: now}
+    certKey, err := ctx.GetStub().CreateCompositeKey(certObjectType, []string{issuerID, serialNumber})
+    if err != nil {
+        return nil, fmt.Errorf("помилка створення ключа сертифіката: %v", err)
+    }
+    recordJSON, err := ctx.GetStub().GetState(certKey)
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання сертифіката: %v", err)
+    }
+    if recordJSON == nil {
+        return status, nil
+    }
+
+    var record CertificateRecord
+    if err := json.Unmarshal(recordJSON, &record); err != nil {
+        return nil, fmt.Errorf("помилка десеріалізації сертифіката: %v", err)
+    }
+    key, err := readKey(ctx, record.KeyID)
+    if err != nil {
+        return nil, err
+    }
+
+    effective := effectiveCertificateStatus(&record, key)
+    status.Status = effective.Status
+    status.RevokedAt = effective.RevokedAt
+    status.RevocationReason = effective.RevocationReason
+    return status, nil
+}
+
+// GetCertificatesByKey повертає всі сертифікати, видані для ключа
+func (s *SmartContract) GetCertificatesByKey(ctx contractapi.TransactionContextInterface, keyID string) ([]*CertificateRecord, error) {
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return nil, err
+    }
+
+    iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(certByKeyIndex, []string{keyID})
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання індексу сертифікатів: %v", err)
+    }
+    defer iterator.Close()
+
+    records := []*CertificateRecord{}
+    for iterator.HasNext() {
+        kv, err := iterator.Next()
+        if err != nil {
+            return nil, fmt.Errorf("помилка читання індексу: %v", err)
+        }
+        _, attributes, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+        if err != nil || len(attributes) != 3 {
+            return nil, fmt.Errorf("пошкоджений запис індексу %q", kv.Key)
+        }
+        record, err := readCertificate(ctx, attributes[1], attributes[2])
+        if err != nil {
+            return nil, err
+        }
+        records = append(records, effectiveCertificateStatus(record, key))
+    }
+
+    return records, nil
+}
+
+// GetCRL формує список відкликаних сертифікатів видавця зі стану реєстру.
+// Закритий ключ центру сертифікації не зберігається в мережі, тому запит повертає
+// детерміноване тіло CRL у DER; видавець підписує його функцією crl.Sign.
+// Сертифікати, строк дії яких минув, до списку не включаються.
+func (s *SmartContract) GetCRL(ctx contractapi.TransactionContextInterface, issuerID string) (*CertificateRevocationList, error) {
+    ca, err := readCACertificate(ctx, issuerID)
+    if err != nil {
+        return nil, err
+    }
+    issuer, err := parseCertificatePEM("issuerID", ca.PEM)
+    if err != nil {
+        return nil, err
+    }
+
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return nil, err
+    }
+
+    iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(certObjectType, []string{issuerID})
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання сертифікатів: %v", err)
+    }
+    defer iterator.Close()
+
+    list := &CertificateRevocationList{
+        IssuerID:   issuerID,
+        Number:     now,
+        ThisUpdate: now,
+        NextUpdate: clock.AfterDays(now, crlValidityDays),
+        Entries:    []*CertificateRecord{},
+    }
+    var entries []crl.Entry
+    keys := make(map[string]*CryptoKey)
+    for iterator.HasNext() {
+        kv, err := iterator.Next()
+        if err != nil {
+            return nil, fmt.Errorf("помилка читання сертифіката: %v", err)
+        }
+        var record CertificateRecord
+        if err := json.Unmarshal(kv.Value, &record); err != nil {
+            return nil, fmt.Errorf("помилка десеріалізації сертифіката: %v", err)
+        }
+        if record.NotAfter <= now {
+            continue
+        }
+
+        key, cached := keys[record.KeyID]
+        if !cached {
+            key, err = readKey(ctx, record.KeyID)
+            if err != nil {
+                return nil, err
+            }
+            keys[record.KeyID] = key
+        }
+        effective := effectiveCertificateStatus(&record, key)
+        if effective.Status != certStatusRevoked {
+            continue
+        }
+
+        serialNumber, err := parseSerialNumber(effective.SerialNumber)
+        if err != nil {
+            return nil, err
+        }
+        entries = append(entries, crl.Entry{SerialNumber: serialNumber, RevokedAt: time.Unix(effective.RevokedAt, 0), Reason: effective.RevocationReason})
+        list.Entries = append(list.Entries, effective)
+    }
+
+    tbs, err := crl.BuildTBS(issuer, entries, time.Unix(list.ThisUpdate, 0), time.Unix(list.NextUpdate, 0), big.NewInt(list.Number))
+    if err != nil {
+        return nil, fmt.Errorf("помилка формування CRL: %v", err)
+    }
+    list.TBSCertList = base64.StdEncoding.EncodeToString(tbs)
+
+    return list, nil
+}
+
+// effectiveCertificateStatus повертає сертифікат зі станом, що враховує стан ключа:
+// сертифікат скомпрометованого, відкликаного або знищеного ключа вважається відкликаним
+func effectiveCertificateStatus(record *CertificateRecord, key *CryptoKey) *CertificateRecord {
+    if record.Status == certStatusRevoked {
+        return record
+    }
+
+    effective := *record
+    switch {
+    case key.CompromisedAt > 0:
+        effective.RevokedAt, effective.RevocationReason = key.CompromisedAt, reasonKeyCompromise
+    case key.RevocationReason != "":
+        effective.RevokedAt, effective.RevocationReason = key.RevokedAt, key.RevocationReason
+    case key.Status == statusDestroyed:
+        effective.RevokedAt, effective.RevocationReason = key.DestroyedAt, reasonCessationOfOperation
+    default:
+        return record
+    }
+    effective.Status = certStatusRevoked
+    return &effective
+}
+
+// verifyCertificateChain перевіряє сертифікат ланцюжком до зареєстрованих кореневих сертифікатів
+// на час транзакції. Повертає ланцюжок від сертифіката до кореня та зареєстровані корені за ID.
+func verifyCertificateChain(ctx contractapi.TransactionContextInterface, cert *x509.Certificate, intermediates *x509.CertPool, now int64) ([]*x509.Certificate, map[string]*CACertificate, error) {
+    iterator, err := ctx.GetStub().GetStateByRange(caCertPrefix, caCertRangeEnd)
+    if err != nil {
+        return nil, nil, fmt.Errorf("помилка читання центрів сертифікації: %v", err)
+    }
+    defer iterator.Close()
+
+    pool := x509.NewCertPool()
+    roots := make(map[string]*CACertificate)
+    for iterator.HasNext() {
+        kv, err := iterator.Next()
+        if err != nil {
+            return nil, nil, fmt.Errorf("помилка читання центру сертифікації: %v", err)
+        }
+        var ca CACertificate
+        if err := json.Unmarshal(kv.Value, &ca); err != nil {
+            return nil, nil, fmt.Errorf("помилка десеріалізації центру сертифікації: %v", err)
+        }
+        if !ca.Root {
+            continue
+        }
+        rootCert, err := parseCertificatePEM("root", ca.PEM)
+        if err != nil {
+            return nil, nil, err
+        }
+        pool.AddCert(rootCert)
+        roots[ca.ID] = &ca
+    }
+    if len(roots) == 0 {
+        return nil, nil, fmt.Errorf("не зареєстровано жодного кореневого сертифіката")
+    }
+
+    chains, err := cert.Verify(x509.VerifyOptions{
+        Roots:         pool,
+        Intermediates: intermediates,
+        CurrentTime:   time.Unix(now, 0),
+        KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+    })
+    if err != nil {
+        return nil, nil, invalidArgument("chainPEM", "сертифікат не підтверджується зареєстрованими центрами сертифікації: %v", err)
+    }
+    if len(chains[0]) < 2 {
+        return nil, nil, invalidArgument("certPEM", "кореневий сертифікат не може бути закріплений за ключем")
+    }
+
+    return chains[0], roots, nil
+}
+
+// newCACertificate створює запис центру сертифікації
+func newCACertificate(cert *x509.Certificate, mspID string, root bool, registeredBy string, now int64) *CACertificate {
+    return &CACertificate{
+        ID:           certificateID(cert),
+        MSPID:        mspID,
+        Subject:      cert.Subject.String(),
+        PEM:          string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})),
+        Root:         root,
+        NotAfter:     cert.NotAfter.Unix(),
+        RegisteredBy: registeredBy,
+        RegisteredAt: now,
+    }
+}
+
+// certificateID повертає ідентифікатор сертифіката - SHA-256 від DER, hex
+func certificateID(cert *x509.Certificate) string {
+    sum := sha256.Sum256(cert.Raw)
+    return hex.EncodeToString(sum[:])
+}
+
+// certificateValidAt перевіряє, що час входить у строк дії сертифіката
+func certificateValidAt(cert *x509.Certificate, now int64) bool {
+    return cert.NotBefore.Unix() <= now && now < cert.NotAfter.Unix()
+}
+
+// parseCertificatePEM розбирає один сертифікат у форматі PEM
+func parseCertificatePEM(field string, certPEM string) (*x509.Certificate, error) {
+    block, _ := pem.Decode([]byte(certPEM))
+    if block == nil || block.Type != "CERTIFICATE" {
+        return nil, invalidArgument(field, "очікується сертифікат у форматі PEM")
+    }
+    cert, err := x509.ParseCertificate(block.Bytes)
+    if err != nil {
+        return nil, invalidArgument(field, "помилка розбору сертифіката: %v", err)
+    }
+    return cert, nil
+}
+
+// parseCertificatePool розбирає послідовність сертифікатів PEM; порожній рядок дає порожній пул
+func parseCertificatePool(field string, chainPEM string) (*x509.CertPool, error) {
+    pool := x509.NewCertPool()
+    rest := []byte(chainPEM)
+    for {
+        var block *pem.Block
+        block, rest = pem.Decode(rest)
+        if block == nil {
+            break
+        }
+        if block.Type != "CERTIFICATE" {
+            return nil, invalidArgument(field, "очікується блок PEM CERTIFICATE, отримано %s", block.Type)
+        }
+        cert, err := x509.ParseCertificate(block.Bytes)
+        if err != nil {
+            return nil, invalidArgument(field, "помилка розбору сертифіката: %v", err)
+        }
+        pool.AddCert(cert)
+    }
+    if len(bytes.TrimSpace(rest)) > 0 {
+        return nil, invalidArgument(field, "ланцюжок містить дані поза блоками PEM")
+    }
+    return pool, nil
+}
+
+// parseSerialNumber розбирає серійний номер у hex у тому вигляді, в якому його зберігає реєстр
+func parseSerialNumber(serialNumber string) (*big.Int, error) {
+    serial, ok := new(big.Int).SetString(serialNumber, 16)
+    if !ok || serial.Text(16) != serialNumber {
+        return nil, invalidArgument("serialNumber", "серійний номер має бути числом у hex нижнього регістру: %s", serialNumber)
+    }
+    return serial, nil
+}
+
+// readCACertificateIfExists читає центр сертифікації; повертає nil, якщо його не зареєстровано
+func readCACertificateIfExists(ctx contractapi.TransactionContextInterface, issuerID string) (*CACertificate, error) {
+    caJSON, err := ctx.GetStub().GetState(caCertPrefix + issuerID)
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання центру сертифікації: %v", err)
+    }
+    if caJSON == nil {
+        return nil, nil
+    }
+
+    var ca CACertificate
+    if err := json.Unmarshal(caJSON, &ca); err != nil {
+        return nil, fmt.Errorf("помилка десеріалізації центру сертифікації: %v", err)
+    }
+    return &ca, nil
+}
+
+// readCACertificate читає зареєстрований центр сертифікації
+func readCACertificate(ctx contractapi.TransactionContextInterface, issuerID string) (*CACertificate, error) {
+    ca, err := readCACertificateIfExists(ctx, issuerID)
+    if err != nil {
+        return nil, err
+    }
+    if ca == nil {
+        return nil, fmt.Errorf("центр сертифікації %s не зареєстровано", issuerID)
+    }
+    return ca, nil
+}
+
+// putCACertificate зберігає центр сертифікації у world state
+func putCACertificate(ctx contractapi.TransactionContextInterface, ca *CACertificate) error {
+    caJSON, err := json.Marshal(ca)
+    if err != nil {
+        return err
+    }
+    return ctx.GetStub().PutState(caCertPrefix+ca.ID, caJSON)
+}
+
+// readCertificate читає сертифікат за видавцем і серійним номером
+func readCertificate(ctx contractapi.TransactionContextInterface, issuerID string, serialNumber string) (*CertificateRecord, error) {
+    if _, err := parseSerialNumber(serialNumber); err != nil {
+        return nil, err
+    }
+    certKey, err := ctx.GetStub().CreateCompositeKey(certObjectType, []string{issuerID, serialNumber})
+    if err != nil {
+        return nil, fmt.Errorf("помилка створення ключа сертифіката: %v", err)
+    }
+
+    recordJSON, err := ctx.GetStub().GetState(certKey)
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання сертифіката: %v", err)
+    }
+    if recordJSON == nil {
+        return nil, fmt.Errorf("сертифікат %s видавця %s не зареєстровано", serialNumber, issuerID)
+    }
+
+    var record CertificateRecord
+    if err := json.Unmarshal(recordJSON, &record); err != nil {
+        return nil, fmt.Errorf("помилка десеріалізації сертифіката: %v", err)
+    }
+    return &record, nil
+}
+
+// putCertificate зберігає сертифікат у world state
+func putCertificate(ctx contractapi.TransactionContextInterface, record *CertificateRecord) error {
+    certKey, err := ctx.GetStub().CreateCompositeKey(certObjectType, []string{record.IssuerID, record.SerialNumber})
+    if err != nil {
+        return fmt.Errorf("помилка створення ключа сертифіката: %v", err)
+    }
+    recordJSON, err := json.Marshal(record)
+    if err != nil {
+        return err
+    }
+    return ctx.GetStub().PutState(certKey, recordJSON)
+}
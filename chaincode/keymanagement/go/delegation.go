@@ -0,0 +1,89 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+
+    "github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Максимальна глибина ланцюжка передачі доступу від власника ключа
+const maxDelegationDepth = 3
+
+// authorizeDelegation перевіряє, що користувач може передати доступ далі, і повертає його власний доступ.
+// Переданий доступ не може бути ширшим за власний, а глибина передачі зменшується на кожному рівні.
+func authorizeDelegation(ctx contractapi.TransactionContextInterface, key *CryptoKey, grantorID string, userID string, accessType string, delegationDepth int, now int64) (*KeyAccess, error) {
+    grantor, err := readAccess(ctx, key.ID, grantorID)
+    if err != nil {
+        return nil, err
+    }
+    if grantor == nil || isAccessExpired(grantor, now) || !grantor.CanDelegate || grantor.DelegationDepth <= 0 {
+        return nil, fmt.Errorf("клієнт %s не має права передавати доступ до ключа %s", grantorID, key.ID)
+    }
+    if userID == grantorID {
+        return nil, fmt.Errorf("клієнт %s не може передати доступ до ключа %s самому собі", grantorID, key.ID)
+    }
+    if !accessCovers(grantor.AccessType, accessType) {
+        return nil, fmt.Errorf("доступ %s ширший за доступ %s клієнта %s", accessType, grantor.AccessType, grantorID)
+    }
+    if delegationDepth > grantor.DelegationDepth-1 {
+        return nil, invalidArgument("delegationDepth", "глибина передачі не може перевищувати %d", grantor.DelegationDepth-1)
+    }
+    return grantor, nil
+}
+
+// accessCovers перевіряє, що тип доступу granted включає всі операції типу requested
+func accessCovers(granted string, requested string) bool {
+    return granted == accessFull || granted == requested
+}
+
+// revokeAccessCascade видаляє доступ користувача та всі доступи, передані з нього.
+// Доступи, надані власниками ключа, не вважаються похідними від їхніх записів доступу.
+func revokeAccessCascade(ctx contractapi.TransactionContextInterface, key *CryptoKey, userID string) error {
+    iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(accessObjectType, []string{key.ID})
+    if err != nil {
+        return fmt.Errorf("помилка читання доступів: %v", err)
+    }
+    defer iterator.Close()
+
+    // Спочатку будуємо дерево передачі доступів, щоб не змінювати стан під час ітерації
+    derived := make(map[string][]string)
+    for iterator.HasNext() {
+        kv, err := iterator.Next()
+        if err != nil {
+            return fmt.Errorf("помилка читання доступу: %v", err)
+        }
+        var access KeyAccess
+        if err := json.Unmarshal(kv.Value, &access); err != nil {
+            return fmt.Errorf("помилка десеріалізації доступу: %v", err)
+        }
+        if !isKeyOwner(key, access.GrantedBy) {
+            derived[access.GrantedBy] = append(derived[access.GrantedBy], access.UserID)
+        }
+    }
+
+    revoked := map[string]bool{userID: true}
+    queue := []string{userID}
+    for len(queue) > 0 {
+        current := queue[0]
+        queue = queue[1:]
+        if err := deleteAccess(ctx, key.ID, current); err != nil {
+            return err
+        }
+        reason := accessRevokedCascade
+        if current == userID {
+            reason = accessRevokedExplicit
+        }
+        if err := emitAccessRevoked(ctx, key.ID, current, reason); err != nil {
+            return err
+        }
+        for _, child := range derived[current] {
+            if !revoked[child] {
+                revoked[child] = true
+                queue = append(queue, child)
+            }
+        }
+    }
+
+    return nil
+}
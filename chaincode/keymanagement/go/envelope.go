@@ -0,0 +1,213 @@
+package main
+
+import (
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+
+    "github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+    "blockchain-security/chaincode/common/clock"
+)
+
+// Схеми загортання ключа даних (DEK) ключем шифрування ключів (KEK)
+const (
+    wrapSchemeAESKW   = "AES-KW"   // RFC 3394, KEK - симетричний ключ AES
+    wrapSchemeRSAOAEP = "RSA-OAEP" // RFC 8017 з SHA-256, KEK - асиметричний ключ RSA
+)
+
+// Тип складеного ключа загорнутих ключів даних: dataKeyID, recipientID -> WrappedDataKey
+const wrappedKeyObjectType = "wrappeddek"
+
+// Межі розміру загорнутого ключа AES-KW: DEK від 128 до 256 біт плюс 8 байт перевірочного значення
+const (
+    minAESKWWrappedSize = 24
+    maxAESKWWrappedSize = 40
+)
+
+// WrappedDataKey ключ даних, загорнутий для конкретного отримувача.
+// Загортання і розгортання виконує клієнт, який володіє матеріалом KEK;
+// реєстр зберігає лише загорнутий блоб і посилання на KEK.
+type WrappedDataKey struct {
+    DataKeyID   string `json:"dataKeyId"`
+    RecipientID string `json:"recipientId"`
+    KEKID       string `json:"kekId"`
+    Scheme      string `json:"scheme"`     // AES-KW, RSA-OAEP
+    WrappedKey  string `json:"wrappedKey"` // base64
+    WrappedBy   string `json:"wrappedBy"`
+    WrappedAt   int64  `json:"wrappedAt"`
+}
+
+// WrapDataKey зберігає ключ даних, загорнутий під KEK, для отримувача.
+// Клієнт повинен мати право шифрування KEK, а отримувач - право розшифрування.
+func (s *SmartContract) WrapDataKey(ctx contractapi.TransactionContextInterface, dataKeyID string, kekID string, recipientID string, scheme string, wrappedKey string) error {
+    if err := validateKeyID(dataKeyID); err != nil {
+        return err
+    }
+    if recipientID == "" {
+        return invalidArgument("recipientID", "отримувач не може бути порожнім")
+    }
+    wrapped, err := base64.StdEncoding.DecodeString(wrappedKey)
+    if err != nil {
+        return invalidArgument("wrappedKey", "загорнутий ключ має бути в base64: %v", err)
+    }
+
+    kek, err := readKey(ctx, kekID)
+    if err != nil {
+        return err
+    }
+    if err := validateWrapping(kek, scheme, wrapped); err != nil {
+        return err
+    }
+
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return err
+    }
+
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return err
+    }
+    allowed, err := keyAccessAllowed(ctx, kek, caller, operationEncrypt, now)
+    if err != nil {
+        return err
+    }
+    if !allowed {
+        return fmt.Errorf("клієнт %s не може шифрувати ключем %s", caller, kekID)
+    }
+
+    // Загортати для отримувача, який не зможе розгорнути ключ, немає сенсу
+    allowed, err = keyAccessAllowed(ctx, kek, recipientID, operationDecrypt, now)
+    if err != nil {
+        return err
+    }
+    if !allowed {
+        return fmt.Errorf("отримувач %s не має права розшифрування ключем %s", recipientID, kekID)
+    }
+
+    recordKey, err := ctx.GetStub().CreateCompositeKey(wrappedKeyObjectType, []string{dataKeyID, recipientID})
+    if err != nil {
+        return fmt.Errorf("помилка створення ключа загорнутого DEK: %v", err)
+    }
+    if err := authorizeRewrap(ctx, recordKey, caller); err != nil {
+        return err
+    }
+
+    record := WrappedDataKey{
+        DataKeyID:   dataKeyID,
+        RecipientID: recipientID,
+        KEKID:       kekID,
+        Scheme:      scheme,
+        WrappedKey:  wrappedKey,
+        WrappedBy:   caller,
+        WrappedAt:   now,
+    }
+    recordJSON, err := json.Marshal(record)
+    if err != nil {
+        return err
+    }
+
+    return ctx.GetStub().PutState(recordKey, recordJSON)
+}
+
+// UnwrapDataKey повертає клієнту його загорнутий ключ даних, якщо він досі має
+// доступ decrypt-only або full до KEK. Розгортання виконується на боці клієнта.
+func (s *SmartContract) UnwrapDataKey(ctx contractapi.TransactionContextInterface, dataKeyID string) (*WrappedDataKey, error) {
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    recordKey, err := ctx.GetStub().CreateCompositeKey(wrappedKeyObjectType, []string{dataKeyID, caller})
+    if err != nil {
+        return nil, fmt.Errorf("помилка створення ключа загорнутого DEK: %v", err)
+    }
+    recordJSON, err := ctx.GetStub().GetState(recordKey)
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання загорнутого DEK: %v", err)
+    }
+    if recordJSON == nil {
+        return nil, fmt.Errorf("ключ даних %s не загорнуто для %s", dataKeyID, caller)
+    }
+
+    var record WrappedDataKey
+    if err := json.Unmarshal(recordJSON, &record); err != nil {
+        return nil, fmt.Errorf("помилка десеріалізації загорнутого DEK: %v", err)
+    }
+
+    kek, err := readKey(ctx, record.KEKID)
+    if err != nil {
+        return nil, err
+    }
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return nil, err
+    }
+    allowed, err := keyAccessAllowed(ctx, kek, caller, operationDecrypt, now)
+    if err != nil {
+        return nil, err
+    }
+    if !allowed {
+        return nil, fmt.Errorf("клієнт %s не має права розшифрування ключем %s", caller, record.KEKID)
+    }
+
+    return &record, nil
+}
+
+// authorizeRewrap дозволяє перезаписати наявний загорнутий DEK лише клієнту, який його загорнув,
+// або власнику KEK, під яким він загорнутий. Інакше будь-хто з правом шифрування
+// міг би підмінити отримувачу ключ даних.
+func authorizeRewrap(ctx contractapi.TransactionContextInterface, recordKey string, caller string) error {
+    existingJSON, err := ctx.GetStub().GetState(recordKey)
+    if err != nil {
+        return fmt.Errorf("помилка читання загорнутого DEK: %v", err)
+    }
+    if existingJSON == nil {
+        return nil
+    }
+
+    var existing WrappedDataKey
+    if err := json.Unmarshal(existingJSON, &existing); err != nil {
+        return fmt.Errorf("помилка десеріалізації загорнутого DEK: %v", err)
+    }
+    if existing.WrappedBy == caller {
+        return nil
+    }
+    existingKEK, err := readKey(ctx, existing.KEKID)
+    if err != nil {
+        return err
+    }
+    if isKeyOwner(existingKEK, caller) {
+        return nil
+    }
+
+    return &ValidationError{
+        Kind:    ErrAlreadyExists,
+        Field:   "dataKeyID",
+        Message: fmt.Sprintf("ключ даних %s вже загорнуто для %s клієнтом %s", existing.DataKeyID, existing.RecipientID, existing.WrappedBy),
+    }
+}
+
+// validateWrapping перевіряє відповідність схеми загортання ключу KEK та розмір блоба
+func validateWrapping(kek *CryptoKey, scheme string, wrapped []byte) error {
+    switch scheme {
+    case wrapSchemeAESKW:
+        if kek.Type != keyTypeSymmetric || kek.Algorithm != "AES" {
+            return invalidArgument("scheme", "схема %s потребує симетричного ключа AES", scheme)
+        }
+        if len(wrapped)%8 != 0 || len(wrapped) < minAESKWWrappedSize || len(wrapped) > maxAESKWWrappedSize {
+            return invalidArgument("wrappedKey", "некоректний розмір блоба AES-KW: %d байт", len(wrapped))
+        }
+    case wrapSchemeRSAOAEP:
+        if kek.Type != keyTypeAsymmetric || kek.Algorithm != "RSA" {
+            return invalidArgument("scheme", "схема %s потребує асиметричного ключа RSA", scheme)
+        }
+        if len(wrapped) != kek.KeySize/8 {
+            return invalidArgument("wrappedKey", "розмір блоба RSA-OAEP має дорівнювати %d байт", kek.KeySize/8)
+        }
+    default:
+        return invalidArgument("scheme", "невідома схема загортання: %s", scheme)
+    }
+    return nil
+}
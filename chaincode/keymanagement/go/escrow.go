@@ -0,0 +1,167 @@
+package main
+
+import (
+    "encoding/base64"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+
+    "github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+    "blockchain-security/chaincode/common/clock"
+)
+
+// Префікс для депонованих часток ключів у world state
+const escrowPrefix = "keyescrow:"
+
+// Схеми шифрування часток, які підтримує клієнтська бібліотека client/escrow
+var escrowSchemes = []string{"RSA-OAEP", "ECIES-P256"}
+
+// Мінімальний розмір солі зобов'язання в байтах
+const minEscrowSaltSize = 16
+
+// EscrowShare частка секрету ключа, зашифрована для одного з власників
+type EscrowShare struct {
+    OwnerID        string `json:"ownerId"`
+    Index          int    `json:"index"` // абсциса частки Шаміра, 1..255
+    Scheme         string `json:"scheme"`
+    EncryptedShare string `json:"encryptedShare"` // base64
+}
+
+// KeyEscrow депозит часток симетричного ключа для відновлення K з N власниками
+type KeyEscrow struct {
+    KeyID       string        `json:"keyId"`
+    Threshold   int           `json:"threshold"`
+    Commitment  string        `json:"commitment"` // hex SHA-256(salt || secret)
+    Salt        string        `json:"salt"`       // hex
+    Shares      []EscrowShare `json:"shares"`
+    DepositedBy string        `json:"depositedBy"`
+    DepositedAt int64         `json:"depositedAt"`
+}
+
+// DepositKeyEscrow зберігає зашифровані частки симетричного ключа, підготовлені client/escrow.
+// Кожен власник ключа має отримати рівно одну частку; секрет у реєстр не потрапляє.
+func (s *SmartContract) DepositKeyEscrow(ctx contractapi.TransactionContextInterface, keyID string, depositJSON string) error {
+    var escrow KeyEscrow
+    if err := json.Unmarshal([]byte(depositJSON), &escrow); err != nil {
+        return invalidArgument("deposit", "помилка розбору депозиту: %v", err)
+    }
+
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return err
+    }
+    if key.Type != keyTypeSymmetric {
+        return invalidArgument("keyID", "депонування підтримується лише для симетричних ключів")
+    }
+    if key.Status != statusPreActivation && key.Status != statusActive {
+        return fmt.Errorf("ключ %s у стані %s не можна депонувати", keyID, key.Status)
+    }
+    if err := validateEscrow(key, &escrow); err != nil {
+        return err
+    }
+
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return err
+    }
+    if err := authorizeKeyOwner(key, caller); err != nil {
+        return err
+    }
+
+    existing, err := ctx.GetStub().GetState(escrowPrefix + keyID)
+    if err != nil {
+        return fmt.Errorf("помилка читання депозиту: %v", err)
+    }
+    if existing != nil {
+        return &ValidationError{Kind: ErrAlreadyExists, Field: "keyID", Message: fmt.Sprintf("ключ %s вже депоновано", keyID)}
+    }
+
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return err
+    }
+
+    escrow.KeyID = keyID
+    escrow.DepositedBy = caller
+    escrow.DepositedAt = now
+
+    escrowJSON, err := json.Marshal(escrow)
+    if err != nil {
+        return err
+    }
+    return ctx.GetStub().PutState(escrowPrefix+keyID, escrowJSON)
+}
+
+// GetKeyEscrow повертає власнику депозит ключа для відновлення через client/escrow
+func (s *SmartContract) GetKeyEscrow(ctx contractapi.TransactionContextInterface, keyID string) (*KeyEscrow, error) {
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return nil, err
+    }
+
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return nil, err
+    }
+    if err := authorizeKeyOwner(key, caller); err != nil {
+        return nil, err
+    }
+
+    escrowJSON, err := ctx.GetStub().GetState(escrowPrefix + keyID)
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання депозиту: %v", err)
+    }
+    if escrowJSON == nil {
+        return nil, fmt.Errorf("ключ %s не депоновано", keyID)
+    }
+
+    var escrow KeyEscrow
+    if err := json.Unmarshal(escrowJSON, &escrow); err != nil {
+        return nil, fmt.Errorf("помилка десеріалізації депозиту: %v", err)
+    }
+    return &escrow, nil
+}
+
+// validateEscrow перевіряє поріг, зобов'язання та відповідність часток власникам ключа
+func validateEscrow(key *CryptoKey, escrow *KeyEscrow) error {
+    if len(escrow.Shares) != len(key.OwnerIDs) {
+        return invalidArgument("shares", "очікується %d часток, по одній на власника", len(key.OwnerIDs))
+    }
+    if escrow.Threshold < 1 || escrow.Threshold > len(escrow.Shares) {
+        return invalidArgument("threshold", "поріг має бути від 1 до %d", len(escrow.Shares))
+    }
+
+    commitment, err := hex.DecodeString(escrow.Commitment)
+    if err != nil || len(commitment) != 32 {
+        return invalidArgument("commitment", "зобов'язання має бути SHA-256 у hex")
+    }
+    salt, err := hex.DecodeString(escrow.Salt)
+    if err != nil || len(salt) < minEscrowSaltSize {
+        return invalidArgument("salt", "сіль має містити щонайменше %d байт у hex", minEscrowSaltSize)
+    }
+
+    owners := make(map[string]bool, len(escrow.Shares))
+    indices := make(map[int]bool, len(escrow.Shares))
+    for _, share := range escrow.Shares {
+        if !isKeyOwner(key, share.OwnerID) {
+            return invalidArgument("shares", "%s не є власником ключа %s", share.OwnerID, key.ID)
+        }
+        if owners[share.OwnerID] {
+            return invalidArgument("shares", "власник %s отримав більше однієї частки", share.OwnerID)
+        }
+        if share.Index < 1 || share.Index > 255 || indices[share.Index] {
+            return invalidArgument("shares", "некоректний або повторений індекс частки %d", share.Index)
+        }
+        if !containsString(escrowSchemes, share.Scheme) {
+            return invalidArgument("shares", "невідома схема шифрування частки: %s", share.Scheme)
+        }
+        if data, err := base64.StdEncoding.DecodeString(share.EncryptedShare); err != nil || len(data) == 0 {
+            return invalidArgument("shares", "частка власника %s має бути в base64", share.OwnerID)
+        }
+        owners[share.OwnerID] = true
+        indices[share.Index] = true
+    }
+
+    return nil
+}
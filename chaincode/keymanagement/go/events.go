@@ -0,0 +1,117 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+
+    "github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Назва події чейнкоду, яку публікує контракт управління ключами
+const keyEventName = "KeyManagementEvent"
+
+// Версія схеми корисного навантаження події; збільшується при несумісних змінах
+const keyEventSchemaVersion = 1
+
+// Типи подій змін стану ключів
+const (
+    eventKeyGenerated     = "KeyGenerated"
+    eventKeyAccessGranted = "KeyAccessGranted"
+    eventKeyAccessRevoked = "KeyAccessRevoked"
+    eventKeyRotated       = "KeyRotated"
+    eventKeyStatusChanged = "KeyStatusChanged"
+)
+
+// Причини видалення доступу в подіях KeyAccessRevoked
+const (
+    accessRevokedExplicit = "revoked" // відкликано власником, менеджером або тим, хто надав доступ
+    accessRevokedCascade  = "cascade" // відкликано разом із доступом, з якого його передано
+    accessRevokedExpired  = "expired" // видалено після закінчення строку дії
+)
+
+// KeyEvent зміна стану ключа; заповнюються лише поля, що стосуються типу події
+type KeyEvent struct {
+    Type           string `json:"type"`
+    KeyID          string `json:"keyId"`
+    KeyType        string `json:"keyType,omitempty"`        // KeyGenerated
+    Algorithm      string `json:"algorithm,omitempty"`      // KeyGenerated
+    KeySize        int    `json:"keySize,omitempty"`        // KeyGenerated
+    Role           string `json:"role,omitempty"`           // KeyGenerated
+    ParentKeyID    string `json:"parentKeyId,omitempty"`    // KeyGenerated
+    UserID         string `json:"userId,omitempty"`         // KeyAccessGranted, KeyAccessRevoked
+    AccessType     string `json:"accessType,omitempty"`     // KeyAccessGranted
+    GrantedBy      string `json:"grantedBy,omitempty"`      // KeyAccessGranted
+    NewKeyID       string `json:"newKeyId,omitempty"`       // KeyRotated
+    PreviousStatus string `json:"previousStatus,omitempty"` // KeyStatusChanged
+    Status         string `json:"status,omitempty"`         // KeyGenerated, KeyStatusChanged
+    Reason         string `json:"reason,omitempty"`         // KeyAccessRevoked; код RFC 5280 для KeyStatusChanged
+    ExpiresAt      int64  `json:"expiresAt,omitempty"`      // KeyGenerated, KeyAccessGranted, KeyRotated
+}
+
+// KeyEventBatch корисне навантаження події чейнкоду: усі зміни однієї транзакції.
+// Ідентифікатор і час транзакції слухач отримує з самої події чейнкоду.
+type KeyEventBatch struct {
+    SchemaVersion int             `json:"schemaVersion"`
+    Events        []KeyEvent      `json:"events"`
+    Audit         *KeyAuditRecord `json:"audit,omitempty"` // подія аудиту, яку не вдалося записати в смарт-контракт аудиту
+}
+
+// KeyManagementContext контекст транзакції, що накопичує події змін стану ключів
+type KeyManagementContext struct {
+    contractapi.TransactionContext
+    batch KeyEventBatch
+}
+
+// keyEventRecorder контекст, що накопичує події транзакції
+type keyEventRecorder interface {
+    keyEventBatch() *KeyEventBatch
+}
+
+// keyEventBatch повертає пакет подій транзакції
+func (c *KeyManagementContext) keyEventBatch() *KeyEventBatch {
+    return &c.batch
+}
+
+// emitKeyEvent додає подію до подій транзакції та публікує їх одним пакетом
+func emitKeyEvent(ctx contractapi.TransactionContextInterface, event KeyEvent) error {
+    batch := transactionBatch(ctx)
+    batch.Events = append(batch.Events, event)
+    return publishKeyEvents(ctx, batch)
+}
+
+// emitKeyAudit публікує подію аудиту разом з подіями ключів транзакції
+func emitKeyAudit(ctx contractapi.TransactionContextInterface, record KeyAuditRecord) error {
+    batch := transactionBatch(ctx)
+    batch.Audit = &record
+    return publishKeyEvents(ctx, batch)
+}
+
+// emitAccessRevoked публікує подію видалення доступу користувача до ключа
+func emitAccessRevoked(ctx contractapi.TransactionContextInterface, keyID string, userID string, reason string) error {
+    return emitKeyEvent(ctx, KeyEvent{Type: eventKeyAccessRevoked, KeyID: keyID, UserID: userID, Reason: reason})
+}
+
+// transactionBatch повертає пакет подій транзакції; контекст без накопичення отримує новий пакет
+func transactionBatch(ctx contractapi.TransactionContextInterface) *KeyEventBatch {
+    if recorder, ok := ctx.(keyEventRecorder); ok {
+        return recorder.keyEventBatch()
+    }
+    return &KeyEventBatch{}
+}
+
+// publishKeyEvents публікує пакет подій транзакції.
+// Fabric зберігає лише останню подію транзакції, тому кожен виклик перезаписує пакет повністю.
+func publishKeyEvents(ctx contractapi.TransactionContextInterface, batch *KeyEventBatch) error {
+    batch.SchemaVersion = keyEventSchemaVersion
+    if batch.Events == nil {
+        batch.Events = []KeyEvent{}
+    }
+    payload, err := json.Marshal(batch)
+    if err != nil {
+        return err
+    }
+    if err := ctx.GetStub().SetEvent(keyEventName, payload); err != nil {
+        return fmt.Errorf("помилка публікації події: %v", err)
+    }
+    return nil
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+
+    "github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+    "blockchain-security/chaincode/common/clock"
+)
+
+// Типи доступу до ключа
+const (
+    accessFull        = "full"
+    accessEncryptOnly = "encrypt-only"
+    accessDecryptOnly = "decrypt-only"
+)
+
+// accessTypes перелік допустимих типів доступу
+var accessTypes = []string{accessFull, accessEncryptOnly, accessDecryptOnly}
+
+// Операції з ключем, які перевіряє CheckKeyAccess
+const (
+    operationEncrypt = "encrypt"
+    operationDecrypt = "decrypt"
+)
+
+// Межа діапазону ключів у world state
+const keyRangeEnd = "cryptokey~"
+
+// Максимальна кількість ключів, які переглядає один виклик SweepExpired
+const maxSweepBatch = 500
+
+// SweepResult результат одного пакета очищення прострочених записів
+type SweepResult struct {
+    Examined      int    `json:"examined"`
+    KeysExpired   int    `json:"keysExpired"`
+    GrantsDeleted int    `json:"grantsDeleted"`
+    Cursor        string `json:"cursor"` // порожній, якщо очищення завершено
+}
+
+// CheckKeyAccess перевіряє, чи може користувач виконати операцію з ключем
+func (s *SmartContract) CheckKeyAccess(ctx contractapi.TransactionContextInterface, keyID string, userID string, operation string) (bool, error) {
+    if operation != operationEncrypt && operation != operationDecrypt {
+        return false, fmt.Errorf("невідома операція: %s", operation)
+    }
+
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return false, err
+    }
+
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return false, err
+    }
+
+    return keyAccessAllowed(ctx, key, userID, operation, now)
+}
+
+// keyAccessAllowed перевіряє стан ключа та право користувача на операцію з ним
+func keyAccessAllowed(ctx contractapi.TransactionContextInterface, key *CryptoKey, userID string, operation string, now int64) (bool, error) {
+    _, allowed, err := authorizeKeyOperation(ctx, key, userID, operation, now)
+    return allowed, err
+}
+
+// authorizeKeyOperation перевіряє право користувача на операцію і повертає доступ, яким воно надане.
+// Для власників ключа доступ дорівнює nil.
+func authorizeKeyOperation(ctx contractapi.TransactionContextInterface, key *CryptoKey, userID string, operation string, now int64) (*KeyAccess, bool, error) {
+    if !keyPermits(key, operation, now) {
+        return nil, false, nil
+    }
+
+    // Власники ключа мають повний доступ
+    if isKeyOwner(key, userID) {
+        return nil, true, nil
+    }
+
+    access, err := readAccess(ctx, key.ID, userID)
+    if err != nil {
+        return nil, false, err
+    }
+    if access == nil || isAccessExpired(access, now) {
+        return nil, false, nil
+    }
+
+    return access, accessPermits(access.AccessType, operation), nil
+}
+
+// SweepExpired деактивує прострочені ключі та видаляє прострочені доступи пакетами.
+// Курсор вказує на останній оброблений ключ; доступи ключа очищуються разом із ним.
+func (s *SmartContract) SweepExpired(ctx contractapi.TransactionContextInterface, cursor string, batchSize int) (*SweepResult, error) {
+    if batchSize <= 0 || batchSize > maxSweepBatch {
+        return nil, fmt.Errorf("розмір пакета має бути від 1 до %d", maxSweepBatch)
+    }
+
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return nil, err
+    }
+
+    result := &SweepResult{}
+
+    last, done, err := scanRange(ctx, rangeStart(keyPrefix, cursor), keyRangeEnd, batchSize, func(value []byte) error {
+        result.Examined++
+        var key CryptoKey
+        if err := json.Unmarshal(value, &key); err != nil {
+            return fmt.Errorf("помилка десеріалізації даних ключа: %v", err)
+        }
+        if isKeyExpired(&key, now) && (key.Status == statusActive || key.Status == statusSuspended) {
+            if err := transitionKey(ctx, &key, statusDeactivated, "", now); err != nil {
+                return err
+            }
+            result.KeysExpired++
+            if err := putKey(ctx, &key); err != nil {
+                return err
+            }
+        }
+
+        deleted, err := sweepKeyAccess(ctx, key.ID, now)
+        if err != nil {
+            return err
+        }
+        result.GrantsDeleted += deleted
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    if !done {
+        result.Cursor = last
+    }
+
+    return result, nil
+}
+
+// sweepKeyAccess видаляє прострочені доступи до ключа та повертає їх кількість
+func sweepKeyAccess(ctx contractapi.TransactionContextInterface, keyID string, now int64) (int, error) {
+    iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(accessObjectType, []string{keyID})
+    if err != nil {
+        return 0, fmt.Errorf("помилка читання доступів: %v", err)
+    }
+    defer iterator.Close()
+
+    // Спочатку збираємо прострочені доступи, щоб не змінювати стан під час ітерації
+    var expired []*KeyAccess
+    for iterator.HasNext() {
+        kv, err := iterator.Next()
+        if err != nil {
+            return 0, fmt.Errorf("помилка читання доступу: %v", err)
+        }
+        var access KeyAccess
+        if err := json.Unmarshal(kv.Value, &access); err != nil {
+            return 0, fmt.Errorf("помилка десеріалізації доступу: %v", err)
+        }
+        if isAccessExpired(&access, now) {
+            expired = append(expired, &access)
+        }
+    }
+
+    for _, access := range expired {
+        if err := deleteAccess(ctx, access.KeyID, access.UserID); err != nil {
+            return 0, err
+        }
+        if err := emitAccessRevoked(ctx, access.KeyID, access.UserID, accessRevokedExpired); err != nil {
+            return 0, err
+        }
+    }
+
+    return len(expired), nil
+}
+
+// rangeStart повертає початок діапазону, що продовжує сканування після курсора
+func rangeStart(prefix string, cursor string) string {
+    if cursor == "" || cursor == prefix {
+        return prefix
+    }
+    return cursor + "\x00"
+}
+
+// scanRange викликає fn для щонайбільше limit записів діапазону.
+// Повертає останній переглянутий ключ і ознаку того, що діапазон вичерпано.
+func scanRange(ctx contractapi.TransactionContextInterface, startKey string, endKey string, limit int, fn func(value []byte) error) (string, bool, error) {
+    iterator, err := ctx.GetStub().GetStateByRange(startKey, endKey)
+    if err != nil {
+        return "", false, fmt.Errorf("помилка читання діапазону: %v", err)
+    }
+    defer iterator.Close()
+
+    last := ""
+    for count := 0; iterator.HasNext(); count++ {
+        if count >= limit {
+            return last, false, nil
+        }
+        kv, err := iterator.Next()
+        if err != nil {
+            return "", false, fmt.Errorf("помилка читання запису: %v", err)
+        }
+        if err := fn(kv.Value); err != nil {
+            return "", false, err
+        }
+        last = kv.Key
+    }
+
+    return last, true, nil
+}
+
+// isKeyExpired перевіряє, чи минув строк дії ключа
+func isKeyExpired(key *CryptoKey, now int64) bool {
+    return key.ExpiresAt > 0 && key.ExpiresAt <= now
+}
+
+// isAccessExpired перевіряє, чи минув строк дії доступу
+func isAccessExpired(access *KeyAccess, now int64) bool {
+    return access.ExpiresAt > 0 && access.ExpiresAt <= now
+}
+
+// effectiveKeyStatus повертає стан ключа з урахуванням строку дії:
+// активний або призупинений ключ після закінчення строку вважається деактивованим
+func effectiveKeyStatus(key *CryptoKey, now int64) string {
+    if (key.Status == statusActive || key.Status == statusSuspended) && isKeyExpired(key, now) {
+        return statusDeactivated
+    }
+    return key.Status
+}
+
+// keyPermits перевіряє, чи дозволяє стан ключа операцію.
+// Деактивованим ключем можна лише розшифровувати та перевіряти раніше захищені дані.
+func keyPermits(key *CryptoKey, operation string, now int64) bool {
+    switch effectiveKeyStatus(key, now) {
+    case statusActive:
+        return true
+    case statusDeactivated:
+        return operation == operationDecrypt || operation == operationVerify
+    default:
+        return false
+    }
+}
+
+// accessPermits перевіряє, чи дозволяє тип доступу операцію
+func accessPermits(accessType string, operation string) bool {
+    switch accessType {
+    case accessFull:
+        return true
+    case accessEncryptOnly:
+        return operation == operationEncrypt
+    case accessDecryptOnly:
+        return operation == operationDecrypt
+    default:
+        return false
+    }
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+
+    "github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+    "blockchain-security/chaincode/common/clock"
+)
+
+// Ролі ключів в ієрархії: кореневий ключ -> ключ шифрування ключів -> ключ шифрування даних
+const (
+    keyRoleRoot = "root"
+    keyRoleKEK  = "kek"
+    keyRoleDEK  = "dek"
+)
+
+// keyRoleParents визначає роль батьківського ключа для кожної ролі; кореневий ключ не має батька
+var keyRoleParents = map[string]string{
+    keyRoleRoot: "",
+    keyRoleKEK:  keyRoleRoot,
+    keyRoleDEK:  keyRoleKEK,
+}
+
+// Максимальна глибина ієрархії ключів
+const maxHierarchyDepth = 3
+
+// Максимальна кількість нащадків, стан яких змінюється в одній транзакції
+const maxCascadeBatch = 200
+
+// CascadeResult результат поширення стану ключа на його нащадків
+type CascadeResult struct {
+    Updated  int  `json:"updated"`
+    Complete bool `json:"complete"` // false - потрібен ще один виклик PropagateKeyStatus
+}
+
+// GenerateHierarchyKey створює активний ключ ієрархії. Кореневий ключ створюється без батька,
+// ключ kek - під кореневим, dek - під kek; дочірній ключ може створити лише власник батьківського.
+func (s *SmartContract) GenerateHierarchyKey(ctx contractapi.TransactionContextInterface, id string, role string, parentKeyID string, keyType string, algorithm string, keySize int, ownerIDs string, expirationDays int) error {
+    if err := validateHierarchyRole(role, parentKeyID, keyType); err != nil {
+        return err
+    }
+    if role == keyRoleRoot {
+        return createKey(ctx, id, keyType, algorithm, keySize, ownerIDs, expirationDays, statusActive, role, nil)
+    }
+
+    parent, err := readKey(ctx, parentKeyID)
+    if err != nil {
+        return err
+    }
+    if parent.Role != keyRoleParents[role] {
+        return invalidArgument("parentKeyID", "ключ %s з роллю %q не може бути батьківським для ключа %s", parentKeyID, parent.Role, role)
+    }
+
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return err
+    }
+    if effectiveKeyStatus(parent, now) != statusActive {
+        return fmt.Errorf("батьківський ключ %s не активний", parentKeyID)
+    }
+
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return err
+    }
+    if err := authorizeKeyOwner(parent, caller); err != nil {
+        return err
+    }
+
+    return createKey(ctx, id, keyType, algorithm, keySize, ownerIDs, expirationDays, statusActive, role, parent)
+}
+
+// GetChildKeys повертає безпосередні дочірні ключі
+func (s *SmartContract) GetChildKeys(ctx contractapi.TransactionContextInterface, keyID string) ([]*CryptoKey, error) {
+    iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(childIndex, []string{keyID})
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання індексу дочірніх ключів: %v", err)
+    }
+    defer iterator.Close()
+
+    return collectIndexedKeys(ctx, iterator)
+}
+
+// GetKeyDescendants повертає всіх нащадків ключа в порядку обходу в ширину.
+// Дерево відновлюється за полем ParentKeyID кожного ключа.
+func (s *SmartContract) GetKeyDescendants(ctx contractapi.TransactionContextInterface, keyID string) ([]*CryptoKey, error) {
+    descendants := []*CryptoKey{}
+    level := []string{keyID}
+    for depth := 0; len(level) > 0; depth++ {
+        if depth >= maxHierarchyDepth {
+            return nil, fmt.Errorf("ієрархія ключа %s глибша за %d рівні", keyID, maxHierarchyDepth)
+        }
+
+        var next []string
+        for _, parentID := range level {
+            children, err := s.GetChildKeys(ctx, parentID)
+            if err != nil {
+                return nil, err
+            }
+            for _, child := range children {
+                descendants = append(descendants, child)
+                next = append(next, child.ID)
+            }
+        }
+        level = next
+    }
+
+    return descendants, nil
+}
+
+// GetKeyAncestors повертає батьківські ключі від безпосереднього батька до кореневого
+func (s *SmartContract) GetKeyAncestors(ctx contractapi.TransactionContextInterface, keyID string) ([]*CryptoKey, error) {
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return nil, err
+    }
+
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return nil, err
+    }
+
+    ancestors := []*CryptoKey{}
+    for key.ParentKeyID != "" {
+        if len(ancestors) >= maxHierarchyDepth {
+            return nil, fmt.Errorf("ієрархія ключа %s глибша за %d рівні", keyID, maxHierarchyDepth)
+        }
+        key, err = readKey(ctx, key.ParentKeyID)
+        if err != nil {
+            return nil, err
+        }
+        key.Status = effectiveKeyStatus(key, now)
+        ancestors = append(ancestors, key)
+    }
+
+    return ancestors, nil
+}
+
+// PropagateKeyStatus продовжує поширення компрометації або відкликання ключа на нащадків,
+// якщо вони не вмістилися в транзакцію зміни стану. Повторний виклик безпечний.
+// Доступно лише власнику ключа; змінені нащадки фіксуються в події аудиту ключа.
+func (s *SmartContract) PropagateKeyStatus(ctx contractapi.TransactionContextInterface, keyID string, batchSize int) (*CascadeResult, error) {
+    if batchSize <= 0 || batchSize > maxCascadeBatch {
+        return nil, invalidArgument("batchSize", "розмір пакета має бути від 1 до %d", maxCascadeBatch)
+    }
+
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return nil, err
+    }
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return nil, err
+    }
+    if err := authorizeKeyOwner(key, caller); err != nil {
+        return nil, err
+    }
+    target, reasonCode, cascade := cascadeTarget(key)
+    if !cascade {
+        return nil, fmt.Errorf("ключ %s не відкликано і не скомпрометовано", keyID)
+    }
+
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return nil, err
+    }
+
+    updated, complete, err := cascadeKeyStatus(ctx, key, now, batchSize)
+    if err != nil {
+        return nil, err
+    }
+    if key.CascadePending == complete {
+        key.CascadePending = !complete
+        if err := putKey(ctx, key); err != nil {
+            return nil, err
+        }
+    }
+
+    metadata := cascadeAuditMetadata(target, reasonCode, updated)
+    metadata["cascadeComplete"] = strconv.FormatBool(complete)
+    if err := recordKeyAudit(ctx, statusAuditAction(target, reasonCode), key.ID, auditResultSuccess, metadata); err != nil {
+        return nil, err
+    }
+    return &CascadeResult{Updated: len(updated), Complete: complete}, nil
+}
+
+// validateHierarchyRole перевіряє роль ключа, наявність батьківського ключа і тип ключа для ролі
+func validateHierarchyRole(role string, parentKeyID string, keyType string) error {
+    parentRole, known := keyRoleParents[role]
+    if !known {
+        return invalidArgument("role", "невідома роль ключа: %s", role)
+    }
+    if parentRole == "" && parentKeyID != "" {
+        return invalidArgument("parentKeyID", "кореневий ключ не може мати батьківського ключа")
+    }
+    if parentRole != "" && parentKeyID == "" {
+        return invalidArgument("parentKeyID", "ключ з роллю %s потребує батьківського ключа з роллю %s", role, parentRole)
+    }
+    if role == keyRoleDEK && keyType != keyTypeSymmetric {
+        return invalidArgument("keyType", "ключ шифрування даних має бути симетричним")
+    }
+    return nil
+}
+
+// attachChildKey додає ключ до індексу дочірніх ключів батьківського ключа
+func attachChildKey(ctx contractapi.TransactionContextInterface, parent *CryptoKey, child *CryptoKey) error {
+    indexKey, err := ctx.GetStub().CreateCompositeKey(childIndex, []string{parent.ID, child.ID})
+    if err != nil {
+        return fmt.Errorf("помилка створення індексу дочірніх ключів: %v", err)
+    }
+    if err := ctx.GetStub().PutState(indexKey, indexValue); err != nil {
+        return err
+    }
+
+    parent.ChildKeyCount++
+    return putKey(ctx, parent)
+}
+
+// cascadeTarget визначає стан, у який переходять нащадки ключа: скомпрометований ключ
+// компрометує нащадків, відкликаний - відкликає їх з тією ж причиною
+func cascadeTarget(key *CryptoKey) (string, string, bool) {
+    switch {
+    case key.CompromisedAt > 0:
+        return statusCompromised, reasonKeyCompromise, true
+    case key.RevocationReason != "":
+        return statusDeactivated, key.RevocationReason, true
+    default:
+        return "", "", false
+    }
+}
+
+// cascadeKeyStatus поширює стан ключа на нащадків обходом у ширину, змінюючи не більше limit ключів,
+// і повертає ідентифікатори змінених нащадків. Нащадки, які вже перебувають у цільовому стані,
+// обходяться повторно, тому пакети можна продовжувати.
+func cascadeKeyStatus(ctx contractapi.TransactionContextInterface, root *CryptoKey, now int64, limit int) ([]string, bool, error) {
+    updated := []string{}
+    queue := []*CryptoKey{root}
+    for len(queue) > 0 {
+        node := queue[0]
+        queue = queue[1:]
+
+        target, reasonCode, ok := cascadeTarget(node)
+        if !ok || node.ChildKeyCount == 0 {
+            continue
+        }
+
+        childIDs, err := childKeyIDs(ctx, node.ID)
+        if err != nil {
+            return nil, false, err
+        }
+        for _, childID := range childIDs {
+            child, err := readKey(ctx, childID)
+            if err != nil {
+                return nil, false, err
+            }
+            if child.Status != target && containsString(keyTransitions[child.Status], target) {
+                if len(updated) >= limit {
+                    return updated, false, nil
+                }
+                if err := transitionKey(ctx, child, target, reasonCode, now); err != nil {
+                    return nil, false, err
+                }
+                if err := putKey(ctx, child); err != nil {
+                    return nil, false, err
+                }
+                updated = append(updated, child.ID)
+            }
+            queue = append(queue, child)
+        }
+    }
+
+    return updated, true, nil
+}
+
+// cascadeAuditMetadata повертає метадані події аудиту переходу ключа в стан target разом
+// з нащадками, на яких поширено перехід: транзакція записує одну подію аудиту
+func cascadeAuditMetadata(target string, reasonCode string, cascaded []string) map[string]string {
+    metadata := map[string]string{"status": target}
+    if reasonCode != "" {
+        metadata["reason"] = reasonCode
+    }
+    if len(cascaded) > 0 {
+        metadata["cascaded"] = strconv.Itoa(len(cascaded))
+        metadata["cascadedKeys"] = strings.Join(cascaded, ",")
+    }
+    return metadata
+}
+
+// childKeyIDs повертає ідентифікатори безпосередніх дочірніх ключів
+func childKeyIDs(ctx contractapi.TransactionContextInterface, keyID string) ([]string, error) {
+    iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(childIndex, []string{keyID})
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання індексу дочірніх ключів: %v", err)
+    }
+    defer iterator.Close()
+
+    var childIDs []string
+    for iterator.HasNext() {
+        kv, err := iterator.Next()
+        if err != nil {
+            return nil, fmt.Errorf("помилка читання індексу: %v", err)
+        }
+        _, attributes, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+        if err != nil || len(attributes) != 2 {
+            return nil, fmt.Errorf("пошкоджений запис індексу %q", kv.Key)
+        }
+        childIDs = append(childIDs, attributes[1])
+    }
+
+    return childIDs, nil
+}
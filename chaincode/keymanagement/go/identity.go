@@ -0,0 +1,125 @@
+package main
+
+import (
+    "fmt"
+
+    "github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+    "blockchain-security/chaincode/common/clock"
+)
+
+// Роздільник між MSP ID та іменем у форматі ідентичності
+const identitySeparator = "::"
+
+// Організаційний підрозділ сертифікатів адміністраторів організації (Fabric NodeOUs)
+const adminOU = "admin"
+
+// GetCallerIdentity повертає ідентичність клієнта у форматі, що використовується в OwnerIDs
+func (s *SmartContract) GetCallerIdentity(ctx contractapi.TransactionContextInterface) (string, error) {
+    return callerIdentity(ctx)
+}
+
+// callerIdentity визначає ідентичність клієнта за його сертифікатом: <MSP ID>::<CN>::<DN видавця>.
+// Однакові CN можуть видати різні CA однієї MSP, тому видавець входить до ідентичності.
+func callerIdentity(ctx contractapi.TransactionContextInterface) (string, error) {
+    clientIdentity := ctx.GetClientIdentity()
+    if clientIdentity == nil {
+        return "", fmt.Errorf("ідентичність клієнта недоступна")
+    }
+
+    mspID, err := clientIdentity.GetMSPID()
+    if err != nil {
+        return "", fmt.Errorf("помилка отримання MSP ID клієнта: %v", err)
+    }
+
+    cert, err := clientIdentity.GetX509Certificate()
+    if err != nil {
+        return "", fmt.Errorf("помилка отримання сертифіката клієнта: %v", err)
+    }
+
+    // Для ідентичностей без X.509 сертифіката (idemix) використовуємо ID з cid
+    name := ""
+    if cert != nil {
+        issuer := cert.Issuer.String()
+        if cert.Subject.CommonName == "" || issuer == "" {
+            return "", fmt.Errorf("не вдалося визначити ім'я клієнта")
+        }
+        name = cert.Subject.CommonName + identitySeparator + issuer
+    } else {
+        name, err = clientIdentity.GetID()
+        if err != nil {
+            return "", fmt.Errorf("помилка отримання ID клієнта: %v", err)
+        }
+    }
+    if name == "" {
+        return "", fmt.Errorf("не вдалося визначити ім'я клієнта")
+    }
+
+    return mspID + identitySeparator + name, nil
+}
+
+// authorizeOrgAdmin дозволяє операцію лише адміністратору організації і повертає його MSP ID
+func authorizeOrgAdmin(ctx contractapi.TransactionContextInterface) (string, error) {
+    clientIdentity := ctx.GetClientIdentity()
+    if clientIdentity == nil {
+        return "", fmt.Errorf("ідентичність клієнта недоступна")
+    }
+
+    mspID, err := clientIdentity.GetMSPID()
+    if err != nil {
+        return "", fmt.Errorf("помилка отримання MSP ID клієнта: %v", err)
+    }
+    cert, err := clientIdentity.GetX509Certificate()
+    if err != nil {
+        return "", fmt.Errorf("помилка отримання сертифіката клієнта: %v", err)
+    }
+    if cert == nil || !containsString(cert.Subject.OrganizationalUnit, adminOU) {
+        return "", fmt.Errorf("операція доступна лише адміністратору організації %s", mspID)
+    }
+
+    return mspID, nil
+}
+
+// isKeyOwner перевіряє, чи входить ідентичність до власників ключа
+func isKeyOwner(key *CryptoKey, identity string) bool {
+    return containsString(key.OwnerIDs, identity)
+}
+
+// authorizeKeyOwner дозволяє операцію лише власнику ключа
+func authorizeKeyOwner(key *CryptoKey, identity string) error {
+    if !isKeyOwner(key, identity) {
+        return fmt.Errorf("клієнт %s не є власником ключа %s", identity, key.ID)
+    }
+    return nil
+}
+
+// authorizeKeyManager дозволяє операцію власнику ключа або користувачу з чинним повним доступом
+func authorizeKeyManager(ctx contractapi.TransactionContextInterface, key *CryptoKey, identity string) error {
+    if isKeyOwner(key, identity) {
+        return nil
+    }
+
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return err
+    }
+    access, err := readAccess(ctx, key.ID, identity)
+    if err != nil {
+        return err
+    }
+    if access != nil && access.AccessType == accessFull && !isAccessExpired(access, now) {
+        return nil
+    }
+
+    return fmt.Errorf("клієнт %s не має права керувати ключем %s", identity, key.ID)
+}
+
+// containsString перевіряє наявність рядка у списку
+func containsString(list []string, value string) bool {
+    for _, item := range list {
+        if item == value {
+            return true
+        }
+    }
+    return false
+}
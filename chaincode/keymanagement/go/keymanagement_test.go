@@ -2,20 +2,52 @@
 package main
 
 import (
+    "crypto"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "crypto/sha256"
+    "crypto/x509"
+    "crypto/x509/pkix"
+    "encoding/base64"
+    "encoding/hex"
     "encoding/json"
+    "encoding/pem"
+    "errors"
+    "math/big"
+    "strconv"
+    "strings"
     "testing"
     "time"
 
+    "github.com/golang/protobuf/ptypes/timestamp"
+    "github.com/hyperledger/fabric-chaincode-go/pkg/cid"
     "github.com/hyperledger/fabric-chaincode-go/shim"
     "github.com/hyperledger/fabric-contract-api-go/contractapi"
+    "github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+    pb "github.com/hyperledger/fabric-protos-go/peer"
     "github.com/stretchr/testify/assert"
     "github.com/stretchr/testify/mock"
+
+    "blockchain-security/chaincode/common/crl"
+    "blockchain-security/client/escrow"
 )
 
 // MockStub імітує ChainCodeStubInterface
 type MockStub struct {
     mock.Mock
     shim.ChaincodeStubInterface
+    EventName    string
+    EventPayload []byte
+    Invocations    []MockInvocation
+    InvokeResponse *pb.Response
+}
+
+// MockInvocation виклик іншого чейнкоду
+type MockInvocation struct {
+    Chaincode string
+    Channel   string
+    Args      []string
 }
 
 func (s *MockStub) GetState(key string) ([]byte, error) {
@@ -28,11 +60,122 @@ func (s *MockStub) PutState(key string, value []byte) error {
     return args.Error(0)
 }
 
+func (s *MockStub) DelState(key string) error {
+    args := s.Called(key)
+    return args.Error(0)
+}
+
+func (s *MockStub) GetChannelID() string {
+    args := s.Called()
+    return args.String(0)
+}
+
 func (s *MockStub) GetTxID() string {
     args := s.Called()
     return args.String(0)
 }
 
+func (s *MockStub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+    args := s.Called()
+    return args.Get(0).(*timestamp.Timestamp), args.Error(1)
+}
+
+func (s *MockStub) GetStateByRange(startKey string, endKey string) (shim.StateQueryIteratorInterface, error) {
+    args := s.Called(startKey, endKey)
+    return args.Get(0).(shim.StateQueryIteratorInterface), args.Error(1)
+}
+
+func (s *MockStub) GetStateByRangeWithPagination(startKey string, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+    args := s.Called(startKey, endKey, pageSize, bookmark)
+    return args.Get(0).(shim.StateQueryIteratorInterface), args.Get(1).(*pb.QueryResponseMetadata), args.Error(2)
+}
+
+func (s *MockStub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+    args := s.Called(objectType, keys)
+    return args.Get(0).(shim.StateQueryIteratorInterface), args.Error(1)
+}
+
+func (s *MockStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+    args := s.Called(objectType, keys, pageSize, bookmark)
+    return args.Get(0).(shim.StateQueryIteratorInterface), args.Get(1).(*pb.QueryResponseMetadata), args.Error(2)
+}
+
+func (s *MockStub) GetTransient() (map[string][]byte, error) {
+    args := s.Called()
+    return args.Get(0).(map[string][]byte), args.Error(1)
+}
+
+func (s *MockStub) PutPrivateData(collection string, key string, value []byte) error {
+    args := s.Called(collection, key, value)
+    return args.Error(0)
+}
+
+func (s *MockStub) DelPrivateData(collection string, key string) error {
+    args := s.Called(collection, key)
+    return args.Error(0)
+}
+
+func (s *MockStub) GetPrivateData(collection string, key string) ([]byte, error) {
+    args := s.Called(collection, key)
+    return args.Get(0).([]byte), args.Error(1)
+}
+
+func (s *MockStub) GetPrivateDataHash(collection string, key string) ([]byte, error) {
+    args := s.Called(collection, key)
+    return args.Get(0).([]byte), args.Error(1)
+}
+
+// SetEvent не записується у виклики мока: як і пір, мок зберігає лише останню подію транзакції
+func (s *MockStub) SetEvent(name string, payload []byte) error {
+    s.EventName, s.EventPayload = name, payload
+    return nil
+}
+
+// InvokeChaincode не записується у виклики мока, щоб не зсувати індекси Calls.
+// Виклики зберігаються в Invocations; без заданої InvokeResponse мок відповідає успіхом.
+func (s *MockStub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) pb.Response {
+    invocation := MockInvocation{Chaincode: chaincodeName, Channel: channel}
+    for _, arg := range args {
+        invocation.Args = append(invocation.Args, string(arg))
+    }
+    s.Invocations = append(s.Invocations, invocation)
+    if s.InvokeResponse != nil {
+        return *s.InvokeResponse
+    }
+    return shim.Success(nil)
+}
+
+// CreateCompositeKey не записується у виклики мока, щоб не зсувати індекси Calls
+func (s *MockStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+    return shim.CreateCompositeKey(objectType, attributes)
+}
+
+func (s *MockStub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+    parts := strings.Split(strings.Trim(compositeKey, "\x00"), "\x00")
+    return parts[0], parts[1:], nil
+}
+
+// MockQueryIterator імітує StateQueryIteratorInterface
+type MockQueryIterator struct {
+    shim.StateQueryIteratorInterface
+    Results []*queryresult.KV
+    Index   int
+}
+
+func (i *MockQueryIterator) HasNext() bool {
+    return i.Index < len(i.Results)
+}
+
+func (i *MockQueryIterator) Next() (*queryresult.KV, error) {
+    result := i.Results[i.Index]
+    i.Index++
+    return result, nil
+}
+
+func (i *MockQueryIterator) Close() error {
+    return nil
+}
+
 // MockContext імітує TransactionContextInterface
 type MockContext struct {
     mock.Mock
@@ -44,27 +187,79 @@ func (c *MockContext) GetStub() shim.ChaincodeStubInterface {
     return args.Get(0).(shim.ChaincodeStubInterface)
 }
 
+func (c *MockContext) GetClientIdentity() cid.ClientIdentity {
+    args := c.Called()
+    return args.Get(0).(cid.ClientIdentity)
+}
+
+// MockClientIdentity імітує ClientIdentity
+type MockClientIdentity struct {
+    mock.Mock
+    cid.ClientIdentity
+}
+
+func (i *MockClientIdentity) GetMSPID() (string, error) {
+    args := i.Called()
+    return args.String(0), args.Error(1)
+}
+
+func (i *MockClientIdentity) GetX509Certificate() (*x509.Certificate, error) {
+    args := i.Called()
+    return args.Get(0).(*x509.Certificate), args.Error(1)
+}
+
+// newMockIdentity створює ідентичність клієнта з вказаним MSP ID та CN сертифіката
+func newMockIdentity(mspID string, commonName string) *MockClientIdentity {
+    identity := new(MockClientIdentity)
+    identity.On("GetMSPID").Return(mspID, nil)
+    identity.On("GetX509Certificate").Return(&x509.Certificate{Subject: pkix.Name{CommonName: commonName}, Issuer: pkix.Name{CommonName: "ca." + mspID}}, nil)
+    return identity
+}
+
+// newMockAdminIdentity створює ідентичність адміністратора організації (OU=admin)
+func newMockAdminIdentity(mspID string, commonName string) *MockClientIdentity {
+    identity := new(MockClientIdentity)
+    identity.On("GetMSPID").Return(mspID, nil)
+    identity.On("GetX509Certificate").Return(&x509.Certificate{Subject: pkix.Name{CommonName: commonName, OrganizationalUnit: []string{"admin"}}, Issuer: pkix.Name{CommonName: "ca." + mspID}}, nil)
+    return identity
+}
+
+// Час транзакції, який бачать усі піри
+var txTimestamp = &timestamp.Timestamp{Seconds: 1630000000, Nanos: 42}
+
+// compositeKey формує складений ключ так само, як це робить пір
+func compositeKey(objectType string, attributes ...string) string {
+    key, _ := shim.CreateCompositeKey(objectType, attributes)
+    return key
+}
+
 // Тестування GenerateKey
 func TestGenerateKey(t *testing.T) {
     // Ініціалізація мок-об'єктів
     mockStub := new(MockStub)
     mockContext := new(MockContext)
     mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
 
     // Підготовка даних для тесту
     id := "key123"
     keyType := "symmetric"
     algorithm := "AES"
-    ownerIDs := `["user1", "user2"]`
+    keySize := 256
+    ownerIDs := `["Org1MSP::user1::CN=ca.Org1MSP", "Org2MSP::user2::CN=ca.Org2MSP"]`
     expirationDays := 365
     
     // Очікуємо виклики методів
+    mockStub.On("GetTransient").Return(map[string][]byte{}, nil)
     mockStub.On("GetTxID").Return("tx123")
+    mockStub.On("GetState", "cryptokey:key123-tx123").Return([]byte(nil), nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
     mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+    mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
 
     // Створення об'єкту смарт-контракту і виклик методу
     contract := new(SmartContract)
-    err := contract.GenerateKey(mockContext, id, keyType, algorithm, ownerIDs, expirationDays)
+    err := contract.GenerateKey(mockContext, id, keyType, algorithm, keySize, ownerIDs, expirationDays)
     
     // Перевірка результатів
     assert.Nil(t, err)
@@ -72,7 +267,7 @@ func TestGenerateKey(t *testing.T) {
     mockContext.AssertExpectations(t)
     
     // Перевірка, що PutState був викликаний з коректними даними
-    call := mockStub.Calls[1] // Другий виклик - це PutState
+    call := mockStub.Calls[4] // П'ятий виклик - це PutState
     actualKey := call.Arguments[0].(string)
     actualValue := call.Arguments[1].([]byte)
     
@@ -88,6 +283,7 @@ func TestGenerateKey(t *testing.T) {
     assert.Contains(t, cryptoKey.ID, id)
     assert.Equal(t, keyType, cryptoKey.Type)
     assert.Equal(t, algorithm, cryptoKey.Algorithm)
+    assert.Equal(t, keySize, cryptoKey.KeySize)
     assert.Equal(t, "active", cryptoKey.Status)
     
     // Перевірка власників
@@ -97,35 +293,176 @@ func TestGenerateKey(t *testing.T) {
     assert.Equal(t, expectedOwners, cryptoKey.OwnerIDs)
     
     // Перевірка часових міток
-    now := time.Now().Unix()
-    assert.LessOrEqual(t, cryptoKey.CreatedAt, now)
-    assert.LessOrEqual(t, cryptoKey.ActivatedAt, now)
-    assert.Greater(t, cryptoKey.ExpiresAt, now)
+    now := txTimestamp.Seconds
+    assert.Equal(t, now, cryptoKey.CreatedAt)
+    assert.Equal(t, now, cryptoKey.ActivatedAt)
+    assert.Equal(t, now+int64(expirationDays*24*60*60), cryptoKey.ExpiresAt)
     assert.Equal(t, int64(0), cryptoKey.RevokedAt)
 }
 
+// Тестування детермінованості GenerateKey на різних пірах
+func TestGenerateKeyDeterministic(t *testing.T) {
+    // Кожен пір виконує транзакцію незалежно, але з однаковим заголовком
+    endorse := func() []byte {
+        mockStub := new(MockStub)
+        mockContext := new(MockContext)
+        mockContext.On("GetStub").Return(mockStub)
+        mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+        mockStub.On("GetTransient").Return(map[string][]byte{}, nil)
+        mockStub.On("GetTxID").Return("tx123")
+        mockStub.On("GetState", "cryptokey:key123-tx123").Return([]byte(nil), nil)
+        mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+        mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+        mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
+        
+        contract := new(SmartContract)
+        err := contract.GenerateKey(mockContext, "key123", "symmetric", "AES", 256, `["Org1MSP::user1::CN=ca.Org1MSP"]`, 30)
+        assert.Nil(t, err)
+        
+        return mockStub.Calls[4].Arguments[1].([]byte)
+    }
+    
+    first := endorse()
+    time.Sleep(1100 * time.Millisecond)
+    second := endorse()
+    
+    assert.Equal(t, first, second)
+}
+
+// Тестування валідації параметрів GenerateKey
+func TestGenerateKeyValidation(t *testing.T) {
+    testCases := []struct {
+        name           string
+        id             string
+        keyType        string
+        algorithm      string
+        keySize        int
+        ownerIDs       string
+        expirationDays int
+        field          string
+    }{
+        {name: "Порожній ідентифікатор", id: "", keyType: "symmetric", algorithm: "AES", keySize: 256, ownerIDs: `[]`, expirationDays: 30, field: "id"},
+        {name: "Роздільник у ідентифікаторі", id: "key\x00123", keyType: "symmetric", algorithm: "AES", keySize: 256, ownerIDs: `[]`, expirationDays: 30, field: "id"},
+        {name: "Невідомий тип", id: "key123", keyType: "quantum", algorithm: "AES", keySize: 256, ownerIDs: `[]`, expirationDays: 30, field: "keyType"},
+        {name: "Асиметричний AES", id: "key123", keyType: "asymmetric", algorithm: "AES", keySize: 256, ownerIDs: `[]`, expirationDays: 30, field: "algorithm"},
+        {name: "Короткий RSA", id: "key123", keyType: "asymmetric", algorithm: "RSA", keySize: 1024, ownerIDs: `[]`, expirationDays: 30, field: "keySize"},
+        {name: "AES-192", id: "key123", keyType: "symmetric", algorithm: "AES", keySize: 192, ownerIDs: `[]`, expirationDays: 30, field: "keySize"},
+        {name: "Нульовий строк дії", id: "key123", keyType: "symmetric", algorithm: "AES", keySize: 256, ownerIDs: `[]`, expirationDays: 0, field: "expirationDays"},
+        {name: "Від'ємний строк дії", id: "key123", keyType: "symmetric", algorithm: "AES", keySize: 256, ownerIDs: `[]`, expirationDays: -5, field: "expirationDays"},
+        {name: "Завеликий строк дії", id: "key123", keyType: "symmetric", algorithm: "AES", keySize: 256, ownerIDs: `[]`, expirationDays: 36500, field: "expirationDays"},
+        {name: "Некоректний JSON власників", id: "key123", keyType: "symmetric", algorithm: "AES", keySize: 256, ownerIDs: `user1`, expirationDays: 30, field: "ownerIDs"},
+        {name: "Порожній власник", id: "key123", keyType: "symmetric", algorithm: "AES", keySize: 256, ownerIDs: `[""]`, expirationDays: 30, field: "ownerIDs"},
+        {name: "Повторений власник", id: "key123", keyType: "symmetric", algorithm: "AES", keySize: 256, ownerIDs: `["Org1MSP::user1::CN=ca.Org1MSP", "Org1MSP::user1::CN=ca.Org1MSP"]`, expirationDays: 30, field: "ownerIDs"},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            
+            contract := new(SmartContract)
+            err := contract.GenerateKey(mockContext, tc.id, tc.keyType, tc.algorithm, tc.keySize, tc.ownerIDs, tc.expirationDays)
+            
+            // Помилка типізована і не призводить до звернень до стану
+            var validationErr *ValidationError
+            assert.True(t, errors.As(err, &validationErr))
+            assert.True(t, errors.Is(err, ErrInvalidArgument))
+            assert.Equal(t, tc.field, validationErr.Field)
+            mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+        })
+    }
+}
+
+// Тестування відмови у перезаписі наявного ключа
+func TestGenerateKeyCollision(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    
+    existing := []byte(`{"id":"key123-tx123","type":"symmetric","algorithm":"AES","status":"active"}`)
+    mockStub.On("GetTransient").Return(map[string][]byte{}, nil)
+    mockStub.On("GetTxID").Return("tx123")
+    mockStub.On("GetState", "cryptokey:key123-tx123").Return(existing, nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    err := contract.GenerateKey(mockContext, "key123", "asymmetric", "ECDSA", 256, `["Org1MSP::user1::CN=ca.Org1MSP"]`, 30)
+    
+    // Перевірка результатів
+    assert.True(t, errors.Is(err, ErrAlreadyExists))
+    assert.Contains(t, err.Error(), "ALREADY_EXISTS")
+    mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+}
+
+// Тестування ліміту власників з урахуванням клієнта, що створює ключ
+func TestGenerateKeyOwnerLimitIncludesCaller(t *testing.T) {
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+    
+    owners := make([]string, maxOwners)
+    for i := range owners {
+        owners[i] = "Org2MSP::user" + strconv.Itoa(i) + "::CN=ca.Org2MSP"
+    }
+    ownersJSON, _ := json.Marshal(owners)
+    mockStub.On("GetTransient").Return(map[string][]byte{}, nil)
+    mockStub.On("GetTxID").Return("tx123")
+    mockStub.On("GetState", "cryptokey:key123-tx123").Return([]byte(nil), nil)
+    
+    contract := new(SmartContract)
+    err := contract.GenerateKey(mockContext, "key123", "symmetric", "AES", 256, string(ownersJSON), 30)
+    
+    var validationErr *ValidationError
+    assert.True(t, errors.As(err, &validationErr))
+    assert.Equal(t, "ownerIDs", validationErr.Field)
+    mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+}
+
+// Тестування перевірки строку дії доступу
+func TestGrantKeyAccessValidation(t *testing.T) {
+    for _, expirationDays := range []int{0, -5, 36500} {
+        mockStub := new(MockStub)
+        mockContext := new(MockContext)
+        
+        contract := new(SmartContract)
+        err := contract.GrantKeyAccess(mockContext, "key123", "Org2MSP::user2::CN=ca.Org2MSP", "encrypt", expirationDays)
+        
+        // Некоректний строк відхиляється до будь-яких звернень до стану
+        var validationErr *ValidationError
+        assert.True(t, errors.As(err, &validationErr))
+        assert.Equal(t, "expirationDays", validationErr.Field)
+        mockStub.AssertNotCalled(t, "GetState", mock.Anything)
+    }
+}
+
 // Тестування GrantKeyAccess
 func TestGrantKeyAccess(t *testing.T) {
     // Ініціалізація мок-об'єктів
     mockStub := new(MockStub)
     mockContext := new(MockContext)
     mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
 
     // Підготовка даних для тесту
     keyID := "key123"
-    keyJSON := []byte(`{"id":"key123","type":"symmetric","algorithm":"AES","status":"active","ownerIds":["user1"],"createdAt":1620000000,"activatedAt":1620000000,"expiresAt":1651536000,"revokedAt":0}`)
+    keyJSON := []byte(`{"id":"key123","type":"symmetric","algorithm":"AES","status":"active","ownerIds":["Org1MSP::user1::CN=ca.Org1MSP"],"createdAt":1620000000,"activatedAt":1620000000,"expiresAt":1651536000,"revokedAt":0}`)
     userID := "user2"
     accessType := "encrypt-only"
-    grantedBy := "user1"
+    grantedBy := "Org1MSP::user1::CN=ca.Org1MSP"
     expirationDays := 30
     
     // Очікуємо виклики методів
     mockStub.On("GetState", "cryptokey:"+keyID).Return(keyJSON, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
     mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+    mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
+    mockStub.On("GetState", compositeKey("keyaccess", "key123", "user2")).Return([]byte(nil), nil)
 
     // Створення об'єкту смарт-контракту і виклик методу
     contract := new(SmartContract)
-    err := contract.GrantKeyAccess(mockContext, keyID, userID, accessType, grantedBy, expirationDays)
+    err := contract.GrantKeyAccess(mockContext, keyID, userID, accessType, expirationDays)
     
     // Перевірка результатів
     assert.Nil(t, err)
@@ -133,14 +470,17 @@ func TestGrantKeyAccess(t *testing.T) {
     mockContext.AssertExpectations(t)
     
     // Перевірка, що PutState був викликаний з коректними даними
-    call := mockStub.Calls[1] // Другий виклик - це PutState
+    call := mockStub.Calls[3] // Четвертий виклик - це PutState
     actualKey := call.Arguments[0].(string)
     actualValue := call.Arguments[1].([]byte)
     
     // Перевірка, що ключ має правильний формат
-    expectedKey := "keyaccess:key123-user2"
+    expectedKey := compositeKey("keyaccess", "key123", "user2")
     assert.Equal(t, expectedKey, actualKey)
     
+    // Перевірка запису індексу доступів користувача
+    assert.Equal(t, compositeKey("keyaccess~user", "user2", "key123"), mockStub.Calls[4].Arguments[0].(string))
+    
     // Десеріалізація доступу до ключа для перевірки полів
     var keyAccess KeyAccess
     err = json.Unmarshal(actualValue, &keyAccess)
@@ -153,9 +493,9 @@ func TestGrantKeyAccess(t *testing.T) {
     assert.Equal(t, grantedBy, keyAccess.GrantedBy)
     
     // Перевірка часових міток
-    now := time.Now().Unix()
-    assert.LessOrEqual(t, keyAccess.GrantedAt, now)
-    assert.Greater(t, keyAccess.ExpiresAt, now)
+    now := txTimestamp.Seconds
+    assert.Equal(t, now, keyAccess.GrantedAt)
+    assert.Equal(t, now+int64(expirationDays*24*60*60), keyAccess.ExpiresAt)
 }
 
 // Тестування RevokeKeyAccess
@@ -164,16 +504,22 @@ func TestRevokeKeyAccess(t *testing.T) {
     mockStub := new(MockStub)
     mockContext := new(MockContext)
     mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
 
     // Підготовка даних для тесту
     keyID := "key123"
     userID := "user2"
-    accessKey := "keyaccess:key123-user2"
-    accessJSON := []byte(`{"keyId":"key123","userId":"user2","accessType":"encrypt-only","grantedAt":1620000000,"expiresAt":1651536000,"grantedBy":"user1"}`)
+    accessKey := compositeKey("keyaccess", "key123", "user2")
+    accessJSON := []byte(`{"keyId":"key123","userId":"user2","accessType":"encrypt-only","grantedAt":1620000000,"expiresAt":1651536000,"grantedBy":"Org1MSP::user1::CN=ca.Org1MSP"}`)
+    keyJSON := []byte(`{"id":"key123","type":"symmetric","algorithm":"AES","status":"active","ownerIds":["Org1MSP::user1::CN=ca.Org1MSP"],"createdAt":1620000000,"activatedAt":1620000000,"expiresAt":1651536000,"revokedAt":0}`)
     
     // Очікуємо виклики методів
     mockStub.On("GetState", accessKey).Return(accessJSON, nil)
+    mockStub.On("GetState", "cryptokey:"+keyID).Return(keyJSON, nil)
+    mockStub.On("GetStateByPartialCompositeKey", "keyaccess", []string{"key123"}).Return(&MockQueryIterator{}, nil)
     mockStub.On("DelState", accessKey).Return(nil)
+    mockStub.On("DelState", compositeKey("keyaccess~user", "user2", "key123")).Return(nil)
+    mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
 
     // Створення об'єкту смарт-контракту і виклик методу
     contract := new(SmartContract)
@@ -191,15 +537,20 @@ func TestRotateKey(t *testing.T) {
     mockStub := new(MockStub)
     mockContext := new(MockContext)
     mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
 
     // Підготовка даних для тесту
     keyID := "key123"
-    oldKeyJSON := []byte(`{"id":"key123","type":"symmetric","algorithm":"AES","status":"active","ownerIds":["user1"],"createdAt":1620000000,"activatedAt":1620000000,"expiresAt":1651536000,"revokedAt":0}`)
+    oldKeyJSON := []byte(`{"id":"key123","type":"symmetric","algorithm":"AES","status":"active","ownerIds":["Org1MSP::user1::CN=ca.Org1MSP"],"createdAt":1620000000,"activatedAt":1620000000,"expiresAt":1651536000,"revokedAt":0}`)
     
     // Очікуємо виклики методів
     mockStub.On("GetState", "cryptokey:"+keyID).Return(oldKeyJSON, nil)
+    mockStub.On("GetState", "cryptokey:"+keyID+"-tx456").Return([]byte(nil), nil)
     mockStub.On("GetTxID").Return("tx456")
-    mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil).Times(2) // Один раз для старого ключа, один для нового
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil).Times(3) // Старий ключ, новий ключ та індекс власника
+    mockStub.On("GetStateByPartialCompositeKey", "keyaccess", []string{keyID}).Return(&MockQueryIterator{}, nil)
+    mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
 
     // Створення об'єкту смарт-контракту і виклик методу
     contract := new(SmartContract)
@@ -213,16 +564,16 @@ func TestRotateKey(t *testing.T) {
     mockContext.AssertExpectations(t)
     
     // Перевірка, що старий ключ був оновлений
-    call1 := mockStub.Calls[1] // Другий виклик - це PutState для старого ключа
+    call1 := mockStub.Calls[4] // П'ятий виклик - це PutState для старого ключа
     oldKeyValue := call1.Arguments[1].([]byte)
     
     var updatedOldKey CryptoKey
     err = json.Unmarshal(oldKeyValue, &updatedOldKey)
     assert.Nil(t, err)
-    assert.Equal(t, "rotated", updatedOldKey.Status)
+    assert.Equal(t, "deactivated", updatedOldKey.Status)
     
     // Перевірка, що новий ключ був створений
-    call2 := mockStub.Calls[2] // Третій виклик - це PutState для нового ключа
+    call2 := mockStub.Calls[5] // Шостий виклик - це PutState для нового ключа
     newKeyValue := call2.Arguments[1].([]byte)
     
     var newKey CryptoKey
@@ -233,4 +584,2704 @@ func TestRotateKey(t *testing.T) {
     assert.Equal(t, updatedOldKey.Type, newKey.Type)
     assert.Equal(t, updatedOldKey.Algorithm, newKey.Algorithm)
     assert.Equal(t, updatedOldKey.OwnerIDs, newKey.OwnerIDs)
-}
\ No newline at end of file
+    
+    // Перевірка зв'язку між версіями ключа
+    assert.Equal(t, newKey.ID, updatedOldKey.ReplacedBy)
+    assert.Equal(t, keyID, newKey.PreviousKeyID)
+    assert.Equal(t, txTimestamp.Seconds, newKey.CreatedAt)
+    
+    // Новий ключ додано до індексу власників
+    assert.Equal(t, compositeKey("owner~key", "Org1MSP::user1::CN=ca.Org1MSP", newKeyID), mockStub.Calls[6].Arguments[0].(string))
+}
+
+// Тестування перенесення доступів при ротації ключа
+func TestRotateKeyCarriesAccess(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+
+    // Підготовка даних для тесту
+    keyID := "key123"
+    future := txTimestamp.Seconds + 3600
+    oldKeyJSON := []byte(`{"id":"key123","type":"symmetric","algorithm":"AES","status":"active","ownerIds":["Org1MSP::user1::CN=ca.Org1MSP"],"createdAt":1620000000,"activatedAt":1620000000,"expiresAt":1651536000,"revokedAt":0}`)
+    activeAccess, _ := json.Marshal(KeyAccess{KeyID: keyID, UserID: "user2", AccessType: "full", ExpiresAt: future, GrantedBy: "user1", UsageCount: 7, UsageQuota: 10})
+    expiredAccess, _ := json.Marshal(KeyAccess{KeyID: keyID, UserID: "user3", AccessType: "full", ExpiresAt: 1620000000, GrantedBy: "user1"})
+    iterator := &MockQueryIterator{
+        Results: []*queryresult.KV{
+            {Key: compositeKey("keyaccess", keyID, "user2"), Value: activeAccess},
+            {Key: compositeKey("keyaccess", keyID, "user3"), Value: expiredAccess},
+        },
+    }
+    
+    // Очікуємо виклики методів
+    mockStub.On("GetState", "cryptokey:"+keyID).Return(oldKeyJSON, nil)
+    mockStub.On("GetState", "cryptokey:key123-tx456789").Return([]byte(nil), nil)
+    mockStub.On("GetTxID").Return("tx456789abc")
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+    mockStub.On("GetStateByPartialCompositeKey", "keyaccess", []string{keyID}).Return(iterator, nil)
+    mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
+
+    // Створення об'єкту смарт-контракту і виклик методу
+    contract := new(SmartContract)
+    newKeyID, err := contract.RotateKey(mockContext, keyID)
+    
+    // Перевірка результатів
+    assert.Nil(t, err)
+    assert.Equal(t, "key123-tx456789", newKeyID)
+    
+    // Переноситься лише чинний доступ разом з індексом користувача
+    mockStub.AssertNumberOfCalls(t, "PutState", 5)
+    call := mockStub.Calls[8] // Дев'ятий виклик - це PutState для перенесеного доступу
+    assert.Equal(t, compositeKey("keyaccess", "key123-tx456789", "user2"), call.Arguments[0].(string))
+    
+    var carried KeyAccess
+    err = json.Unmarshal(call.Arguments[1].([]byte), &carried)
+    assert.Nil(t, err)
+    assert.Equal(t, newKeyID, carried.KeyID)
+    assert.Equal(t, "full", carried.AccessType)
+    assert.Equal(t, future, carried.ExpiresAt)
+    
+    // Ротація не поновлює ліміт використань
+    assert.Equal(t, int64(7), carried.UsageCount)
+    assert.Equal(t, int64(10), carried.UsageQuota)
+}
+// Тестування додавання клієнта до власників ключа
+func TestGenerateKeyAddsCallerAsOwner(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org3MSP", "user3"))
+    
+    // Очікуємо виклики методів
+    mockStub.On("GetTransient").Return(map[string][]byte{}, nil)
+    mockStub.On("GetTxID").Return("tx123")
+    mockStub.On("GetState", "cryptokey:key123-tx123").Return([]byte(nil), nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+    mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
+    
+    // Клієнт вказує власником лише іншого користувача
+    contract := new(SmartContract)
+    err := contract.GenerateKey(mockContext, "key123", "symmetric", "AES", 256, `["Org1MSP::user1::CN=ca.Org1MSP"]`, 30)
+    assert.Nil(t, err)
+    
+    var cryptoKey CryptoKey
+    err = json.Unmarshal(mockStub.Calls[4].Arguments[1].([]byte), &cryptoKey)
+    assert.Nil(t, err)
+    assert.Equal(t, []string{"Org3MSP::user3::CN=ca.Org3MSP", "Org1MSP::user1::CN=ca.Org1MSP"}, cryptoKey.OwnerIDs)
+}
+
+// Тестування того, що однаковий CN від різних CA дає різні ідентичності
+func TestCallerIdentityIncludesIssuer(t *testing.T) {
+    identities := map[string]bool{}
+    for _, issuer := range []string{"ca1.org1", "ca2.org1"} {
+        clientIdentity := new(MockClientIdentity)
+        clientIdentity.On("GetMSPID").Return("Org1MSP", nil)
+        clientIdentity.On("GetX509Certificate").Return(&x509.Certificate{Subject: pkix.Name{CommonName: "user1"}, Issuer: pkix.Name{CommonName: issuer}}, nil)
+        mockContext := new(MockContext)
+        mockContext.On("GetClientIdentity").Return(clientIdentity)
+        
+        identity, err := new(SmartContract).GetCallerIdentity(mockContext)
+        assert.Nil(t, err)
+        assert.Equal(t, "Org1MSP::user1::CN="+issuer, identity)
+        identities[identity] = true
+    }
+    assert.Len(t, identities, 2)
+    
+    // Сертифікат без видавця не ідентифікує клієнта
+    clientIdentity := new(MockClientIdentity)
+    clientIdentity.On("GetMSPID").Return("Org1MSP", nil)
+    clientIdentity.On("GetX509Certificate").Return(&x509.Certificate{Subject: pkix.Name{CommonName: "user1"}}, nil)
+    mockContext := new(MockContext)
+    mockContext.On("GetClientIdentity").Return(clientIdentity)
+    _, err := new(SmartContract).GetCallerIdentity(mockContext)
+    assert.NotNil(t, err)
+}
+
+// Тестування відмови у наданні доступу клієнту, який не є власником
+func TestGrantKeyAccessByNonOwner(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org2MSP", "mallory"))
+    
+    // Підготовка даних для тесту
+    keyJSON := []byte(`{"id":"key123","type":"symmetric","algorithm":"AES","status":"active","ownerIds":["Org1MSP::user1::CN=ca.Org1MSP"],"createdAt":1620000000,"activatedAt":1620000000,"expiresAt":1651536000,"revokedAt":0}`)
+    
+    // Очікуємо виклики методів
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    mockStub.On("GetState", compositeKey("keyaccess", "key123", "Org2MSP::mallory::CN=ca.Org2MSP")).Return([]byte(nil), nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    
+    // Створення об'єкту смарт-контракту і виклик методу
+    contract := new(SmartContract)
+    err := contract.GrantKeyAccess(mockContext, "key123", "Org2MSP::mallory::CN=ca.Org2MSP", "full", 30)
+    
+    // Перевірка результатів
+    assert.NotNil(t, err)
+    mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+}
+
+// Тестування надання доступу користувачем з правом передачі доступу
+func TestGrantKeyAccessByFullGrantee(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org2MSP", "user2"))
+    
+    // Підготовка даних для тесту
+    keyJSON := []byte(`{"id":"key123","type":"symmetric","algorithm":"AES","status":"active","ownerIds":["Org1MSP::user1::CN=ca.Org1MSP"],"createdAt":1620000000,"activatedAt":1620000000,"expiresAt":1651536000,"revokedAt":0}`)
+    fullAccessJSON := []byte(`{"keyId":"key123","userId":"Org2MSP::user2::CN=ca.Org2MSP","accessType":"full","grantedAt":1620000000,"expiresAt":1651536000,"grantedBy":"Org1MSP::user1::CN=ca.Org1MSP","canDelegate":true,"delegationDepth":1}`)
+    
+    // Очікуємо виклики методів
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    mockStub.On("GetState", compositeKey("keyaccess", "key123", "Org2MSP::user2::CN=ca.Org2MSP")).Return(fullAccessJSON, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("PutState", compositeKey("keyaccess", "key123", "Org3MSP::user3::CN=ca.Org3MSP"), mock.Anything).Return(nil)
+    mockStub.On("PutState", compositeKey("keyaccess~user", "Org3MSP::user3::CN=ca.Org3MSP", "key123"), mock.Anything).Return(nil)
+    mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
+    mockStub.On("GetState", compositeKey("keyaccess", "key123", "Org3MSP::user3::CN=ca.Org3MSP")).Return([]byte(nil), nil)
+    
+    // Створення об'єкту смарт-контракту і виклик методу
+    contract := new(SmartContract)
+    err := contract.GrantKeyAccess(mockContext, "key123", "Org3MSP::user3::CN=ca.Org3MSP", "decrypt-only", 30)
+    
+    // Перевірка результатів
+    assert.Nil(t, err)
+    mockStub.AssertExpectations(t)
+    
+    var keyAccess KeyAccess
+    err = json.Unmarshal(mockStub.Calls[4].Arguments[1].([]byte), &keyAccess)
+    assert.Nil(t, err)
+    assert.Equal(t, "Org2MSP::user2::CN=ca.Org2MSP", keyAccess.GrantedBy)
+    assert.False(t, keyAccess.CanDelegate)
+}
+
+// Тестування відмови у ротації ключа клієнту, який не є власником
+func TestRotateKeyByNonOwner(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org2MSP", "user2"))
+    
+    // Підготовка даних для тесту
+    keyJSON := []byte(`{"id":"key123","type":"symmetric","algorithm":"AES","status":"active","ownerIds":["Org1MSP::user1::CN=ca.Org1MSP"],"createdAt":1620000000,"activatedAt":1620000000,"expiresAt":1651536000,"revokedAt":0}`)
+    
+    // Очікуємо виклики методів
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    
+    // Створення об'єкту смарт-контракту і виклик методу
+    contract := new(SmartContract)
+    newKeyID, err := contract.RotateKey(mockContext, "key123")
+    
+    // Перевірка результатів
+    assert.NotNil(t, err)
+    assert.Empty(t, newKeyID)
+    mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+}
+
+// Тестування відмови в ротації простроченого ключа та ротації на зайнятий ідентифікатор
+func TestRotateKeyRejected(t *testing.T) {
+    testCases := []struct {
+        name       string
+        expiresAt  int64
+        newKeyJSON []byte
+    }{
+        {name: "Строк дії ключа минув", expiresAt: txTimestamp.Seconds - 1, newKeyJSON: nil},
+        {name: "Ідентифікатор нової версії зайнятий", expiresAt: 1651536000, newKeyJSON: []byte(`{"id":"key123-tx456"}`)},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            mockContext.On("GetStub").Return(mockStub)
+            mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+            
+            keyJSON, _ := json.Marshal(CryptoKey{ID: "key123", Status: "active", OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP"}, ActivatedAt: 1620000000, ExpiresAt: tc.expiresAt})
+            mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+            mockStub.On("GetState", "cryptokey:key123-tx456").Return(tc.newKeyJSON, nil)
+            mockStub.On("GetTxID").Return("tx456")
+            mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+            
+            contract := new(SmartContract)
+            newKeyID, err := contract.RotateKey(mockContext, "key123")
+            
+            assert.NotNil(t, err)
+            assert.Empty(t, newKeyID)
+            mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+        })
+    }
+}
+
+// Тестування таблиці переходів життєвого циклу ключа
+func TestTransitionKey(t *testing.T) {
+    testCases := []struct {
+        name    string
+        from    string
+        to      string
+        allowed bool
+    }{
+        {name: "Активація", from: "pre-activation", to: "active", allowed: true},
+        {name: "Призупинення", from: "active", to: "suspended", allowed: true},
+        {name: "Відновлення", from: "suspended", to: "active", allowed: true},
+        {name: "Деактивація", from: "active", to: "deactivated", allowed: true},
+        {name: "Компрометація деактивованого", from: "deactivated", to: "compromised", allowed: true},
+        {name: "Знищення скомпрометованого", from: "compromised", to: "destroyed", allowed: true},
+        {name: "Знищення активного", from: "active", to: "destroyed", allowed: false},
+        {name: "Повернення деактивованого", from: "deactivated", to: "active", allowed: false},
+        {name: "Відновлення скомпрометованого", from: "compromised", to: "active", allowed: false},
+        {name: "Будь-що після знищення", from: "destroyed", to: "compromised", allowed: false},
+        {name: "Невідомий стан", from: "rotated", to: "active", allowed: false},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            mockContext.On("GetStub").Return(mockStub)
+            
+            key := &CryptoKey{ID: "key123", Status: tc.from}
+            err := transitionKey(mockContext, key, tc.to, "", 1630000000)
+            
+            if tc.allowed {
+                assert.Nil(t, err)
+                assert.Equal(t, tc.to, key.Status)
+            } else {
+                assert.NotNil(t, err)
+                assert.Equal(t, tc.from, key.Status)
+            }
+        })
+    }
+}
+
+// Тестування RevokeKey з різними причинами
+func TestRevokeKey(t *testing.T) {
+    testCases := []struct {
+        name           string
+        reason         string
+        expectedStatus string
+    }{
+        {name: "Заміна ключа", reason: "superseded", expectedStatus: "deactivated"},
+        {name: "Компрометація ключа", reason: "keyCompromise", expectedStatus: "compromised"},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            // Ініціалізація мок-об'єктів
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            mockContext.On("GetStub").Return(mockStub)
+            mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+            
+            keyJSON := []byte(`{"id":"key123","type":"symmetric","algorithm":"AES","status":"active","ownerIds":["Org1MSP::user1::CN=ca.Org1MSP"],"createdAt":1620000000,"activatedAt":1620000000,"expiresAt":1651536000,"revokedAt":0}`)
+            mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+            mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+            mockStub.On("PutState", "cryptokey:key123", mock.Anything).Return(nil)
+            mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
+            
+            // Виклик методу
+            contract := new(SmartContract)
+            err := contract.RevokeKey(mockContext, "key123", tc.reason)
+            assert.Nil(t, err)
+            mockStub.AssertExpectations(t)
+            
+            // Перевірка оновленого ключа
+            var key CryptoKey
+            err = json.Unmarshal(mockStub.Calls[2].Arguments[1].([]byte), &key)
+            assert.Nil(t, err)
+            assert.Equal(t, tc.expectedStatus, key.Status)
+            assert.Equal(t, tc.reason, key.RevocationReason)
+            assert.Equal(t, txTimestamp.Seconds, key.RevokedAt)
+            assert.Equal(t, int64(1620000000), key.ActivatedAt)
+        })
+    }
+}
+
+// Тестування RevokeKey з невідомою причиною
+func TestRevokeKeyUnknownReason(t *testing.T) {
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    
+    contract := new(SmartContract)
+    err := contract.RevokeKey(mockContext, "key123", "bored")
+    
+    assert.NotNil(t, err)
+    mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+}
+
+// Тестування відмови у знищенні активного ключа
+func TestDestroyActiveKey(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+    
+    keyJSON := []byte(`{"id":"key123","type":"symmetric","algorithm":"AES","status":"active","ownerIds":["Org1MSP::user1::CN=ca.Org1MSP"],"createdAt":1620000000,"activatedAt":1620000000,"expiresAt":1651536000,"revokedAt":0}`)
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    err := contract.DestroyKey(mockContext, "key123")
+    
+    // Перевірка результатів
+    assert.NotNil(t, err)
+    mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+}
+
+// Тестування активації ключа, створеного у стані pre-activation
+func TestActivateKey(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+    
+    keyJSON := []byte(`{"id":"key123","type":"symmetric","algorithm":"AES","status":"pre-activation","ownerIds":["Org1MSP::user1::CN=ca.Org1MSP"],"createdAt":1620000000,"activatedAt":0,"expiresAt":1651536000,"revokedAt":0}`)
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("PutState", "cryptokey:key123", mock.Anything).Return(nil)
+    mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    err := contract.ActivateKey(mockContext, "key123")
+    assert.Nil(t, err)
+    
+    // Перевірка оновленого ключа
+    var key CryptoKey
+    err = json.Unmarshal(mockStub.Calls[3].Arguments[1].([]byte), &key)
+    assert.Nil(t, err)
+    assert.Equal(t, "active", key.Status)
+    assert.Equal(t, txTimestamp.Seconds, key.ActivatedAt)
+}
+
+// Тестування відмови у наданні доступу до простроченого ключа
+func TestGrantKeyAccessExpiredKey(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    
+    // Строк дії ключа минув до часу транзакції
+    keyJSON := []byte(`{"id":"key123","type":"symmetric","algorithm":"AES","status":"active","ownerIds":["Org1MSP::user1::CN=ca.Org1MSP"],"createdAt":1600000000,"activatedAt":1600000000,"expiresAt":1620000000,"revokedAt":0}`)
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    err := contract.GrantKeyAccess(mockContext, "key123", "user2", "full", 30)
+    
+    // Перевірка результатів
+    assert.NotNil(t, err)
+    mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+}
+
+// Тестування обмеження строку доступу строком дії ключа
+func TestGrantKeyAccessCappedByKeyExpiry(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+    
+    // Ключ діє ще 10 діб, а доступ запитується на 30
+    keyExpiresAt := txTimestamp.Seconds + 10*24*60*60
+    key := CryptoKey{ID: "key123", Type: "symmetric", Algorithm: "AES", Status: "active", OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP"}, ExpiresAt: keyExpiresAt}
+    keyJSON, _ := json.Marshal(key)
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+    mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
+    mockStub.On("GetState", compositeKey("keyaccess", "key123", "user2")).Return([]byte(nil), nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    err := contract.GrantKeyAccess(mockContext, "key123", "user2", "full", 30)
+    assert.Nil(t, err)
+    
+    var keyAccess KeyAccess
+    err = json.Unmarshal(mockStub.Calls[3].Arguments[1].([]byte), &keyAccess)
+    assert.Nil(t, err)
+    assert.Equal(t, keyExpiresAt, keyAccess.ExpiresAt)
+}
+
+// Тестування CheckKeyAccess
+func TestCheckKeyAccess(t *testing.T) {
+    future := txTimestamp.Seconds + 3600
+    past := txTimestamp.Seconds - 3600
+    
+    testCases := []struct {
+        name         string
+        keyStatus    string
+        keyExpiresAt int64
+        accessType   string
+        grantExpiry  int64
+        noGrant      bool
+        operation    string
+        expected     bool
+    }{
+        {name: "Повний доступ, шифрування", keyStatus: "active", keyExpiresAt: future, accessType: "full", grantExpiry: future, operation: "encrypt", expected: true},
+        {name: "Лише шифрування, розшифрування", keyStatus: "active", keyExpiresAt: future, accessType: "encrypt-only", grantExpiry: future, operation: "decrypt", expected: false},
+        {name: "Лише розшифрування, розшифрування", keyStatus: "active", keyExpiresAt: future, accessType: "decrypt-only", grantExpiry: future, operation: "decrypt", expected: true},
+        {name: "Прострочений доступ", keyStatus: "active", keyExpiresAt: future, accessType: "full", grantExpiry: past, operation: "encrypt", expected: false},
+        {name: "Доступ відсутній", keyStatus: "active", keyExpiresAt: future, noGrant: true, operation: "encrypt", expected: false},
+        {name: "Прострочений ключ, шифрування", keyStatus: "active", keyExpiresAt: past, accessType: "full", grantExpiry: future, operation: "encrypt", expected: false},
+        {name: "Прострочений ключ, розшифрування", keyStatus: "active", keyExpiresAt: past, accessType: "full", grantExpiry: future, operation: "decrypt", expected: true},
+        {name: "Призупинений ключ", keyStatus: "suspended", keyExpiresAt: future, accessType: "full", grantExpiry: future, operation: "decrypt", expected: false},
+        {name: "Скомпрометований ключ", keyStatus: "compromised", keyExpiresAt: future, accessType: "full", grantExpiry: future, operation: "decrypt", expected: false},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            // Ініціалізація мок-об'єктів
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            mockContext.On("GetStub").Return(mockStub)
+            
+            key := CryptoKey{ID: "key123", Type: "symmetric", Algorithm: "AES", Status: tc.keyStatus, OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP"}, ExpiresAt: tc.keyExpiresAt}
+            keyJSON, _ := json.Marshal(key)
+            accessJSON := []byte(nil)
+            if !tc.noGrant {
+                accessJSON, _ = json.Marshal(KeyAccess{KeyID: "key123", UserID: "user2", AccessType: tc.accessType, ExpiresAt: tc.grantExpiry})
+            }
+            mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+            mockStub.On("GetState", compositeKey("keyaccess", "key123", "user2")).Return(accessJSON, nil)
+            mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+            
+            // Виклик методу
+            contract := new(SmartContract)
+            result, err := contract.CheckKeyAccess(mockContext, "key123", "user2", tc.operation)
+            
+            // Перевірка результатів
+            assert.Nil(t, err)
+            assert.Equal(t, tc.expected, result)
+        })
+    }
+}
+
+// Тестування SweepExpired
+func TestSweepExpired(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    
+    // Підготовка даних для тесту
+    future := txTimestamp.Seconds + 3600
+    past := txTimestamp.Seconds - 3600
+    expiredKey, _ := json.Marshal(CryptoKey{ID: "key1", Status: "active", ExpiresAt: past})
+    validKey, _ := json.Marshal(CryptoKey{ID: "key2", Status: "active", ExpiresAt: future})
+    destroyedKey, _ := json.Marshal(CryptoKey{ID: "key3", Status: "destroyed", ExpiresAt: past})
+    expiredGrant, _ := json.Marshal(KeyAccess{KeyID: "key2", UserID: "user1", ExpiresAt: past})
+    validGrant, _ := json.Marshal(KeyAccess{KeyID: "key2", UserID: "user2", ExpiresAt: future})
+    
+    keys := &MockQueryIterator{Results: []*queryresult.KV{
+        {Key: "cryptokey:key1", Value: expiredKey},
+        {Key: "cryptokey:key2", Value: validKey},
+        {Key: "cryptokey:key3", Value: destroyedKey},
+    }}
+    grants := &MockQueryIterator{Results: []*queryresult.KV{
+        {Key: compositeKey("keyaccess", "key2", "user1"), Value: expiredGrant},
+        {Key: compositeKey("keyaccess", "key2", "user2"), Value: validGrant},
+    }}
+    
+    // Очікуємо виклики методів
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("GetStateByRange", "cryptokey:", "cryptokey~").Return(keys, nil)
+    mockStub.On("GetStateByPartialCompositeKey", "keyaccess", []string{"key1"}).Return(&MockQueryIterator{}, nil)
+    mockStub.On("GetStateByPartialCompositeKey", "keyaccess", []string{"key2"}).Return(grants, nil)
+    mockStub.On("GetStateByPartialCompositeKey", "keyaccess", []string{"key3"}).Return(&MockQueryIterator{}, nil)
+    mockStub.On("PutState", "cryptokey:key1", mock.Anything).Return(nil)
+    mockStub.On("DelState", compositeKey("keyaccess", "key2", "user1")).Return(nil)
+    mockStub.On("DelState", compositeKey("keyaccess~user", "user1", "key2")).Return(nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    result, err := contract.SweepExpired(mockContext, "", 10)
+    
+    // Перевірка результатів
+    assert.Nil(t, err)
+    assert.Equal(t, 3, result.Examined)
+    assert.Equal(t, 1, result.KeysExpired)
+    assert.Equal(t, 1, result.GrantsDeleted)
+    assert.Empty(t, result.Cursor)
+    mockStub.AssertExpectations(t)
+    
+    var key CryptoKey
+    err = json.Unmarshal(mockStub.Calls[2].Arguments[1].([]byte), &key)
+    assert.Nil(t, err)
+    assert.Equal(t, "deactivated", key.Status)
+}
+
+// Тестування обмеження розміру пакета SweepExpired
+func TestSweepExpiredBatchLimit(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    
+    // Підготовка даних для тесту
+    future := txTimestamp.Seconds + 3600
+    key2, _ := json.Marshal(CryptoKey{ID: "key2", Status: "active", ExpiresAt: future})
+    key3, _ := json.Marshal(CryptoKey{ID: "key3", Status: "active", ExpiresAt: future})
+    keys := &MockQueryIterator{Results: []*queryresult.KV{
+        {Key: "cryptokey:key2", Value: key2},
+        {Key: "cryptokey:key3", Value: key3},
+    }}
+    
+    // Очікуємо виклики методів
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("GetStateByRange", "cryptokey:key1\x00", "cryptokey~").Return(keys, nil)
+    mockStub.On("GetStateByPartialCompositeKey", "keyaccess", []string{"key2"}).Return(&MockQueryIterator{}, nil)
+    
+    // Виклик методу з курсором попереднього пакета
+    contract := new(SmartContract)
+    result, err := contract.SweepExpired(mockContext, "cryptokey:key1", 1)
+    
+    // Перевірка результатів
+    assert.Nil(t, err)
+    assert.Equal(t, 1, result.Examined)
+    assert.Equal(t, "cryptokey:key2", result.Cursor)
+    mockStub.AssertNotCalled(t, "GetStateByPartialCompositeKey", "keyaccess", []string{"key3"})
+}
+
+// Тестування GetKeysByOwner
+func TestGetKeysByOwner(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    
+    // Підготовка даних для тесту: строк дії другого ключа минув
+    future := txTimestamp.Seconds + 3600
+    past := txTimestamp.Seconds - 3600
+    key1, _ := json.Marshal(CryptoKey{ID: "key1", Status: "active", OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP"}, ExpiresAt: future})
+    key2, _ := json.Marshal(CryptoKey{ID: "key2", Status: "active", OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP"}, ExpiresAt: past})
+    index := &MockQueryIterator{Results: []*queryresult.KV{
+        {Key: compositeKey("owner~key", "Org1MSP::user1::CN=ca.Org1MSP", "key1"), Value: indexValue},
+        {Key: compositeKey("owner~key", "Org1MSP::user1::CN=ca.Org1MSP", "key2"), Value: indexValue},
+    }}
+    
+    // Очікуємо виклики методів
+    mockStub.On("GetStateByPartialCompositeKey", "owner~key", []string{"Org1MSP::user1::CN=ca.Org1MSP"}).Return(index, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("GetState", "cryptokey:key1").Return(key1, nil)
+    mockStub.On("GetState", "cryptokey:key2").Return(key2, nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    keys, err := contract.GetKeysByOwner(mockContext, "Org1MSP::user1::CN=ca.Org1MSP")
+    
+    // Перевірка результатів
+    assert.Nil(t, err)
+    assert.Len(t, keys, 2)
+    assert.Equal(t, "key1", keys[0].ID)
+    assert.Equal(t, "active", keys[0].Status)
+    assert.Equal(t, "deactivated", keys[1].Status)
+}
+
+// Тестування GetAccessGrantsForUser
+func TestGetAccessGrantsForUser(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    
+    // Підготовка даних для тесту
+    future := txTimestamp.Seconds + 3600
+    past := txTimestamp.Seconds - 3600
+    validGrant, _ := json.Marshal(KeyAccess{KeyID: "key1", UserID: "user2", AccessType: "full", ExpiresAt: future})
+    expiredGrant, _ := json.Marshal(KeyAccess{KeyID: "key2", UserID: "user2", AccessType: "full", ExpiresAt: past})
+    index := &MockQueryIterator{Results: []*queryresult.KV{
+        {Key: compositeKey("keyaccess~user", "user2", "key1"), Value: indexValue},
+        {Key: compositeKey("keyaccess~user", "user2", "key2"), Value: indexValue},
+    }}
+    
+    // Очікуємо виклики методів
+    mockStub.On("GetStateByPartialCompositeKey", "keyaccess~user", []string{"user2"}).Return(index, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("GetState", compositeKey("keyaccess", "key1", "user2")).Return(validGrant, nil)
+    mockStub.On("GetState", compositeKey("keyaccess", "key2", "user2")).Return(expiredGrant, nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    grants, err := contract.GetAccessGrantsForUser(mockContext, "user2")
+    
+    // Прострочений доступ не повертається
+    assert.Nil(t, err)
+    assert.Len(t, grants, 1)
+    assert.Equal(t, "key1", grants[0].KeyID)
+}
+
+// Тестування GetAccessGrantsForKeyWithPagination
+func TestGetAccessGrantsForKeyWithPagination(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    
+    // Підготовка даних для тесту
+    future := txTimestamp.Seconds + 3600
+    grant, _ := json.Marshal(KeyAccess{KeyID: "key1", UserID: "user2", AccessType: "decrypt-only", ExpiresAt: future})
+    page := &MockQueryIterator{Results: []*queryresult.KV{
+        {Key: compositeKey("keyaccess", "key1", "user2"), Value: grant},
+    }}
+    metadata := &pb.QueryResponseMetadata{FetchedRecordsCount: 1, Bookmark: "next"}
+    
+    // Очікуємо виклики методів
+    mockStub.On("GetStateByPartialCompositeKeyWithPagination", "keyaccess", []string{"key1"}, int32(1), "").Return(page, metadata, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    result, err := contract.GetAccessGrantsForKeyWithPagination(mockContext, "key1", 1, "")
+    
+    // Перевірка результатів
+    assert.Nil(t, err)
+    assert.Len(t, result.Records, 1)
+    assert.Equal(t, "user2", result.Records[0].UserID)
+    assert.Equal(t, int32(1), result.FetchedRecordsCount)
+    assert.Equal(t, "next", result.Bookmark)
+    
+    // Некоректний розмір сторінки відхиляється без звернення до стану
+    _, err = contract.GetAccessGrantsForKeyWithPagination(mockContext, "key1", 0, "")
+    assert.NotNil(t, err)
+    mockStub.AssertNumberOfCalls(t, "GetStateByPartialCompositeKeyWithPagination", 1)
+}
+
+// newTestPublicKey генерує ключову пару ECDSA P-256 і повертає її разом з відкритим ключем у PEM
+func newTestPublicKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+    privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    assert.Nil(t, err)
+    der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+    assert.Nil(t, err)
+    return privateKey, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// Тестування RegisterPublicKey
+func TestRegisterPublicKey(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+    
+    // Підготовка даних для тесту
+    _, publicPEM := newTestPublicKey(t)
+    keyJSON := []byte(`{"id":"key123","type":"asymmetric","algorithm":"ECDSA","keySize":256,"status":"active","ownerIds":["Org1MSP::user1::CN=ca.Org1MSP"],"expiresAt":1651536000}`)
+    
+    // Очікуємо виклики методів
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    mockStub.On("GetState", "pubkey:key123").Return([]byte(nil), nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("PutState", "pubkey:key123", mock.Anything).Return(nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    record, err := contract.RegisterPublicKey(mockContext, "key123", publicPEM)
+    
+    // Перевірка результатів
+    assert.Nil(t, err)
+    mockStub.AssertExpectations(t)
+    assert.Equal(t, "ECDSA", record.Algorithm)
+    assert.Equal(t, 256, record.KeySize)
+    assert.Equal(t, "Org1MSP::user1::CN=ca.Org1MSP", record.RegisteredBy)
+    
+    block, _ := pem.Decode([]byte(publicPEM))
+    fingerprint := sha256.Sum256(block.Bytes)
+    assert.Equal(t, hex.EncodeToString(fingerprint[:]), record.Fingerprint)
+}
+
+// Тестування відмови у реєстрації відкритого ключа, що не відповідає запису
+func TestRegisterPublicKeyMismatch(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    
+    // Запис оголошує RSA-2048, а передається ключ ECDSA
+    _, publicPEM := newTestPublicKey(t)
+    keyJSON := []byte(`{"id":"key123","type":"asymmetric","algorithm":"RSA","keySize":2048,"status":"active","ownerIds":["Org1MSP::user1::CN=ca.Org1MSP"]}`)
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    _, err := contract.RegisterPublicKey(mockContext, "key123", publicPEM)
+    
+    // Перевірка результатів
+    assert.True(t, errors.Is(err, ErrInvalidArgument))
+    mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+}
+
+// Тестування VerifySignature
+func TestVerifySignature(t *testing.T) {
+    privateKey, publicPEM := newTestPublicKey(t)
+    message := []byte("payload")
+    digest := sha256.Sum256(message)
+    signature, err := ecdsa.SignASN1(rand.Reader, privateKey, digest[:])
+    assert.Nil(t, err)
+    
+    testCases := []struct {
+        name      string
+        keyStatus string
+        message   []byte
+        expected  bool
+    }{
+        {name: "Коректний підпис", keyStatus: "active", message: message, expected: true},
+        {name: "Змінене повідомлення", keyStatus: "active", message: []byte("tampered"), expected: false},
+        {name: "Деактивований ключ", keyStatus: "deactivated", message: message, expected: true},
+        {name: "Скомпрометований ключ", keyStatus: "compromised", message: message, expected: false},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            // Ініціалізація мок-об'єктів
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            mockContext.On("GetStub").Return(mockStub)
+            
+            key := CryptoKey{ID: "key123", Type: "asymmetric", Algorithm: "ECDSA", KeySize: 256, Status: tc.keyStatus, ExpiresAt: txTimestamp.Seconds + 3600}
+            keyJSON, _ := json.Marshal(key)
+            recordJSON, _ := json.Marshal(PublicKeyRecord{KeyID: "key123", Algorithm: "ECDSA", KeySize: 256, PEM: publicPEM})
+            mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+            mockStub.On("GetState", "pubkey:key123").Return(recordJSON, nil)
+            mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+            
+            // Виклик методу
+            contract := new(SmartContract)
+            valid, err := contract.VerifySignature(mockContext, "key123", base64.StdEncoding.EncodeToString(tc.message), base64.StdEncoding.EncodeToString(signature))
+            
+            // Перевірка результатів
+            assert.Nil(t, err)
+            assert.Equal(t, tc.expected, valid)
+        })
+    }
+}
+
+// Тестування збереження приватних метаданих при створенні ключа
+func TestGenerateKeyWithPrivateMetadata(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org2MSP", "user2"))
+    
+    // Метадані передаються лише через transient map
+    metadataJSON := []byte(`{"custodian":"Org2MSP::user2::CN=ca.Org2MSP","hsmSlot":"slot-7","wrappedKey":"AAEC"}`)
+    mockStub.On("GetTransient").Return(map[string][]byte{"metadata": metadataJSON}, nil)
+    mockStub.On("GetTxID").Return("tx123")
+    mockStub.On("GetState", "cryptokey:key123-tx123").Return([]byte(nil), nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("PutPrivateData", "Org2MSPKeyMetadata", "cryptokey:key123-tx123", metadataJSON).Return(nil)
+    mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+    mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    err := contract.GenerateKey(mockContext, "key123", "symmetric", "AES", 256, `[]`, 30)
+    assert.Nil(t, err)
+    mockStub.AssertExpectations(t)
+    
+    // Публічний запис містить лише хеш метаданих
+    var cryptoKey CryptoKey
+    err = json.Unmarshal(mockStub.Calls[5].Arguments[1].([]byte), &cryptoKey)
+    assert.Nil(t, err)
+    hash := sha256.Sum256(metadataJSON)
+    assert.Equal(t, hex.EncodeToString(hash[:]), cryptoKey.Metadata)
+    assert.Equal(t, "Org2MSPKeyMetadata", cryptoKey.MetadataCollection)
+    assert.NotContains(t, string(mockStub.Calls[5].Arguments[1].([]byte)), "slot-7")
+}
+
+// Тестування перенесення приватних метаданих у колекцію співвласника з іншої організації
+func TestSetKeyMetadataMovesCollection(t *testing.T) {
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org2MSP", "user2"))
+    
+    keyJSON, _ := json.Marshal(CryptoKey{
+        ID:                 "key123",
+        Status:             "active",
+        OwnerIDs:           []string{"Org1MSP::user1::CN=ca.Org1MSP", "Org2MSP::user2::CN=ca.Org2MSP"},
+        Metadata:           "oldhash",
+        MetadataCollection: "Org1MSPKeyMetadata",
+    })
+    metadataJSON := []byte(`{"custodian":"Org2MSP::user2::CN=ca.Org2MSP","hsmSlot":"slot-9"}`)
+    mockStub.On("GetTransient").Return(map[string][]byte{"metadata": metadataJSON}, nil)
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    mockStub.On("DelPrivateData", "Org1MSPKeyMetadata", "cryptokey:key123").Return(nil)
+    mockStub.On("PutPrivateData", "Org2MSPKeyMetadata", "cryptokey:key123", metadataJSON).Return(nil)
+    mockStub.On("PutState", "cryptokey:key123", mock.Anything).Return(nil)
+    
+    contract := new(SmartContract)
+    err := contract.SetKeyMetadata(mockContext, "key123")
+    assert.Nil(t, err)
+    mockStub.AssertExpectations(t)
+    
+    var cryptoKey CryptoKey
+    err = json.Unmarshal(mockStub.Calls[len(mockStub.Calls)-1].Arguments[1].([]byte), &cryptoKey)
+    assert.Nil(t, err)
+    assert.Equal(t, "Org2MSPKeyMetadata", cryptoKey.MetadataCollection)
+    assert.Equal(t, metadataHash(metadataJSON), cryptoKey.Metadata)
+}
+
+// Тестування GetKeyMetadata
+func TestGetKeyMetadata(t *testing.T) {
+    metadataJSON := []byte(`{"custodian":"Org1MSP::user1::CN=ca.Org1MSP","hsmSlot":"slot-1"}`)
+    hash := sha256.Sum256(metadataJSON)
+    
+    testCases := []struct {
+        name        string
+        privateData []byte
+        expectError bool
+    }{
+        {name: "Метадані відповідають хешу", privateData: metadataJSON, expectError: false},
+        {name: "Метадані змінено", privateData: []byte(`{"custodian":"Org1MSP::mallory::CN=ca.Org1MSP"}`), expectError: true},
+        {name: "Пір поза колекцією", privateData: nil, expectError: true},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            // Ініціалізація мок-об'єктів
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            mockContext.On("GetStub").Return(mockStub)
+            mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+            
+            key := CryptoKey{ID: "key123", Status: "active", OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP"}, Metadata: hex.EncodeToString(hash[:]), MetadataCollection: "Org1MSPKeyMetadata"}
+            keyJSON, _ := json.Marshal(key)
+            mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+            mockStub.On("GetPrivateData", "Org1MSPKeyMetadata", "cryptokey:key123").Return(tc.privateData, nil)
+            
+            // Виклик методу
+            contract := new(SmartContract)
+            metadata, err := contract.GetKeyMetadata(mockContext, "key123")
+            
+            // Перевірка результатів
+            if tc.expectError {
+                assert.NotNil(t, err)
+                assert.Nil(t, metadata)
+            } else {
+                assert.Nil(t, err)
+                assert.Equal(t, "slot-1", metadata.HSMSlot)
+            }
+        })
+    }
+}
+
+// Тестування VerifyKeyMetadata
+func TestVerifyKeyMetadata(t *testing.T) {
+    metadataJSON := []byte(`{"custodian":"Org1MSP::user1::CN=ca.Org1MSP"}`)
+    hash := sha256.Sum256(metadataJSON)
+    
+    testCases := []struct {
+        name      string
+        chainHash []byte
+        candidate []byte
+        expected  bool
+    }{
+        {name: "Хеш збігається", chainHash: hash[:], expected: true},
+        {name: "Хеш не збігається", chainHash: []byte("other"), expected: false},
+        {name: "Метадані відсутні", chainHash: nil, expected: false},
+        {name: "Коректна копія клієнта", chainHash: hash[:], candidate: metadataJSON, expected: true},
+        {name: "Змінена копія клієнта", chainHash: hash[:], candidate: []byte(`{"custodian":"x"}`), expected: false},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            // Ініціалізація мок-об'єктів
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            mockContext.On("GetStub").Return(mockStub)
+            
+            key := CryptoKey{ID: "key123", Status: "active", Metadata: hex.EncodeToString(hash[:]), MetadataCollection: "Org1MSPKeyMetadata"}
+            keyJSON, _ := json.Marshal(key)
+            transient := map[string][]byte{}
+            if tc.candidate != nil {
+                transient["metadata"] = tc.candidate
+            }
+            mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+            mockStub.On("GetPrivateDataHash", "Org1MSPKeyMetadata", "cryptokey:key123").Return(tc.chainHash, nil)
+            mockStub.On("GetTransient").Return(transient, nil)
+            
+            // Виклик методу
+            contract := new(SmartContract)
+            valid, err := contract.VerifyKeyMetadata(mockContext, "key123")
+            
+            // Перевірка результатів
+            assert.Nil(t, err)
+            assert.Equal(t, tc.expected, valid)
+        })
+    }
+}
+
+// Тестування WrapDataKey
+func TestWrapDataKey(t *testing.T) {
+    future := txTimestamp.Seconds + 3600
+    wrapped := base64.StdEncoding.EncodeToString(make([]byte, 40)) // AES-256 DEK під AES-KW
+    
+    testCases := []struct {
+        name            string
+        scheme          string
+        wrappedKey      string
+        recipientAccess string
+        expectError     bool
+    }{
+        {name: "Отримувач з правом розшифрування", scheme: "AES-KW", wrappedKey: wrapped, recipientAccess: "decrypt-only", expectError: false},
+        {name: "Отримувач лише з правом шифрування", scheme: "AES-KW", wrappedKey: wrapped, recipientAccess: "encrypt-only", expectError: true},
+        {name: "Схема не відповідає KEK", scheme: "RSA-OAEP", wrappedKey: wrapped, recipientAccess: "full", expectError: true},
+        {name: "Некоректний розмір блоба", scheme: "AES-KW", wrappedKey: base64.StdEncoding.EncodeToString(make([]byte, 20)), recipientAccess: "full", expectError: true},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            // Ініціалізація мок-об'єктів
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            mockContext.On("GetStub").Return(mockStub)
+            mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+            
+            kek := CryptoKey{ID: "kek1", Type: "symmetric", Algorithm: "AES", KeySize: 256, Status: "active", OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP"}, ExpiresAt: future}
+            kekJSON, _ := json.Marshal(kek)
+            accessJSON, _ := json.Marshal(KeyAccess{KeyID: "kek1", UserID: "Org2MSP::user2::CN=ca.Org2MSP", AccessType: tc.recipientAccess, ExpiresAt: future})
+            mockStub.On("GetState", "cryptokey:kek1").Return(kekJSON, nil)
+            mockStub.On("GetState", compositeKey("keyaccess", "kek1", "Org2MSP::user2::CN=ca.Org2MSP")).Return(accessJSON, nil)
+            mockStub.On("GetState", compositeKey("wrappeddek", "dek1", "Org2MSP::user2::CN=ca.Org2MSP")).Return([]byte(nil), nil)
+            mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+            mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+            
+            // Виклик методу
+            contract := new(SmartContract)
+            err := contract.WrapDataKey(mockContext, "dek1", "kek1", "Org2MSP::user2::CN=ca.Org2MSP", tc.scheme, tc.wrappedKey)
+            
+            // Перевірка результатів
+            if tc.expectError {
+                assert.NotNil(t, err)
+                mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+                return
+            }
+            assert.Nil(t, err)
+            mockStub.AssertCalled(t, "PutState", compositeKey("wrappeddek", "dek1", "Org2MSP::user2::CN=ca.Org2MSP"), mock.Anything)
+        })
+    }
+}
+
+// Тестування захисту наявного загорнутого DEK від підміни
+func TestWrapDataKeyOverwrite(t *testing.T) {
+    future := txTimestamp.Seconds + 3600
+    wrapped := base64.StdEncoding.EncodeToString(make([]byte, 40))
+    
+    testCases := []struct {
+        name        string
+        existing    []byte
+        expectError bool
+    }{
+        {name: "Запису ще немає", existing: nil, expectError: false},
+        {name: "Запис загорнуто тим самим клієнтом", existing: []byte(`{"dataKeyId":"dek1","kekId":"kek1","wrappedBy":"Org3MSP::user3::CN=ca.Org3MSP"}`), expectError: false},
+        {name: "Запис загорнуто іншим клієнтом", existing: []byte(`{"dataKeyId":"dek1","kekId":"kek1","wrappedBy":"Org1MSP::user1::CN=ca.Org1MSP"}`), expectError: true},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            // Клієнт має лише право шифрування KEK і не є його власником
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            mockContext.On("GetStub").Return(mockStub)
+            mockContext.On("GetClientIdentity").Return(newMockIdentity("Org3MSP", "user3"))
+            
+            kekJSON, _ := json.Marshal(CryptoKey{ID: "kek1", Type: "symmetric", Algorithm: "AES", KeySize: 256, Status: "active", OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP"}, ExpiresAt: future})
+            callerAccessJSON, _ := json.Marshal(KeyAccess{KeyID: "kek1", UserID: "Org3MSP::user3::CN=ca.Org3MSP", AccessType: "encrypt-only", ExpiresAt: future})
+            recipientAccessJSON, _ := json.Marshal(KeyAccess{KeyID: "kek1", UserID: "Org2MSP::user2::CN=ca.Org2MSP", AccessType: "decrypt-only", ExpiresAt: future})
+            mockStub.On("GetState", "cryptokey:kek1").Return(kekJSON, nil)
+            mockStub.On("GetState", compositeKey("keyaccess", "kek1", "Org3MSP::user3::CN=ca.Org3MSP")).Return(callerAccessJSON, nil)
+            mockStub.On("GetState", compositeKey("keyaccess", "kek1", "Org2MSP::user2::CN=ca.Org2MSP")).Return(recipientAccessJSON, nil)
+            mockStub.On("GetState", compositeKey("wrappeddek", "dek1", "Org2MSP::user2::CN=ca.Org2MSP")).Return(tc.existing, nil)
+            mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+            mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+            
+            contract := new(SmartContract)
+            err := contract.WrapDataKey(mockContext, "dek1", "kek1", "Org2MSP::user2::CN=ca.Org2MSP", "AES-KW", wrapped)
+            
+            if tc.expectError {
+                assert.True(t, errors.Is(err, ErrAlreadyExists))
+                mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+                return
+            }
+            assert.Nil(t, err)
+            mockStub.AssertCalled(t, "PutState", compositeKey("wrappeddek", "dek1", "Org2MSP::user2::CN=ca.Org2MSP"), mock.Anything)
+        })
+    }
+}
+
+// Тестування UnwrapDataKey
+func TestUnwrapDataKey(t *testing.T) {
+    future := txTimestamp.Seconds + 3600
+    past := txTimestamp.Seconds - 3600
+    
+    testCases := []struct {
+        name        string
+        accessType  string
+        grantExpiry int64
+        expectError bool
+    }{
+        {name: "Повний доступ", accessType: "full", grantExpiry: future, expectError: false},
+        {name: "Доступ лише на розшифрування", accessType: "decrypt-only", grantExpiry: future, expectError: false},
+        {name: "Доступ лише на шифрування", accessType: "encrypt-only", grantExpiry: future, expectError: true},
+        {name: "Прострочений доступ", accessType: "full", grantExpiry: past, expectError: true},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            // Ініціалізація мок-об'єктів
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            mockContext.On("GetStub").Return(mockStub)
+            mockContext.On("GetClientIdentity").Return(newMockIdentity("Org2MSP", "user2"))
+            
+            kek := CryptoKey{ID: "kek1", Type: "symmetric", Algorithm: "AES", KeySize: 256, Status: "active", OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP"}, ExpiresAt: future}
+            kekJSON, _ := json.Marshal(kek)
+            recordJSON, _ := json.Marshal(WrappedDataKey{DataKeyID: "dek1", RecipientID: "Org2MSP::user2::CN=ca.Org2MSP", KEKID: "kek1", Scheme: "AES-KW", WrappedKey: "AAAA"})
+            accessJSON, _ := json.Marshal(KeyAccess{KeyID: "kek1", UserID: "Org2MSP::user2::CN=ca.Org2MSP", AccessType: tc.accessType, ExpiresAt: tc.grantExpiry})
+            mockStub.On("GetState", compositeKey("wrappeddek", "dek1", "Org2MSP::user2::CN=ca.Org2MSP")).Return(recordJSON, nil)
+            mockStub.On("GetState", "cryptokey:kek1").Return(kekJSON, nil)
+            mockStub.On("GetState", compositeKey("keyaccess", "kek1", "Org2MSP::user2::CN=ca.Org2MSP")).Return(accessJSON, nil)
+            mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+            
+            // Виклик методу
+            contract := new(SmartContract)
+            record, err := contract.UnwrapDataKey(mockContext, "dek1")
+            
+            // Перевірка результатів
+            if tc.expectError {
+                assert.NotNil(t, err)
+                assert.Nil(t, record)
+            } else {
+                assert.Nil(t, err)
+                assert.Equal(t, "kek1", record.KEKID)
+                assert.Equal(t, "AAAA", record.WrappedKey)
+            }
+        })
+    }
+}
+
+// Тестування обчислення кворуму власників
+func TestApprovalThreshold(t *testing.T) {
+    testCases := []struct {
+        name       string
+        owners     int
+        configured int
+        expected   int
+    }{
+        {name: "Один власник", owners: 1, configured: 0, expected: 1},
+        {name: "Два власники, більшість", owners: 2, configured: 0, expected: 2},
+        {name: "Три власники, більшість", owners: 3, configured: 0, expected: 2},
+        {name: "Налаштований кворум", owners: 5, configured: 4, expected: 4},
+        {name: "Кворум більший за кількість власників", owners: 2, configured: 3, expected: 2},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            key := &CryptoKey{ID: "key123", OwnerIDs: make([]string, tc.owners), ApprovalThreshold: tc.configured}
+            assert.Equal(t, tc.expected, approvalThreshold(key))
+        })
+    }
+}
+
+// Тестування відмови у прямому знищенні ключа зі спільним володінням
+func TestDestroySharedKeyRequiresQuorum(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+    
+    keyJSON := []byte(`{"id":"key123","type":"symmetric","algorithm":"AES","status":"deactivated","ownerIds":["Org1MSP::user1::CN=ca.Org1MSP","Org2MSP::user2::CN=ca.Org2MSP"],"expiresAt":1651536000}`)
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    err := contract.DestroyKey(mockContext, "key123")
+    
+    // Перевірка результатів
+    assert.NotNil(t, err)
+    assert.Contains(t, err.Error(), "ProposeKeyOperation")
+    mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+}
+
+// Тестування призупинення ключа зі спільним володінням одним власником
+func TestSuspendSharedKey(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org2MSP", "user2"))
+    
+    keyJSON := []byte(`{"id":"key123","type":"symmetric","algorithm":"AES","status":"active","ownerIds":["Org1MSP::user1::CN=ca.Org1MSP","Org2MSP::user2::CN=ca.Org2MSP"],"expiresAt":1651536000}`)
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("PutState", "cryptokey:key123", mock.Anything).Return(nil)
+    mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    err := contract.SuspendKey(mockContext, "key123")
+    
+    // Призупинення є зворотним і не потребує кворуму
+    assert.Nil(t, err)
+    mockStub.AssertExpectations(t)
+}
+
+// Тестування схвалення відкликання ключа кворумом власників
+func TestKeyOperationQuorum(t *testing.T) {
+    keyJSON := []byte(`{"id":"key123","type":"symmetric","algorithm":"AES","status":"active","ownerIds":["Org1MSP::user1::CN=ca.Org1MSP","Org2MSP::user2::CN=ca.Org2MSP","Org3MSP::user3::CN=ca.Org3MSP"],"expiresAt":1651536000}`)
+    
+    // Перший власник створює пропозицію
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("GetTxID").Return("tx-propose")
+    mockStub.On("PutState", "keyproposal:tx-propose", mock.Anything).Return(nil)
+    
+    contract := new(SmartContract)
+    proposal, err := contract.ProposeKeyOperation(mockContext, "key123", "revoke", "superseded")
+    assert.Nil(t, err)
+    assert.Equal(t, "pending", proposal.Status)
+    assert.Equal(t, 2, proposal.Threshold)
+    assert.Len(t, proposal.Votes, 1)
+    mockStub.AssertNotCalled(t, "PutState", "cryptokey:key123", mock.Anything)
+    proposalJSON := mockStub.Calls[len(mockStub.Calls)-1].Arguments[1].([]byte)
+    
+    // Повторний голос ініціатора не зараховується
+    mockStub = new(MockStub)
+    mockContext = new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+    mockStub.On("GetState", "keyproposal:tx-propose").Return(proposalJSON, nil)
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    
+    _, err = contract.ApproveKeyOperation(mockContext, "tx-propose")
+    assert.NotNil(t, err)
+    
+    // Другий власник досягає кворуму, і ключ відкликається
+    mockStub = new(MockStub)
+    mockContext = new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org2MSP", "user2"))
+    mockStub.On("GetState", "keyproposal:tx-propose").Return(proposalJSON, nil)
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("GetTxID").Return("tx-approve")
+    mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+    mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
+    
+    proposal, err = contract.ApproveKeyOperation(mockContext, "tx-propose")
+    assert.Nil(t, err)
+    assert.Equal(t, "executed", proposal.Status)
+    assert.Equal(t, []string{"Org1MSP::user1::CN=ca.Org1MSP", "Org2MSP::user2::CN=ca.Org2MSP"}, []string{proposal.Votes[0].Voter, proposal.Votes[1].Voter})
+    assert.Equal(t, "tx-approve", proposal.Votes[1].TxID)
+    
+    var key CryptoKey
+    for _, call := range mockStub.Calls {
+        if call.Method == "PutState" && call.Arguments[0] == "cryptokey:key123" {
+            err = json.Unmarshal(call.Arguments[1].([]byte), &key)
+        }
+    }
+    assert.Nil(t, err)
+    assert.Equal(t, "deactivated", key.Status)
+    assert.Equal(t, "superseded", key.RevocationReason)
+    
+    // Виконання схваленої операції фіксується в аудиті від імені власника, що досяг кворуму
+    assert.Len(t, mockStub.Invocations, 1)
+    assert.Equal(t, []string{"RecordEvent", "key_operation", "Org2MSP::user2::CN=ca.Org2MSP", "key123", "revoke", "success", `{"reason":"superseded","status":"deactivated"}`}, mockStub.Invocations[0].Args)
+}
+
+// Тестування кворуму за поточними власниками та поточним порогом ключа
+func TestApproveProposalUsesCurrentOwners(t *testing.T) {
+    testCases := []struct {
+        name      string
+        owners    []string
+        threshold int
+        expected  string
+    }{
+        {name: "Голос ініціатора чинний", owners: []string{"Org1MSP::user1::CN=ca.Org1MSP", "Org2MSP::user2::CN=ca.Org2MSP", "Org3MSP::user3::CN=ca.Org3MSP"}, threshold: 0, expected: "executed"},
+        {name: "Ініціатора вилучено з власників", owners: []string{"Org2MSP::user2::CN=ca.Org2MSP", "Org3MSP::user3::CN=ca.Org3MSP", "Org4MSP::user4::CN=ca.Org4MSP"}, threshold: 0, expected: "pending"},
+        {name: "Поріг підвищено після створення", owners: []string{"Org1MSP::user1::CN=ca.Org1MSP", "Org2MSP::user2::CN=ca.Org2MSP", "Org3MSP::user3::CN=ca.Org3MSP"}, threshold: 3, expected: "pending"},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            mockContext.On("GetStub").Return(mockStub)
+            mockContext.On("GetClientIdentity").Return(newMockIdentity("Org2MSP", "user2"))
+            
+            // Пропозицію створено, коли кворум становив 2 голоси
+            proposalJSON, _ := json.Marshal(KeyOperationProposal{
+                ID:        "tx-propose",
+                KeyID:     "key123",
+                Operation: "destroy",
+                Threshold: 2,
+                Status:    "pending",
+                ExpiresAt: txTimestamp.Seconds + 3600,
+                Votes:     []ProposalVote{{Voter: "Org1MSP::user1::CN=ca.Org1MSP", TxID: "tx-propose"}},
+            })
+            keyJSON, _ := json.Marshal(CryptoKey{ID: "key123", Status: "deactivated", OwnerIDs: tc.owners, ApprovalThreshold: tc.threshold})
+            mockStub.On("GetState", "keyproposal:tx-propose").Return(proposalJSON, nil)
+            mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+            mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+            mockStub.On("GetTxID").Return("tx-approve")
+            mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+            mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
+            
+            contract := new(SmartContract)
+            proposal, err := contract.ApproveKeyOperation(mockContext, "tx-propose")
+            assert.Nil(t, err)
+            assert.Equal(t, tc.expected, proposal.Status)
+            if tc.expected == "pending" {
+                mockStub.AssertNotCalled(t, "PutState", "cryptokey:key123", mock.Anything)
+            }
+        })
+    }
+}
+
+// Тестування відмови у схваленні простроченої пропозиції
+func TestApproveExpiredProposal(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    
+    proposalJSON, _ := json.Marshal(KeyOperationProposal{ID: "tx-propose", KeyID: "key123", Operation: "destroy", Threshold: 2, Status: "pending", ExpiresAt: txTimestamp.Seconds - 1})
+    mockStub.On("GetState", "keyproposal:tx-propose").Return(proposalJSON, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    _, err := contract.ApproveKeyOperation(mockContext, "tx-propose")
+    
+    // Перевірка результатів
+    assert.NotNil(t, err)
+    assert.Contains(t, err.Error(), "expired")
+    mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+}
+
+// Тестування депонування ключа, підготовленого клієнтською бібліотекою
+func TestDepositKeyEscrow(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+    
+    // Частки для двох власників з порогом 2
+    owner1, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    owner2, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    deposit, err := escrow.PrepareDeposit(make([]byte, 32), 2, map[string]crypto.PublicKey{
+        "Org1MSP::user1::CN=ca.Org1MSP": &owner1.PublicKey,
+        "Org2MSP::user2::CN=ca.Org2MSP": &owner2.PublicKey,
+    })
+    assert.Nil(t, err)
+    depositJSON, _ := json.Marshal(deposit)
+    
+    keyJSON := []byte(`{"id":"key123","type":"symmetric","algorithm":"AES","keySize":256,"status":"active","ownerIds":["Org1MSP::user1::CN=ca.Org1MSP","Org2MSP::user2::CN=ca.Org2MSP"]}`)
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    mockStub.On("GetState", "keyescrow:key123").Return([]byte(nil), nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("PutState", "keyescrow:key123", mock.Anything).Return(nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    err = contract.DepositKeyEscrow(mockContext, "key123", string(depositJSON))
+    
+    // Перевірка результатів
+    assert.Nil(t, err)
+    mockStub.AssertExpectations(t)
+    
+    var stored KeyEscrow
+    err = json.Unmarshal(mockStub.Calls[len(mockStub.Calls)-1].Arguments[1].([]byte), &stored)
+    assert.Nil(t, err)
+    assert.Equal(t, deposit.Commitment, stored.Commitment)
+    assert.Equal(t, "Org1MSP::user1::CN=ca.Org1MSP", stored.DepositedBy)
+    assert.Len(t, stored.Shares, 2)
+}
+
+// Тестування відмови у депонуванні некоректних депозитів
+func TestDepositKeyEscrowValidation(t *testing.T) {
+    owner, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    onlyOwner1, _ := escrow.PrepareDeposit(make([]byte, 32), 1, map[string]crypto.PublicKey{"Org1MSP::user1::CN=ca.Org1MSP": &owner.PublicKey})
+    onlyOwner1JSON, _ := json.Marshal(onlyOwner1)
+    stranger, _ := escrow.PrepareDeposit(make([]byte, 32), 2, map[string]crypto.PublicKey{"Org1MSP::user1::CN=ca.Org1MSP": &owner.PublicKey, "Org3MSP::mallory::CN=ca.Org3MSP": &owner.PublicKey})
+    strangerJSON, _ := json.Marshal(stranger)
+    
+    testCases := []struct {
+        name    string
+        keyType string
+        deposit string
+    }{
+        {name: "Частка не для кожного власника", keyType: "symmetric", deposit: string(onlyOwner1JSON)},
+        {name: "Частка для стороннього", keyType: "symmetric", deposit: string(strangerJSON)},
+        {name: "Асиметричний ключ", keyType: "asymmetric", deposit: string(strangerJSON)},
+        {name: "Некоректний JSON", keyType: "symmetric", deposit: "{"},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            mockContext.On("GetStub").Return(mockStub)
+            
+            key := CryptoKey{ID: "key123", Type: tc.keyType, Algorithm: "AES", KeySize: 256, Status: "active", OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP", "Org2MSP::user2::CN=ca.Org2MSP"}}
+            keyJSON, _ := json.Marshal(key)
+            mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+            
+            contract := new(SmartContract)
+            err := contract.DepositKeyEscrow(mockContext, "key123", tc.deposit)
+            
+            assert.True(t, errors.Is(err, ErrInvalidArgument))
+            mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+        })
+    }
+}
+
+// Тестування вибору ключів, що потребують планової ротації
+func TestGetKeysDueForRotation(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    
+    // Підготовка даних для тесту
+    now := txTimestamp.Seconds
+    future := now + 86400*365
+    intervalKey, _ := json.Marshal(CryptoKey{ID: "key1", Status: "active", ActivatedAt: now - 86400*31, ExpiresAt: future, RotationPolicy: &RotationPolicy{IntervalDays: 30}})
+    freshKey, _ := json.Marshal(CryptoKey{ID: "key2", Status: "active", ActivatedAt: now - 86400*29, ExpiresAt: future, RotationPolicy: &RotationPolicy{IntervalDays: 30}})
+    usageKey, _ := json.Marshal(CryptoKey{ID: "key3", Status: "active", ActivatedAt: now, ExpiresAt: future, RotationPolicy: &RotationPolicy{MaxUsageCount: 1000}})
+    
+    // Використання ключа key3 рахуються за погодинною статистикою всіх користувачів
+    usage1, _ := json.Marshal(UsageBucket{KeyID: "key3", UserID: "user1", Operations: map[string]int64{"encrypt": 600}})
+    usage2, _ := json.Marshal(UsageBucket{KeyID: "key3", UserID: "user2", Operations: map[string]int64{"encrypt": 150, "decrypt": 250}})
+    usage := &MockQueryIterator{Results: []*queryresult.KV{
+        {Key: compositeKey("keyusage", "key3", "user1", "1629997200"), Value: usage1},
+        {Key: compositeKey("keyusage", "key3", "user2", "1629997200"), Value: usage2},
+    }}
+    ownersKey, _ := json.Marshal(CryptoKey{ID: "key4", Status: "active", ActivatedAt: now - 10, OwnersChangedAt: now - 5, ExpiresAt: future, RotationPolicy: &RotationPolicy{RotateOnOwnerChange: true}})
+    suspendedKey, _ := json.Marshal(CryptoKey{ID: "key5", Status: "suspended", ActivatedAt: now - 86400*31, ExpiresAt: future, RotationPolicy: &RotationPolicy{IntervalDays: 30}})
+    noPolicyKey, _ := json.Marshal(CryptoKey{ID: "key6", Status: "active", ActivatedAt: now - 86400*31, ExpiresAt: future})
+    
+    keys := &MockQueryIterator{Results: []*queryresult.KV{
+        {Key: "cryptokey:key1", Value: intervalKey},
+        {Key: "cryptokey:key2", Value: freshKey},
+        {Key: "cryptokey:key3", Value: usageKey},
+        {Key: "cryptokey:key4", Value: ownersKey},
+        {Key: "cryptokey:key5", Value: suspendedKey},
+        {Key: "cryptokey:key6", Value: noPolicyKey},
+    }}
+    
+    // Очікуємо виклики методів
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("GetStateByPartialCompositeKey", "keyusage", []string{"key3"}).Return(usage, nil)
+    mockStub.On("GetStateByRangeWithPagination", "cryptokey:", "cryptokey~", int32(6), "cryptokey:key0").Return(keys, &pb.QueryResponseMetadata{FetchedRecordsCount: 6, Bookmark: "cryptokey:key7"}, nil)
+    
+    // Виклик методу на час транзакції
+    contract := new(SmartContract)
+    due, err := contract.GetKeysDueForRotation(mockContext, 0, 6, "cryptokey:key0")
+    
+    // Перевірка результатів
+    assert.Nil(t, err)
+    assert.Equal(t, []*RotationDue{
+        {KeyID: "key1", Reason: "interval", DueAt: now - 86400},
+        {KeyID: "key3", Reason: "usage", DueAt: now},
+        {KeyID: "key4", Reason: "owner-change", DueAt: now - 5},
+    }, due.Records)
+    assert.Equal(t, int32(6), due.FetchedRecordsCount)
+    assert.Equal(t, "cryptokey:key7", due.Bookmark)
+    
+    // Некоректний розмір сторінки відхиляється без звернення до стану
+    _, err = contract.GetKeysDueForRotation(mockContext, 0, 0, "")
+    assert.NotNil(t, err)
+    mockStub.AssertNumberOfCalls(t, "GetStateByRangeWithPagination", 1)
+}
+
+// Тестування планової ротації ключа виконавцем, що не є власником
+func TestRotateDueKey(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org3MSP", "rotator"))
+    
+    // Підготовка даних для тесту
+    now := txTimestamp.Seconds
+    policy := &RotationPolicy{IntervalDays: 30, Workers: []string{"Org3MSP::rotator::CN=ca.Org3MSP"}}
+    keyJSON, _ := json.Marshal(CryptoKey{ID: "key123", Status: "active", OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP", "Org2MSP::user2::CN=ca.Org2MSP"}, ActivatedAt: now - 86400*31, ExpiresAt: now + 86400*334, RotationPolicy: policy})
+    
+    // Очікуємо виклики методів
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("GetTxID").Return("abcdef123456")
+    mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+    mockStub.On("GetStateByPartialCompositeKey", "keyaccess", []string{"key123"}).Return(&MockQueryIterator{}, nil)
+    mockStub.On("GetState", "cryptokey:key123-abcdef12").Return([]byte(nil), nil)
+    mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    newKeyID, err := contract.RotateDueKey(mockContext, "key123")
+    
+    // Перевірка результатів
+    assert.Nil(t, err)
+    assert.Equal(t, "key123-abcdef12", newKeyID)
+    
+    // Нова версія успадковує політику і відраховує інтервал від своєї активації
+    var newKey CryptoKey
+    for _, call := range mockStub.Calls {
+        if call.Method == "PutState" && call.Arguments[0] == "cryptokey:"+newKeyID {
+            err = json.Unmarshal(call.Arguments[1].([]byte), &newKey)
+        }
+    }
+    assert.Nil(t, err)
+    assert.Equal(t, policy, newKey.RotationPolicy)
+    _, _, due := rotationDue(&newKey, 0, now)
+    assert.False(t, due)
+    
+    // Планова ротація фіксується в аудиті від імені виконавця
+    assert.Len(t, mockStub.Invocations, 1)
+    assert.Equal(t, []string{"RecordEvent", "key_operation", "Org3MSP::rotator::CN=ca.Org3MSP", "key123", "rotate", "success", `{"newKeyId":"key123-abcdef12"}`}, mockStub.Invocations[0].Args)
+}
+
+// Тестування відмови у плановій ротації ключа, строк якої не настав
+func TestRotateDueKeyNotDue(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+    
+    now := txTimestamp.Seconds
+    keyJSON, _ := json.Marshal(CryptoKey{ID: "key123", Status: "active", OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP"}, ActivatedAt: now - 86400, ExpiresAt: now + 86400*364, RotationPolicy: &RotationPolicy{IntervalDays: 30}})
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    _, err := contract.RotateDueKey(mockContext, "key123")
+    
+    // Перевірка результатів
+    assert.NotNil(t, err)
+    mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+}
+
+// Тестування відмови у плановій ротації клієнту, що не є власником чи виконавцем
+func TestRotateDueKeyUnauthorized(t *testing.T) {
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org3MSP", "mallory"))
+    
+    now := txTimestamp.Seconds
+    policy := &RotationPolicy{IntervalDays: 30, Workers: []string{"Org3MSP::rotator::CN=ca.Org3MSP"}}
+    keyJSON, _ := json.Marshal(CryptoKey{ID: "key123", Status: "active", OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP"}, ActivatedAt: now - 86400*31, ExpiresAt: now + 86400*334, RotationPolicy: policy})
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    
+    contract := new(SmartContract)
+    _, err := contract.RotateDueKey(mockContext, "key123")
+    
+    assert.NotNil(t, err)
+    mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+}
+
+// Тестування встановлення політики ротації
+func TestSetRotationPolicy(t *testing.T) {
+    testCases := []struct {
+        name     string
+        owners   []string
+        policy   string
+        wantErr  bool
+        expected *RotationPolicy
+    }{
+        {name: "Інтервал і ліміт", owners: []string{"Org1MSP::user1::CN=ca.Org1MSP"}, policy: `{"intervalDays":90,"maxUsageCount":100000}`, expected: &RotationPolicy{IntervalDays: 90, MaxUsageCount: 100000}},
+        {name: "Зняття політики", owners: []string{"Org1MSP::user1::CN=ca.Org1MSP"}, policy: "", expected: nil},
+        {name: "Порожня політика", owners: []string{"Org1MSP::user1::CN=ca.Org1MSP"}, policy: `{}`, wantErr: true},
+        {name: "Від'ємний інтервал", owners: []string{"Org1MSP::user1::CN=ca.Org1MSP"}, policy: `{"intervalDays":-1}`, wantErr: true},
+        {name: "Виконавець ротації", owners: []string{"Org1MSP::user1::CN=ca.Org1MSP"}, policy: `{"intervalDays":90,"workers":["Org3MSP::rotator::CN=ca.Org3MSP"]}`, expected: &RotationPolicy{IntervalDays: 90, Workers: []string{"Org3MSP::rotator::CN=ca.Org3MSP"}}},
+        {name: "Повторений виконавець", owners: []string{"Org1MSP::user1::CN=ca.Org1MSP"}, policy: `{"intervalDays":90,"workers":["Org3MSP::rotator::CN=ca.Org3MSP","Org3MSP::rotator::CN=ca.Org3MSP"]}`, wantErr: true},
+        {name: "Спільний ключ потребує кворуму", owners: []string{"Org1MSP::user1::CN=ca.Org1MSP", "Org2MSP::user2::CN=ca.Org2MSP"}, policy: `{"intervalDays":90}`, wantErr: true},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            mockContext.On("GetStub").Return(mockStub)
+            mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+            
+            keyJSON, _ := json.Marshal(CryptoKey{ID: "key123", Status: "active", OwnerIDs: tc.owners, RotationPolicy: &RotationPolicy{IntervalDays: 30}})
+            mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+            mockStub.On("PutState", "cryptokey:key123", mock.Anything).Return(nil)
+            
+            contract := new(SmartContract)
+            err := contract.SetRotationPolicy(mockContext, "key123", tc.policy)
+            
+            if tc.wantErr {
+                assert.NotNil(t, err)
+                mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+                return
+            }
+            
+            assert.Nil(t, err)
+            var key CryptoKey
+            err = json.Unmarshal(mockStub.Calls[len(mockStub.Calls)-1].Arguments[1].([]byte), &key)
+            assert.Nil(t, err)
+            assert.Equal(t, tc.expected, key.RotationPolicy)
+        })
+    }
+}
+
+// Тестування зміни власників ключа через кворум
+func TestChangeKeyOwnersProposal(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+    
+    keyJSON := []byte(`{"id":"key123","status":"active","ownerIds":["Org1MSP::user1::CN=ca.Org1MSP","Org2MSP::user2::CN=ca.Org2MSP"],"approvalThreshold":1,"activatedAt":1620000000}`)
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("GetTxID").Return("tx-owners")
+    mockStub.On("DelState", compositeKey("owner~key", "Org2MSP::user2::CN=ca.Org2MSP", "key123")).Return(nil)
+    mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    proposal, err := contract.ProposeKeyOperation(mockContext, "key123", "owners", `["Org1MSP::user1::CN=ca.Org1MSP","Org3MSP::user3::CN=ca.Org3MSP"]`)
+    
+    // Перевірка результатів
+    assert.Nil(t, err)
+    assert.Equal(t, "executed", proposal.Status)
+    mockStub.AssertExpectations(t)
+    mockStub.AssertCalled(t, "PutState", compositeKey("owner~key", "Org3MSP::user3::CN=ca.Org3MSP", "key123"), mock.Anything)
+    
+    var key CryptoKey
+    for _, call := range mockStub.Calls {
+        if call.Method == "PutState" && call.Arguments[0] == "cryptokey:key123" {
+            err = json.Unmarshal(call.Arguments[1].([]byte), &key)
+        }
+    }
+    assert.Nil(t, err)
+    assert.Equal(t, []string{"Org1MSP::user1::CN=ca.Org1MSP", "Org3MSP::user3::CN=ca.Org3MSP"}, key.OwnerIDs)
+    assert.Equal(t, txTimestamp.Seconds, key.OwnersChangedAt)
+}
+
+// Тестування фіксації використання ключа користувачем з доступом
+func TestRecordKeyUsage(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org2MSP", "user2"))
+    
+    // Підготовка даних для тесту
+    now := txTimestamp.Seconds
+    hour := strconv.FormatInt(now-now%3600, 10)
+    keyJSON, _ := json.Marshal(CryptoKey{ID: "key123", Status: "active", OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP"}, ExpiresAt: now + 3600})
+    accessJSON, _ := json.Marshal(KeyAccess{KeyID: "key123", UserID: "Org2MSP::user2::CN=ca.Org2MSP", AccessType: "encrypt-only", ExpiresAt: now + 3600, UsageCount: 9, UsageQuota: 10})
+    bucketJSON, _ := json.Marshal(UsageBucket{KeyID: "key123", UserID: "Org2MSP::user2::CN=ca.Org2MSP", Hour: now - now%3600, Operations: map[string]int64{"encrypt": 4}})
+    
+    // Очікуємо виклики методів
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("GetState", compositeKey("keyaccess", "key123", "Org2MSP::user2::CN=ca.Org2MSP")).Return(accessJSON, nil)
+    mockStub.On("GetState", compositeKey("keyusage", "key123", "Org2MSP::user2::CN=ca.Org2MSP", hour)).Return(bucketJSON, nil)
+    mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    err := contract.RecordKeyUsage(mockContext, "key123", "Org2MSP::user2::CN=ca.Org2MSP", "encrypt")
+    
+    // Перевірка результатів
+    assert.Nil(t, err)
+    
+    var access KeyAccess
+    err = json.Unmarshal(mockStub.Calls[3].Arguments[1].([]byte), &access)
+    assert.Nil(t, err)
+    assert.Equal(t, int64(10), access.UsageCount)
+    
+    var bucket UsageBucket
+    err = json.Unmarshal(mockStub.Calls[6].Arguments[1].([]byte), &bucket)
+    assert.Nil(t, err)
+    assert.Equal(t, int64(5), bucket.Operations["encrypt"])
+    
+    // Запис ключа не змінюється, щоб використання різними користувачами не конфліктували
+    mockStub.AssertNotCalled(t, "PutState", "cryptokey:key123", mock.Anything)
+}
+
+// Тестування відмови у фіксації використання
+func TestRecordKeyUsageDenied(t *testing.T) {
+    now := txTimestamp.Seconds
+    testCases := []struct {
+        name      string
+        caller    *MockClientIdentity
+        access    KeyAccess
+        operation string
+        quota     bool
+    }{
+        {name: "Вичерпаний ліміт", caller: newMockIdentity("Org2MSP", "user2"), access: KeyAccess{AccessType: "full", UsageCount: 10, UsageQuota: 10}, operation: "encrypt", quota: true},
+        {name: "Тип доступу не дозволяє операцію", caller: newMockIdentity("Org2MSP", "user2"), access: KeyAccess{AccessType: "encrypt-only"}, operation: "decrypt"},
+        {name: "Фіксація від імені іншого користувача", caller: newMockIdentity("Org3MSP", "user3"), access: KeyAccess{AccessType: "full"}, operation: "encrypt"},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            mockContext.On("GetStub").Return(mockStub)
+            mockContext.On("GetClientIdentity").Return(tc.caller)
+            
+            keyJSON, _ := json.Marshal(CryptoKey{ID: "key123", Status: "active", OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP"}, ExpiresAt: now + 3600})
+            tc.access.KeyID = "key123"
+            tc.access.UserID = "Org2MSP::user2::CN=ca.Org2MSP"
+            tc.access.ExpiresAt = now + 3600
+            accessJSON, _ := json.Marshal(tc.access)
+            mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+            mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+            mockStub.On("GetState", compositeKey("keyaccess", "key123", "Org2MSP::user2::CN=ca.Org2MSP")).Return(accessJSON, nil)
+            
+            contract := new(SmartContract)
+            err := contract.RecordKeyUsage(mockContext, "key123", "Org2MSP::user2::CN=ca.Org2MSP", tc.operation)
+            
+            assert.NotNil(t, err)
+            assert.Equal(t, tc.quota, errors.Is(err, ErrQuotaExceeded))
+            mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+        })
+    }
+}
+
+// Тестування статистики використання ключа за період
+func TestGetKeyUsageStats(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+    
+    // Підготовка даних для тесту
+    hour := int64(1629997200)
+    keyJSON, _ := json.Marshal(CryptoKey{ID: "key123", Status: "active", OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP"}})
+    early, _ := json.Marshal(UsageBucket{KeyID: "key123", UserID: "Org2MSP::user2::CN=ca.Org2MSP", Hour: hour - 7200, Operations: map[string]int64{"encrypt": 100}})
+    user2, _ := json.Marshal(UsageBucket{KeyID: "key123", UserID: "Org2MSP::user2::CN=ca.Org2MSP", Hour: hour, Operations: map[string]int64{"encrypt": 5, "decrypt": 2}})
+    user3, _ := json.Marshal(UsageBucket{KeyID: "key123", UserID: "Org3MSP::user3::CN=ca.Org3MSP", Hour: hour, Operations: map[string]int64{"encrypt": 3}})
+    buckets := &MockQueryIterator{Results: []*queryresult.KV{
+        {Key: compositeKey("keyusage", "key123", "Org2MSP::user2::CN=ca.Org2MSP", strconv.FormatInt(hour-7200, 10)), Value: early},
+        {Key: compositeKey("keyusage", "key123", "Org2MSP::user2::CN=ca.Org2MSP", strconv.FormatInt(hour, 10)), Value: user2},
+        {Key: compositeKey("keyusage", "key123", "Org3MSP::user3::CN=ca.Org3MSP", strconv.FormatInt(hour, 10)), Value: user3},
+    }}
+    
+    // Очікуємо виклики методів
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("GetStateByPartialCompositeKey", "keyusage", []string{"key123"}).Return(buckets, nil)
+    
+    // Виклик методу за останню годину до часу транзакції
+    contract := new(SmartContract)
+    stats, err := contract.GetKeyUsageStats(mockContext, "key123", "", hour, 0)
+    
+    // Перевірка результатів
+    assert.Nil(t, err)
+    assert.Equal(t, txTimestamp.Seconds, stats.To)
+    assert.Equal(t, int64(10), stats.Total)
+    assert.Equal(t, map[string]int64{"encrypt": 8, "decrypt": 2}, stats.ByOperation)
+    assert.Equal(t, map[string]int64{"Org2MSP::user2::CN=ca.Org2MSP": 7, "Org3MSP::user3::CN=ca.Org3MSP": 3}, stats.ByUser)
+    assert.Len(t, stats.Buckets, 2)
+}
+
+// Тестування обмежень передачі доступу
+func TestGrantDelegableKeyAccess(t *testing.T) {
+    now := txTimestamp.Seconds
+    testCases := []struct {
+        name       string
+        grantor    KeyAccess
+        existing   *KeyAccess
+        accessType string
+        depth      int
+        allowed    bool
+        quota      int64
+        usageCount int64
+    }{
+        {name: "Вужчий доступ з меншою глибиною", grantor: KeyAccess{AccessType: "full", CanDelegate: true, DelegationDepth: 2}, accessType: "encrypt-only", depth: 1, allowed: true},
+        {name: "Ліміт успадковується від доступу, з якого передано", grantor: KeyAccess{AccessType: "full", CanDelegate: true, DelegationDepth: 2, UsageQuota: 50}, accessType: "encrypt-only", depth: 1, allowed: true, quota: 50},
+        {name: "Ліміт обмежено невикористаним залишком", grantor: KeyAccess{AccessType: "full", CanDelegate: true, DelegationDepth: 2, UsageQuota: 100, UsageCount: 99}, accessType: "encrypt-only", depth: 1, allowed: true, quota: 1},
+        {name: "Вичерпаний ліміт", grantor: KeyAccess{AccessType: "full", CanDelegate: true, DelegationDepth: 2, UsageQuota: 100, UsageCount: 100}, accessType: "encrypt-only", depth: 1},
+        {name: "Повторна передача зберігає лічильник і менший ліміт", grantor: KeyAccess{AccessType: "full", CanDelegate: true, DelegationDepth: 2, UsageQuota: 50}, existing: &KeyAccess{GrantedBy: "Org2MSP::user2::CN=ca.Org2MSP", UsageCount: 4, UsageQuota: 20}, accessType: "encrypt-only", depth: 1, allowed: true, quota: 20, usageCount: 4},
+        {name: "Заміна доступу, наданого власником", grantor: KeyAccess{AccessType: "full", CanDelegate: true, DelegationDepth: 2}, existing: &KeyAccess{GrantedBy: "Org1MSP::user1::CN=ca.Org1MSP", AccessType: "full"}, accessType: "encrypt-only", depth: 1},
+        {name: "Доступ без права передачі", grantor: KeyAccess{AccessType: "full"}, accessType: "encrypt-only", depth: 0},
+        {name: "Ширший доступ", grantor: KeyAccess{AccessType: "decrypt-only", CanDelegate: true, DelegationDepth: 2}, accessType: "full", depth: 0},
+        {name: "Інший тип доступу", grantor: KeyAccess{AccessType: "decrypt-only", CanDelegate: true, DelegationDepth: 2}, accessType: "encrypt-only", depth: 0},
+        {name: "Глибина не зменшується", grantor: KeyAccess{AccessType: "full", CanDelegate: true, DelegationDepth: 1}, accessType: "full", depth: 1},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            mockContext.On("GetStub").Return(mockStub)
+            mockContext.On("GetClientIdentity").Return(newMockIdentity("Org2MSP", "user2"))
+            
+            keyJSON, _ := json.Marshal(CryptoKey{ID: "key123", Status: "active", OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP"}, ExpiresAt: now + 86400*365})
+            tc.grantor.KeyID = "key123"
+            tc.grantor.UserID = "Org2MSP::user2::CN=ca.Org2MSP"
+            tc.grantor.GrantedBy = "Org1MSP::user1::CN=ca.Org1MSP"
+            tc.grantor.ExpiresAt = now + 86400*10
+            grantorJSON, _ := json.Marshal(tc.grantor)
+            mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+            mockStub.On("GetState", compositeKey("keyaccess", "key123", "Org2MSP::user2::CN=ca.Org2MSP")).Return(grantorJSON, nil)
+            mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+            mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+            mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
+            var existingJSON []byte
+            if tc.existing != nil {
+                existingJSON, _ = json.Marshal(tc.existing)
+            }
+            mockStub.On("GetState", compositeKey("keyaccess", "key123", "Org3MSP::user3::CN=ca.Org3MSP")).Return(existingJSON, nil)
+            
+            contract := new(SmartContract)
+            err := contract.GrantDelegableKeyAccess(mockContext, "key123", "Org3MSP::user3::CN=ca.Org3MSP", tc.accessType, 30, tc.depth)
+            
+            if !tc.allowed {
+                assert.NotNil(t, err)
+                mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+                return
+            }
+            
+            assert.Nil(t, err)
+            var access KeyAccess
+            err = json.Unmarshal(mockStub.Calls[4].Arguments[1].([]byte), &access)
+            assert.Nil(t, err)
+            assert.True(t, access.CanDelegate)
+            assert.Equal(t, tc.depth, access.DelegationDepth)
+            assert.Equal(t, tc.quota, access.UsageQuota)
+            assert.Equal(t, tc.usageCount, access.UsageCount)
+            // Похідний доступ закінчується разом з доступом, з якого його передано
+            assert.Equal(t, tc.grantor.ExpiresAt, access.ExpiresAt)
+        })
+    }
+}
+
+// Тестування каскадного відкликання переданих доступів
+func TestRevokeKeyAccessCascade(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+    
+    // Власник -> user2 -> user3 -> user4; user5 отримав доступ від власника напряму
+    grant := func(userID string, grantedBy string) []byte {
+        accessJSON, _ := json.Marshal(KeyAccess{KeyID: "key123", UserID: userID, AccessType: "full", GrantedBy: grantedBy})
+        return accessJSON
+    }
+    keyJSON, _ := json.Marshal(CryptoKey{ID: "key123", Status: "active", OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP"}})
+    grants := &MockQueryIterator{Results: []*queryresult.KV{
+        {Key: compositeKey("keyaccess", "key123", "user2"), Value: grant("user2", "Org1MSP::user1::CN=ca.Org1MSP")},
+        {Key: compositeKey("keyaccess", "key123", "user3"), Value: grant("user3", "user2")},
+        {Key: compositeKey("keyaccess", "key123", "user4"), Value: grant("user4", "user3")},
+        {Key: compositeKey("keyaccess", "key123", "user5"), Value: grant("user5", "Org1MSP::user1::CN=ca.Org1MSP")},
+    }}
+    
+    // Очікуємо виклики методів
+    mockStub.On("GetState", compositeKey("keyaccess", "key123", "user2")).Return(grant("user2", "Org1MSP::user1::CN=ca.Org1MSP"), nil)
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    mockStub.On("GetStateByPartialCompositeKey", "keyaccess", []string{"key123"}).Return(grants, nil)
+    for _, userID := range []string{"user2", "user3", "user4"} {
+        mockStub.On("DelState", compositeKey("keyaccess", "key123", userID)).Return(nil)
+        mockStub.On("DelState", compositeKey("keyaccess~user", userID, "key123")).Return(nil)
+        mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
+    }
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    err := contract.RevokeKeyAccess(mockContext, "key123", "user2")
+    
+    // Перевірка результатів
+    assert.Nil(t, err)
+    mockStub.AssertExpectations(t)
+    mockStub.AssertNotCalled(t, "DelState", compositeKey("keyaccess", "key123", "user5"))
+}
+
+// Тестування створення ключа шифрування даних під ключем шифрування ключів
+func TestGenerateHierarchyKey(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+    
+    // Батьківський ключ діє ще 10 днів
+    now := txTimestamp.Seconds
+    kekJSON, _ := json.Marshal(CryptoKey{ID: "kek1", Type: "symmetric", Status: "active", Role: "kek", ParentKeyID: "root1", OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP"}, ExpiresAt: now + 86400*10})
+    
+    // Очікуємо виклики методів
+    mockStub.On("GetState", "cryptokey:kek1").Return(kekJSON, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("GetTransient").Return(map[string][]byte{}, nil)
+    mockStub.On("GetTxID").Return("tx123")
+    mockStub.On("GetState", "cryptokey:dek1-tx123").Return([]byte(nil), nil)
+    mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    err := contract.GenerateHierarchyKey(mockContext, "dek1", "dek", "kek1", "symmetric", "AES", 256, `[]`, 30)
+    
+    // Перевірка результатів
+    assert.Nil(t, err)
+    mockStub.AssertCalled(t, "PutState", compositeKey("parent~child", "kek1", "dek1-tx123"), indexValue)
+    
+    stored := map[string]CryptoKey{}
+    for _, call := range mockStub.Calls {
+        if call.Method == "PutState" && strings.HasPrefix(call.Arguments[0].(string), "cryptokey:") {
+            var key CryptoKey
+            assert.Nil(t, json.Unmarshal(call.Arguments[1].([]byte), &key))
+            stored[key.ID] = key
+        }
+    }
+    assert.Equal(t, "dek", stored["dek1-tx123"].Role)
+    assert.Equal(t, "kek1", stored["dek1-tx123"].ParentKeyID)
+    assert.Equal(t, now+86400*10, stored["dek1-tx123"].ExpiresAt)
+    assert.Equal(t, 1, stored["kek1"].ChildKeyCount)
+}
+
+// Тестування обмежень ієрархії ключів
+func TestGenerateHierarchyKeyValidation(t *testing.T) {
+    testCases := []struct {
+        name      string
+        role      string
+        parentID  string
+        keyType   string
+        algorithm string
+        keySize   int
+    }{
+        {name: "Невідома роль", role: "master", keyType: "symmetric", algorithm: "AES", keySize: 256},
+        {name: "Кореневий ключ з батьком", role: "root", parentID: "root0", keyType: "symmetric", algorithm: "AES", keySize: 256},
+        {name: "KEK без батька", role: "kek", keyType: "symmetric", algorithm: "AES", keySize: 256},
+        {name: "Асиметричний DEK", role: "dek", parentID: "kek1", keyType: "asymmetric", algorithm: "RSA", keySize: 2048},
+        {name: "DEK під кореневим ключем", role: "dek", parentID: "root1", keyType: "symmetric", algorithm: "AES", keySize: 256},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            mockContext.On("GetStub").Return(mockStub)
+            
+            rootJSON, _ := json.Marshal(CryptoKey{ID: "root1", Status: "active", Role: "root", OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP"}})
+            mockStub.On("GetState", "cryptokey:root1").Return(rootJSON, nil)
+            
+            contract := new(SmartContract)
+            err := contract.GenerateHierarchyKey(mockContext, "key1", tc.role, tc.parentID, tc.keyType, tc.algorithm, tc.keySize, `[]`, 30)
+            
+            assert.True(t, errors.Is(err, ErrInvalidArgument))
+            mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+        })
+    }
+}
+
+// Тестування каскадної компрометації нащадків кореневого ключа
+func TestMarkCompromisedCascade(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+    
+    // root1 -> kek1 -> {dek1 (active), dek2 (destroyed)}
+    owners := []string{"Org1MSP::user1::CN=ca.Org1MSP"}
+    rootJSON, _ := json.Marshal(CryptoKey{ID: "root1", Status: "active", Role: "root", OwnerIDs: owners, ChildKeyCount: 1})
+    kekJSON, _ := json.Marshal(CryptoKey{ID: "kek1", Status: "active", Role: "kek", ParentKeyID: "root1", OwnerIDs: owners, ChildKeyCount: 2})
+    dek1JSON, _ := json.Marshal(CryptoKey{ID: "dek1", Status: "active", Role: "dek", ParentKeyID: "kek1", OwnerIDs: owners})
+    dek2JSON, _ := json.Marshal(CryptoKey{ID: "dek2", Status: "destroyed", Role: "dek", ParentKeyID: "kek1", OwnerIDs: owners})
+    
+    // Очікуємо виклики методів
+    mockStub.On("GetState", "cryptokey:root1").Return(rootJSON, nil)
+    mockStub.On("GetState", "cryptokey:kek1").Return(kekJSON, nil)
+    mockStub.On("GetState", "cryptokey:dek1").Return(dek1JSON, nil)
+    mockStub.On("GetState", "cryptokey:dek2").Return(dek2JSON, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("GetStateByPartialCompositeKey", "parent~child", []string{"root1"}).Return(&MockQueryIterator{Results: []*queryresult.KV{
+        {Key: compositeKey("parent~child", "root1", "kek1"), Value: indexValue},
+    }}, nil)
+    mockStub.On("GetStateByPartialCompositeKey", "parent~child", []string{"kek1"}).Return(&MockQueryIterator{Results: []*queryresult.KV{
+        {Key: compositeKey("parent~child", "kek1", "dek1"), Value: indexValue},
+        {Key: compositeKey("parent~child", "kek1", "dek2"), Value: indexValue},
+    }}, nil)
+    mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+    mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    err := contract.MarkCompromised(mockContext, "root1")
+    
+    // Перевірка результатів
+    assert.Nil(t, err)
+    mockStub.AssertNotCalled(t, "PutState", "cryptokey:dek2", mock.Anything)
+    
+    stored := map[string]CryptoKey{}
+    for _, call := range mockStub.Calls {
+        if call.Method == "PutState" {
+            var key CryptoKey
+            assert.Nil(t, json.Unmarshal(call.Arguments[1].([]byte), &key))
+            stored[key.ID] = key
+        }
+    }
+    assert.Len(t, stored, 3)
+    for _, keyID := range []string{"root1", "kek1", "dek1"} {
+        assert.Equal(t, "compromised", stored[keyID].Status, keyID)
+        assert.Equal(t, "keyCompromise", stored[keyID].RevocationReason, keyID)
+    }
+    assert.False(t, stored["root1"].CascadePending)
+    
+    // Одна подія аудиту ключа перелічує скомпрометованих нащадків
+    assert.Len(t, mockStub.Invocations, 1)
+    assert.Equal(t, []string{"RecordEvent", "key_operation", "Org1MSP::user1::CN=ca.Org1MSP", "root1", "revoke", "success", `{"cascaded":"2","cascadedKeys":"kek1,dek1","reason":"keyCompromise","status":"compromised"}`}, mockStub.Invocations[0].Args)
+}
+
+// Тестування поширення відкликання пакетами
+func TestPropagateKeyStatus(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+    
+    // Відкликаний kek1 має двох активних нащадків
+    kekJSON, _ := json.Marshal(CryptoKey{ID: "kek1", Status: "deactivated", Role: "kek", OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP"}, RevocationReason: "superseded", ChildKeyCount: 2, CascadePending: true})
+    dek1JSON, _ := json.Marshal(CryptoKey{ID: "dek1", Status: "active", Role: "dek", ParentKeyID: "kek1"})
+    dek2JSON, _ := json.Marshal(CryptoKey{ID: "dek2", Status: "suspended", Role: "dek", ParentKeyID: "kek1"})
+    children := func() *MockQueryIterator {
+        return &MockQueryIterator{Results: []*queryresult.KV{
+            {Key: compositeKey("parent~child", "kek1", "dek1"), Value: indexValue},
+            {Key: compositeKey("parent~child", "kek1", "dek2"), Value: indexValue},
+        }}
+    }
+    
+    // Очікуємо виклики методів
+    mockStub.On("GetState", "cryptokey:kek1").Return(kekJSON, nil)
+    mockStub.On("GetState", "cryptokey:dek1").Return(dek1JSON, nil)
+    mockStub.On("GetState", "cryptokey:dek2").Return(dek2JSON, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("GetStateByPartialCompositeKey", "parent~child", []string{"kek1"}).Return(children(), nil).Once()
+    mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+    mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
+    
+    // Перший пакет вміщує лише одного нащадка
+    contract := new(SmartContract)
+    result, err := contract.PropagateKeyStatus(mockContext, "kek1", 1)
+    
+    // Перевірка результатів
+    assert.Nil(t, err)
+    assert.Equal(t, &CascadeResult{Updated: 1, Complete: false}, result)
+    mockStub.AssertCalled(t, "PutState", "cryptokey:dek1", mock.Anything)
+    mockStub.AssertNotCalled(t, "PutState", "cryptokey:dek2", mock.Anything)
+    mockStub.AssertNotCalled(t, "PutState", "cryptokey:kek1", mock.Anything)
+    
+    var dek1 CryptoKey
+    for _, call := range mockStub.Calls {
+        if call.Method == "PutState" && call.Arguments[0] == "cryptokey:dek1" {
+            err = json.Unmarshal(call.Arguments[1].([]byte), &dek1)
+        }
+    }
+    assert.Nil(t, err)
+    assert.Equal(t, "deactivated", dek1.Status)
+    assert.Equal(t, "superseded", dek1.RevocationReason)
+    
+    // Змінені нащадки фіксуються в події аудиту ключа
+    assert.Equal(t, []MockInvocation{{
+        Chaincode: "securityaudit",
+        Channel:   "",
+        Args:      []string{"RecordEvent", "key_operation", "Org1MSP::user1::CN=ca.Org1MSP", "kek1", "revoke", "success", `{"cascadeComplete":"false","cascaded":"1","cascadedKeys":"dek1","reason":"superseded","status":"deactivated"}`},
+    }}, mockStub.Invocations)
+    
+    // Клієнт, що не є власником ключа, не може поширювати його стан
+    otherContext := new(MockContext)
+    otherContext.On("GetStub").Return(mockStub)
+    otherContext.On("GetClientIdentity").Return(newMockIdentity("Org2MSP", "user2"))
+    _, err = contract.PropagateKeyStatus(otherContext, "kek1", 1)
+    assert.NotNil(t, err)
+    assert.Len(t, mockStub.Invocations, 1)
+}
+
+// Тестування відмови в активації ключа з неактивним батьківським ключем
+func TestActivateKeyUnderInactiveParent(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+    
+    dekJSON, _ := json.Marshal(CryptoKey{ID: "dek1", Status: "suspended", Role: "dek", ParentKeyID: "kek1", OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP"}})
+    kekJSON, _ := json.Marshal(CryptoKey{ID: "kek1", Status: "suspended", Role: "kek"})
+    mockStub.On("GetState", "cryptokey:dek1").Return(dekJSON, nil)
+    mockStub.On("GetState", "cryptokey:kek1").Return(kekJSON, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    err := contract.ReactivateKey(mockContext, "dek1")
+    
+    // Перевірка результатів
+    assert.NotNil(t, err)
+    mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+}
+
+// testCA центр сертифікації для тестів реєстру сертифікатів
+type testCA struct {
+    cert *x509.Certificate
+    key  *ecdsa.PrivateKey
+    pem  string
+}
+
+// newTestCA створює центр сертифікації; без батьківського центру - самопідписаний кореневий
+func newTestCA(t *testing.T, name string, parent *testCA) *testCA {
+    key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    assert.Nil(t, err)
+    template := &x509.Certificate{
+        SerialNumber:          big.NewInt(1),
+        Subject:               pkix.Name{CommonName: name},
+        NotBefore:             time.Unix(1600000000, 0),
+        NotAfter:              time.Unix(1700000000, 0),
+        IsCA:                  true,
+        BasicConstraintsValid: true,
+        KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+        SubjectKeyId:          []byte(name),
+    }
+    issuer, issuerKey := template, key
+    if parent != nil {
+        issuer, issuerKey = parent.cert, parent.key
+    }
+    der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, issuerKey)
+    assert.Nil(t, err)
+    cert, err := x509.ParseCertificate(der)
+    assert.Nil(t, err)
+    return &testCA{cert: cert, key: key, pem: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))}
+}
+
+// issue видає сертифікат кінцевого суб'єкта для відкритого ключа
+func (ca *testCA) issue(t *testing.T, serial int64, publicKey crypto.PublicKey) string {
+    template := &x509.Certificate{
+        SerialNumber: big.NewInt(serial),
+        Subject:      pkix.Name{CommonName: "service.org1"},
+        NotBefore:    time.Unix(1620000000, 0),
+        NotAfter:     time.Unix(1660000000, 0),
+        KeyUsage:     x509.KeyUsageDigitalSignature,
+    }
+    der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, publicKey, ca.key)
+    assert.Nil(t, err)
+    return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+// rootRecordJSON серіалізує запис кореневого центру сертифікації організації
+func rootRecordJSON(ca *testCA, mspID string) []byte {
+    recordJSON, _ := json.Marshal(CACertificate{ID: certificateID(ca.cert), MSPID: mspID, PEM: ca.pem, Root: true})
+    return recordJSON
+}
+
+// Тестування RegisterCARoot
+func TestRegisterCARoot(t *testing.T) {
+    root := newTestCA(t, "Org1 Root CA", nil)
+    intermediate := newTestCA(t, "Org1 Issuing CA", root)
+    
+    testCases := []struct {
+        name     string
+        identity *MockClientIdentity
+        certPEM  string
+        existing []byte
+        wantErr  bool
+    }{
+        {name: "Адміністратор організації", identity: newMockAdminIdentity("Org1MSP", "admin1"), certPEM: root.pem, existing: []byte(nil)},
+        {name: "Звичайний клієнт", identity: newMockIdentity("Org1MSP", "user1"), certPEM: root.pem, existing: []byte(nil), wantErr: true},
+        {name: "Не самопідписаний сертифікат", identity: newMockAdminIdentity("Org1MSP", "admin1"), certPEM: intermediate.pem, existing: []byte(nil), wantErr: true},
+        {name: "Повторна реєстрація", identity: newMockAdminIdentity("Org1MSP", "admin1"), certPEM: root.pem, existing: rootRecordJSON(root, "Org1MSP"), wantErr: true},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            // Ініціалізація мок-об'єктів
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            mockContext.On("GetStub").Return(mockStub)
+            mockContext.On("GetClientIdentity").Return(tc.identity)
+            mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+            mockStub.On("GetState", "cacert:"+certificateID(root.cert)).Return(tc.existing, nil)
+            mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+            
+            // Виклик методу
+            contract := new(SmartContract)
+            record, err := contract.RegisterCARoot(mockContext, tc.certPEM)
+            
+            // Перевірка результатів
+            if tc.wantErr {
+                assert.NotNil(t, err)
+                mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+                return
+            }
+            assert.Nil(t, err)
+            assert.True(t, record.Root)
+            assert.Equal(t, "Org1MSP", record.MSPID)
+            assert.Equal(t, "Org1MSP::admin1::CN=ca.Org1MSP", record.RegisteredBy)
+            mockStub.AssertCalled(t, "PutState", "cacert:"+record.ID, mock.Anything)
+        })
+    }
+}
+
+// Тестування RegisterCertificate з проміжним центром сертифікації
+func TestRegisterCertificate(t *testing.T) {
+    root := newTestCA(t, "Org1 Root CA", nil)
+    intermediate := newTestCA(t, "Org1 Issuing CA", root)
+    privateKey, publicPEM := newTestPublicKey(t)
+    otherKey, _ := newTestPublicKey(t)
+    
+    block, _ := pem.Decode([]byte(publicPEM))
+    fingerprint := sha256.Sum256(block.Bytes)
+    intermediateID := certificateID(intermediate.cert)
+    
+    testCases := []struct {
+        name     string
+        certPEM  string
+        chainPEM string
+        wantErr  error
+    }{
+        {name: "Сертифікат з ланцюжком", certPEM: intermediate.issue(t, 0x1001, &privateKey.PublicKey), chainPEM: intermediate.pem},
+        {name: "Без проміжного сертифіката", certPEM: intermediate.issue(t, 0x1002, &privateKey.PublicKey), chainPEM: "", wantErr: ErrInvalidArgument},
+        {name: "Інший відкритий ключ", certPEM: intermediate.issue(t, 0x1003, &otherKey.PublicKey), chainPEM: intermediate.pem, wantErr: ErrInvalidArgument},
+        {name: "Невідомий кореневий центр", certPEM: newTestCA(t, "Rogue CA", nil).issue(t, 0x1004, &privateKey.PublicKey), chainPEM: "", wantErr: ErrInvalidArgument},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            // Ініціалізація мок-об'єктів
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            mockContext.On("GetStub").Return(mockStub)
+            mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+            
+            keyJSON := []byte(`{"id":"key123","type":"asymmetric","algorithm":"ECDSA","keySize":256,"status":"active","ownerIds":["Org1MSP::user1::CN=ca.Org1MSP"],"expiresAt":1651536000}`)
+            recordJSON, _ := json.Marshal(PublicKeyRecord{KeyID: "key123", Algorithm: "ECDSA", KeySize: 256, PEM: publicPEM, Fingerprint: hex.EncodeToString(fingerprint[:])})
+            mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+            mockStub.On("GetState", "pubkey:key123").Return(recordJSON, nil)
+            mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+            mockStub.On("GetStateByRange", "cacert:", "cacert;").Return(&MockQueryIterator{Results: []*queryresult.KV{
+                {Key: "cacert:" + certificateID(root.cert), Value: rootRecordJSON(root, "Org1MSP")},
+            }}, nil)
+            mockStub.On("GetState", "cacert:"+intermediateID).Return([]byte(nil), nil)
+            mockStub.On("GetState", compositeKey("certificate", intermediateID, "1001")).Return([]byte(nil), nil)
+            mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+            
+            // Виклик методу
+            contract := new(SmartContract)
+            record, err := contract.RegisterCertificate(mockContext, "key123", tc.certPEM, tc.chainPEM)
+            
+            // Перевірка результатів
+            if tc.wantErr != nil {
+                assert.True(t, errors.Is(err, tc.wantErr), "%v", err)
+                mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+                return
+            }
+            assert.Nil(t, err)
+            assert.Equal(t, intermediateID, record.IssuerID)
+            assert.Equal(t, "1001", record.SerialNumber)
+            assert.Equal(t, "valid", record.Status)
+            
+            // Проміжний центр успадковує організацію кореневого
+            var ca CACertificate
+            for _, call := range mockStub.Calls {
+                if call.Method == "PutState" && call.Arguments[0] == "cacert:"+intermediateID {
+                    assert.Nil(t, json.Unmarshal(call.Arguments[1].([]byte), &ca))
+                }
+            }
+            assert.False(t, ca.Root)
+            assert.Equal(t, "Org1MSP", ca.MSPID)
+            mockStub.AssertCalled(t, "PutState", compositeKey("certificate", intermediateID, "1001"), mock.Anything)
+            mockStub.AssertCalled(t, "PutState", compositeKey("key~cert", "key123", intermediateID, "1001"), indexValue)
+        })
+    }
+}
+
+// Тестування RevokeCertificate
+func TestRevokeCertificate(t *testing.T) {
+    testCases := []struct {
+        name       string
+        identity   *MockClientIdentity
+        reasonCode string
+        keyStatus  string
+        wantErr    bool
+    }{
+        {name: "Власник ключа", identity: newMockIdentity("Org1MSP", "user1"), reasonCode: "superseded", keyStatus: "active"},
+        {name: "Адміністратор організації центру", identity: newMockAdminIdentity("Org1MSP", "admin1"), reasonCode: "affiliationChanged", keyStatus: "active"},
+        {name: "Адміністратор іншої організації", identity: newMockAdminIdentity("Org2MSP", "admin2"), reasonCode: "affiliationChanged", keyStatus: "active", wantErr: true},
+        {name: "Невідома причина", identity: newMockIdentity("Org1MSP", "user1"), reasonCode: "lost", keyStatus: "active", wantErr: true},
+        {name: "Ключ знищено", identity: newMockIdentity("Org1MSP", "user1"), reasonCode: "superseded", keyStatus: "destroyed", wantErr: true},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            // Ініціалізація мок-об'єктів
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            mockContext.On("GetStub").Return(mockStub)
+            mockContext.On("GetClientIdentity").Return(tc.identity)
+            
+            keyJSON, _ := json.Marshal(CryptoKey{ID: "key123", Status: tc.keyStatus, OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP"}})
+            certJSON, _ := json.Marshal(CertificateRecord{IssuerID: "ca1", SerialNumber: "1001", KeyID: "key123", NotAfter: 1660000000, Status: "valid"})
+            caJSON, _ := json.Marshal(CACertificate{ID: "ca1", MSPID: "Org1MSP", Root: true})
+            mockStub.On("GetState", compositeKey("certificate", "ca1", "1001")).Return(certJSON, nil)
+            mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+            mockStub.On("GetState", "cacert:ca1").Return(caJSON, nil)
+            mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+            mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+            
+            // Виклик методу
+            contract := new(SmartContract)
+            err := contract.RevokeCertificate(mockContext, "ca1", "1001", tc.reasonCode)
+            
+            // Перевірка результатів
+            if tc.wantErr {
+                assert.NotNil(t, err)
+                mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+                return
+            }
+            assert.Nil(t, err)
+            
+            var stored CertificateRecord
+            assert.Nil(t, json.Unmarshal(mockStub.Calls[len(mockStub.Calls)-1].Arguments[1].([]byte), &stored))
+            assert.Equal(t, "revoked", stored.Status)
+            assert.Equal(t, tc.reasonCode, stored.RevocationReason)
+            assert.Equal(t, txTimestamp.Seconds, stored.RevokedAt)
+        })
+    }
+}
+
+// Тестування GetCRL: тіло CRL, підписане видавцем, розбирає стандартна бібліотека
+func TestGetCRL(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    
+    root := newTestCA(t, "Org1 Root CA", nil)
+    issuerID := certificateID(root.cert)
+    caJSON, _ := json.Marshal(CACertificate{ID: issuerID, MSPID: "Org1MSP", PEM: root.pem, Root: true})
+    
+    // a1 відкликано явно, b2 - через компрометацію ключа, c3 чинний, d4 відкликано, але строк дії минув
+    activeJSON, _ := json.Marshal(CryptoKey{ID: "key1", Status: "active"})
+    compromisedJSON, _ := json.Marshal(CryptoKey{ID: "key2", Status: "compromised", CompromisedAt: 1625000000})
+    certA, _ := json.Marshal(CertificateRecord{IssuerID: issuerID, SerialNumber: "a1", KeyID: "key1", NotAfter: 1660000000, Status: "revoked", RevokedAt: 1620000000, RevocationReason: "superseded"})
+    certB, _ := json.Marshal(CertificateRecord{IssuerID: issuerID, SerialNumber: "b2", KeyID: "key2", NotAfter: 1660000000, Status: "valid"})
+    certC, _ := json.Marshal(CertificateRecord{IssuerID: issuerID, SerialNumber: "c3", KeyID: "key1", NotAfter: 1660000000, Status: "valid"})
+    certD, _ := json.Marshal(CertificateRecord{IssuerID: issuerID, SerialNumber: "d4", KeyID: "key1", NotAfter: 1620000000, Status: "revoked", RevokedAt: 1610000000, RevocationReason: "keyCompromise"})
+    
+    mockStub.On("GetState", "cacert:"+issuerID).Return(caJSON, nil)
+    mockStub.On("GetState", "cryptokey:key1").Return(activeJSON, nil)
+    mockStub.On("GetState", "cryptokey:key2").Return(compromisedJSON, nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("GetStateByPartialCompositeKey", "certificate", []string{issuerID}).Return(&MockQueryIterator{Results: []*queryresult.KV{
+        {Key: compositeKey("certificate", issuerID, "a1"), Value: certA},
+        {Key: compositeKey("certificate", issuerID, "b2"), Value: certB},
+        {Key: compositeKey("certificate", issuerID, "c3"), Value: certC},
+        {Key: compositeKey("certificate", issuerID, "d4"), Value: certD},
+    }}, nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    list, err := contract.GetCRL(mockContext, issuerID)
+    
+    // Перевірка результатів
+    assert.Nil(t, err)
+    assert.Len(t, list.Entries, 2)
+    assert.Equal(t, txTimestamp.Seconds+7*24*60*60, list.NextUpdate)
+    
+    tbs, err := base64.StdEncoding.DecodeString(list.TBSCertList)
+    assert.Nil(t, err)
+    der, err := crl.Sign(tbs, root.key)
+    assert.Nil(t, err)
+    parsed, err := x509.ParseRevocationList(der)
+    assert.Nil(t, err)
+    assert.Nil(t, parsed.CheckSignatureFrom(root.cert))
+    assert.Equal(t, big.NewInt(txTimestamp.Seconds), parsed.Number)
+    assert.Len(t, parsed.RevokedCertificateEntries, 2)
+    assert.Equal(t, big.NewInt(0xa1), parsed.RevokedCertificateEntries[0].SerialNumber)
+    assert.Equal(t, 4, parsed.RevokedCertificateEntries[0].ReasonCode)
+    assert.Equal(t, big.NewInt(0xb2), parsed.RevokedCertificateEntries[1].SerialNumber)
+    assert.Equal(t, 1, parsed.RevokedCertificateEntries[1].ReasonCode)
+    assert.Equal(t, int64(1625000000), parsed.RevokedCertificateEntries[1].RevocationTime.Unix())
+}
+
+// Тестування GetCertificateStatus
+func TestGetCertificateStatus(t *testing.T) {
+    testCases := []struct {
+        name           string
+        certJSON       []byte
+        keyJSON        []byte
+        expectedStatus string
+        expectedReason string
+    }{
+        {name: "Чинний сертифікат", certJSON: []byte(`{"issuerId":"ca1","serialNumber":"1001","keyId":"key123","status":"valid"}`), keyJSON: []byte(`{"id":"key123","status":"active"}`), expectedStatus: "valid"},
+        {name: "Ключ знищено", certJSON: []byte(`{"issuerId":"ca1","serialNumber":"1001","keyId":"key123","status":"valid"}`), keyJSON: []byte(`{"id":"key123","status":"destroyed","destroyedAt":1629000000}`), expectedStatus: "revoked", expectedReason: "cessationOfOperation"},
+        {name: "Незареєстрований сертифікат", certJSON: []byte(nil), expectedStatus: "unknown"},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            // Ініціалізація мок-об'єктів
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            mockContext.On("GetStub").Return(mockStub)
+            mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+            mockStub.On("GetState", compositeKey("certificate", "ca1", "1001")).Return(tc.certJSON, nil)
+            mockStub.On("GetState", "cryptokey:key123").Return(tc.keyJSON, nil)
+            
+            // Виклик методу
+            contract := new(SmartContract)
+            status, err := contract.GetCertificateStatus(mockContext, "ca1", "1001")
+            
+            // Перевірка результатів
+            assert.Nil(t, err)
+            assert.Equal(t, tc.expectedStatus, status.Status)
+            assert.Equal(t, tc.expectedReason, status.RevocationReason)
+            assert.Equal(t, txTimestamp.Seconds, status.CheckedAt)
+        })
+    }
+}
+
+// newEventContext створює контекст транзакції, що накопичує події, як у розгорнутому чейнкоді
+func newEventContext(stub *MockStub, identity *MockClientIdentity) *KeyManagementContext {
+    ctx := new(KeyManagementContext)
+    ctx.SetStub(stub)
+    ctx.SetClientIdentity(identity)
+    return ctx
+}
+
+// publishedEvents повертає події з останнього пакета, опублікованого транзакцією
+func publishedEvents(t *testing.T, stub *MockStub) []KeyEvent {
+    assert.Equal(t, "KeyManagementEvent", stub.EventName)
+    var batch KeyEventBatch
+    assert.Nil(t, json.Unmarshal(stub.EventPayload, &batch))
+    assert.Equal(t, 1, batch.SchemaVersion)
+    return batch.Events
+}
+
+// Тестування події KeyGenerated
+func TestGenerateKeyEvent(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org1MSP", "user1"))
+    mockStub.On("GetTransient").Return(map[string][]byte{}, nil)
+    mockStub.On("GetTxID").Return("tx123456789")
+    mockStub.On("GetState", "cryptokey:key123-tx123456").Return([]byte(nil), nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+    mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    err := contract.GenerateKey(mockContext, "key123", "symmetric", "AES", 256, `[]`, 30)
+    
+    // Перевірка результатів
+    assert.Nil(t, err)
+    events := publishedEvents(t, mockStub)
+    assert.Equal(t, []KeyEvent{{
+        Type:      "KeyGenerated",
+        KeyID:     "key123-tx123456",
+        KeyType:   "symmetric",
+        Algorithm: "AES",
+        KeySize:   256,
+        Status:    "active",
+        ExpiresAt: txTimestamp.Seconds + 30*24*60*60,
+    }}, events)
+}
+
+// Тестування пакета подій ротації: зміна стану старого ключа і KeyRotated в одній події
+func TestRotateKeyEvents(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    ctx := newEventContext(mockStub, newMockIdentity("Org1MSP", "user1"))
+    
+    oldKeyJSON := []byte(`{"id":"key123","type":"symmetric","algorithm":"AES","status":"active","ownerIds":["Org1MSP::user1::CN=ca.Org1MSP"],"createdAt":1620000000,"activatedAt":1620000000,"expiresAt":1651536000}`)
+    mockStub.On("GetState", "cryptokey:key123").Return(oldKeyJSON, nil)
+    mockStub.On("GetTxID").Return("tx456")
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+    mockStub.On("GetStateByPartialCompositeKey", "keyaccess", []string{"key123"}).Return(&MockQueryIterator{}, nil)
+    mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
+    mockStub.On("GetState", "cryptokey:key123-tx456").Return([]byte(nil), nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    newKeyID, err := contract.RotateKey(ctx, "key123")
+    
+    // Перевірка результатів
+    assert.Nil(t, err)
+    events := publishedEvents(t, mockStub)
+    assert.Len(t, events, 2)
+    assert.Equal(t, KeyEvent{Type: "KeyStatusChanged", KeyID: "key123", PreviousStatus: "active", Status: "deactivated"}, events[0])
+    assert.Equal(t, KeyEvent{Type: "KeyRotated", KeyID: "key123", NewKeyID: newKeyID, ExpiresAt: txTimestamp.Seconds + 31536000}, events[1])
+}
+
+// Тестування пакета подій каскадного відкликання доступу
+func TestRevokeKeyAccessEvents(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    ctx := newEventContext(mockStub, newMockIdentity("Org1MSP", "user1"))
+    
+    // Власник -> user2 -> user3
+    grant := func(userID string, grantedBy string) []byte {
+        accessJSON, _ := json.Marshal(KeyAccess{KeyID: "key123", UserID: userID, AccessType: "full", GrantedBy: grantedBy})
+        return accessJSON
+    }
+    keyJSON, _ := json.Marshal(CryptoKey{ID: "key123", Status: "active", OwnerIDs: []string{"Org1MSP::user1::CN=ca.Org1MSP"}})
+    mockStub.On("GetState", compositeKey("keyaccess", "key123", "user2")).Return(grant("user2", "Org1MSP::user1::CN=ca.Org1MSP"), nil)
+    mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+    mockStub.On("GetStateByPartialCompositeKey", "keyaccess", []string{"key123"}).Return(&MockQueryIterator{Results: []*queryresult.KV{
+        {Key: compositeKey("keyaccess", "key123", "user2"), Value: grant("user2", "Org1MSP::user1::CN=ca.Org1MSP")},
+        {Key: compositeKey("keyaccess", "key123", "user3"), Value: grant("user3", "user2")},
+    }}, nil)
+    mockStub.On("DelState", mock.Anything).Return(nil)
+    mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    err := contract.RevokeKeyAccess(ctx, "key123", "user2")
+    
+    // Перевірка результатів
+    assert.Nil(t, err)
+    assert.Equal(t, []KeyEvent{
+        {Type: "KeyAccessRevoked", KeyID: "key123", UserID: "user2", Reason: "revoked"},
+        {Type: "KeyAccessRevoked", KeyID: "key123", UserID: "user3", Reason: "cascade"},
+    }, publishedEvents(t, mockStub))
+}
+
+// Тестування запису події аудиту генерації ключа в смарт-контракт securityaudit
+func TestGenerateKeyAudit(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    ctx := newEventContext(mockStub, newMockIdentity("Org1MSP", "user1"))
+    mockStub.On("GetTransient").Return(map[string][]byte{}, nil)
+    mockStub.On("GetTxID").Return("tx123456789")
+    mockStub.On("GetState", "cryptokey:key123-tx123456").Return([]byte(nil), nil)
+    mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+    mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+    mockStub.On("GetState", "config:audit").Return([]byte(nil), nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    err := contract.GenerateKey(ctx, "key123", "symmetric", "AES", 256, `[]`, 30)
+    
+    // Перевірка результатів: подія записана в securityaudit у каналі транзакції
+    assert.Nil(t, err)
+    assert.Equal(t, []MockInvocation{{
+        Chaincode: "securityaudit",
+        Channel:   "",
+        Args:      []string{"RecordEvent", "key_operation", "Org1MSP::user1::CN=ca.Org1MSP", "key123-tx123456", "generate", "success", `{"algorithm":"AES","status":"active"}`},
+    }}, mockStub.Invocations)
+    
+    // Подія аудиту не дублюється в пакеті подій ключів
+    var batch KeyEventBatch
+    assert.Nil(t, json.Unmarshal(mockStub.EventPayload, &batch))
+    assert.Nil(t, batch.Audit)
+}
+
+// Тестування локальної публікації події аудиту, коли смарт-контракт аудиту недоступний або вимкнений
+func TestRecordKeyAuditFallback(t *testing.T) {
+    unavailable := shim.Error("chaincode securityaudit not found")
+    testCases := []struct {
+        name        string
+        configJSON  []byte
+        response    *pb.Response
+        invocations int
+    }{
+        {name: "Смарт-контракт аудиту недоступний", configJSON: []byte(nil), response: &unavailable, invocations: 1},
+        {name: "Запис в смарт-контракт аудиту вимкнено", configJSON: []byte(`{"chaincodeName":"","channel":""}`), invocations: 0},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            // Ініціалізація мок-об'єктів
+            mockStub := &MockStub{InvokeResponse: tc.response}
+            ctx := newEventContext(mockStub, newMockIdentity("Org1MSP", "user1"))
+            keyJSON := []byte(`{"id":"key123","type":"symmetric","algorithm":"AES","status":"active","ownerIds":["Org1MSP::user1::CN=ca.Org1MSP"],"createdAt":1620000000,"activatedAt":1620000000,"expiresAt":1651536000}`)
+            mockStub.On("GetState", "cryptokey:key123").Return(keyJSON, nil)
+            mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+            mockStub.On("PutState", "cryptokey:key123", mock.Anything).Return(nil)
+            mockStub.On("GetState", "config:audit").Return(tc.configJSON, nil)
+            
+            // Виклик методу
+            contract := new(SmartContract)
+            err := contract.RevokeKey(ctx, "key123", "superseded")
+            
+            // Перевірка результатів: подія аудиту публікується в одному пакеті зі зміною стану ключа
+            assert.Nil(t, err)
+            assert.Len(t, mockStub.Invocations, tc.invocations)
+            events := publishedEvents(t, mockStub)
+            assert.Equal(t, []KeyEvent{{Type: "KeyStatusChanged", KeyID: "key123", PreviousStatus: "active", Status: "deactivated", Reason: "superseded"}}, events)
+            var batch KeyEventBatch
+            assert.Nil(t, json.Unmarshal(mockStub.EventPayload, &batch))
+            assert.Equal(t, &KeyAuditRecord{
+                EventType: "key_operation",
+                Actor:     "Org1MSP::user1::CN=ca.Org1MSP",
+                Resource:  "key123",
+                Action:    "revoke",
+                Result:    "success",
+                Metadata:  map[string]string{"reason": "superseded", "status": "deactivated"},
+            }, batch.Audit)
+        })
+    }
+}
+
+// Тестування пропозиції зміни налаштувань аудиту
+func TestProposeAuditConfig(t *testing.T) {
+    testCases := []struct {
+        name          string
+        identity      *MockClientIdentity
+        chaincodeName string
+        channel       string
+        wantErr       bool
+    }{
+        {name: "Адміністратор організації", identity: newMockAdminIdentity("Org1MSP", "admin1"), chaincodeName: "audit-v2", channel: "security-channel"},
+        {name: "Вимкнення запису в смарт-контракт аудиту", identity: newMockAdminIdentity("Org1MSP", "admin1")},
+        {name: "Звичайний клієнт", identity: newMockIdentity("Org1MSP", "user1"), chaincodeName: "audit-v2", channel: "security-channel", wantErr: true},
+        {name: "Канал транзакції за замовчуванням", identity: newMockAdminIdentity("Org1MSP", "admin1"), chaincodeName: "audit-v2"},
+        {name: "Інший канал", identity: newMockAdminIdentity("Org1MSP", "admin1"), chaincodeName: "audit-v2", channel: "common-channel", wantErr: true},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            // Ініціалізація мок-об'єктів
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            mockContext.On("GetStub").Return(mockStub)
+            mockContext.On("GetClientIdentity").Return(tc.identity)
+            mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+            mockStub.On("GetTxID").Return("tx123")
+            mockStub.On("GetChannelID").Return("security-channel")
+            mockStub.On("PutState", "keyproposal:tx123", mock.Anything).Return(nil)
+            
+            // Виклик методу
+            contract := new(SmartContract)
+            proposal, err := contract.ProposeAuditConfig(mockContext, tc.chaincodeName, tc.channel)
+            
+            // Перевірка результатів: одного голосу недостатньо, налаштування не змінюються
+            if tc.wantErr {
+                assert.NotNil(t, err)
+                mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+                return
+            }
+            assert.Nil(t, err)
+            assert.Equal(t, "audit-config", proposal.Operation)
+            assert.Equal(t, "pending", proposal.Status)
+            assert.Equal(t, 2, proposal.Threshold)
+            assert.Equal(t, "Org1MSP::admin1::CN=ca.Org1MSP", proposal.ProposedBy)
+            assert.Equal(t, []ProposalVote{{Voter: "Org1MSP", VotedAt: txTimestamp.Seconds, TxID: "tx123"}}, proposal.Votes)
+            var config AuditConfig
+            assert.Nil(t, json.Unmarshal([]byte(proposal.Parameter), &config))
+            assert.Equal(t, AuditConfig{ChaincodeName: tc.chaincodeName, Channel: tc.channel}, config)
+            mockStub.AssertNotCalled(t, "PutState", "config:audit", mock.Anything)
+        })
+    }
+}
+
+// Тестування схвалення зміни налаштувань аудиту організаціями
+func TestApproveAuditConfig(t *testing.T) {
+    pendingJSON := []byte(`{"id":"tx100","operation":"audit-config","parameter":"{\"chaincodeName\":\"\",\"channel\":\"\"}","threshold":2,"status":"pending","proposedBy":"Org1MSP::admin1::CN=ca.Org1MSP","createdAt":1629990000,"expiresAt":1630594800,"votes":[{"voter":"Org1MSP","votedAt":1629990000,"txId":"tx100"}]}`)
+    keyProposalJSON := []byte(`{"id":"tx100","keyId":"key123","operation":"destroy","threshold":2,"status":"pending","proposedBy":"Org1MSP::user1::CN=ca.Org1MSP","createdAt":1629990000,"expiresAt":1630594800,"votes":[{"voter":"Org1MSP::user1::CN=ca.Org1MSP","votedAt":1629990000,"txId":"tx100"}]}`)
+    testCases := []struct {
+        name         string
+        identity     *MockClientIdentity
+        proposalJSON []byte
+        wantErr      bool
+    }{
+        {name: "Адміністратор другої організації", identity: newMockAdminIdentity("Org2MSP", "admin2"), proposalJSON: pendingJSON},
+        {name: "Повторний голос організації", identity: newMockAdminIdentity("Org1MSP", "admin3"), proposalJSON: pendingJSON, wantErr: true},
+        {name: "Звичайний клієнт", identity: newMockIdentity("Org2MSP", "user2"), proposalJSON: pendingJSON, wantErr: true},
+        {name: "Пропозиція операції з ключем", identity: newMockAdminIdentity("Org2MSP", "admin2"), proposalJSON: keyProposalJSON, wantErr: true},
+    }
+    
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            // Ініціалізація мок-об'єктів
+            mockStub := new(MockStub)
+            mockContext := new(MockContext)
+            mockContext.On("GetStub").Return(mockStub)
+            mockContext.On("GetClientIdentity").Return(tc.identity)
+            mockStub.On("GetState", "keyproposal:tx100").Return(tc.proposalJSON, nil)
+            mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+            mockStub.On("GetTxID").Return("tx200")
+            mockStub.On("PutState", "config:audit", mock.Anything).Return(nil)
+            mockStub.On("PutState", "keyproposal:tx100", mock.Anything).Return(nil)
+            
+            // Виклик методу
+            contract := new(SmartContract)
+            proposal, err := contract.ApproveAuditConfig(mockContext, "tx100")
+            
+            // Перевірка результатів: голос другої організації змінює налаштування
+            if tc.wantErr {
+                assert.NotNil(t, err)
+                mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+                return
+            }
+            assert.Nil(t, err)
+            assert.Equal(t, "executed", proposal.Status)
+            assert.Equal(t, txTimestamp.Seconds, proposal.ExecutedAt)
+            var config AuditConfig
+            for _, call := range mockStub.Calls {
+                if call.Method == "PutState" && call.Arguments[0] == "config:audit" {
+                    assert.Nil(t, json.Unmarshal(call.Arguments[1].([]byte), &config))
+                }
+            }
+            assert.Equal(t, AuditConfig{ProposalID: "tx100", UpdatedBy: "Org1MSP::admin1::CN=ca.Org1MSP", UpdatedAt: txTimestamp.Seconds}, config)
+        })
+    }
+}
+
+// Тестування фіксації відхиленої спроби операції з ключем
+func TestRecordFailedKeyOperation(t *testing.T) {
+    // Ініціалізація мок-об'єктів
+    mockStub := new(MockStub)
+    mockContext := new(MockContext)
+    mockContext.On("GetStub").Return(mockStub)
+    mockContext.On("GetClientIdentity").Return(newMockIdentity("Org2MSP", "user2"))
+    mockStub.On("GetState", "config:audit").Return([]byte(`{"chaincodeName":"audit-v2","channel":""}`), nil)
+    
+    // Виклик методу
+    contract := new(SmartContract)
+    err := contract.RecordFailedKeyOperation(mockContext, "rotate", "key123", "denied", "клієнт Org2MSP::user2::CN=ca.Org2MSP не є власником ключа key123")
+    
+    // Перевірка результатів: актором події є клієнт, що повідомляє про спробу
+    assert.Nil(t, err)
+    assert.Equal(t, []MockInvocation{{
+        Chaincode: "audit-v2",
+        Channel:   "",
+        Args:      []string{"RecordEvent", "key_operation", "Org2MSP::user2::CN=ca.Org2MSP", "key123", "rotate", "denied", `{"error":"клієнт Org2MSP::user2::CN=ca.Org2MSP не є власником ключа key123"}`},
+    }}, mockStub.Invocations)
+    
+    // Некоректні дія, результат і опис помилки відхиляються до запису події
+    assert.True(t, errors.Is(contract.RecordFailedKeyOperation(mockContext, "delete", "key123", "denied", ""), ErrInvalidArgument))
+    assert.True(t, errors.Is(contract.RecordFailedKeyOperation(mockContext, "rotate", "key123", "success", ""), ErrInvalidArgument))
+    assert.True(t, errors.Is(contract.RecordFailedKeyOperation(mockContext, "rotate", "key123", "failure", strings.Repeat("x", 1025)), ErrInvalidArgument))
+    assert.Len(t, mockStub.Invocations, 1)
+}
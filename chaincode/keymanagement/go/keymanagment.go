@@ -3,9 +3,11 @@ package main
 import (
     "fmt"
     "encoding/json"
-    "time"
+    "strconv"
     
     "github.com/hyperledger/fabric-contract-api-go/contractapi"
+    
+    "blockchain-security/chaincode/common/clock"
 )
 
 // SmartContract представляє смарт-контракт управління ключами
@@ -15,16 +17,30 @@ type SmartContract struct {
 
 // CryptoKey структура криптографічного ключа
 type CryptoKey struct {
-    ID          string   `json:"id"`
-    Type        string   `json:"type"` // symmetric, asymmetric
-    Algorithm   string   `json:"algorithm"` // AES, RSA, ECDSA
-    Status      string   `json:"status"` // active, rotated, revoked
-    OwnerIDs    []string `json:"ownerIds"`
-    CreatedAt   int64    `json:"createdAt"`
-    ActivatedAt int64    `json:"activatedAt"`
-    ExpiresAt   int64    `json:"expiresAt"`
-    RevokedAt   int64    `json:"revokedAt"`
-    Metadata    string   `json:"metadata"` // шифровані метадані
+    ID                 string   `json:"id"`
+    Type               string   `json:"type"` // symmetric, asymmetric
+    Algorithm          string   `json:"algorithm"` // AES, RSA, ECDSA, Ed25519
+    KeySize            int      `json:"keySize"` // розмір ключа в бітах
+    Status             string   `json:"status"` // pre-activation, active, suspended, deactivated, compromised, destroyed
+    OwnerIDs           []string `json:"ownerIds"`
+    CreatedAt          int64    `json:"createdAt"`
+    ActivatedAt        int64    `json:"activatedAt"`
+    ExpiresAt          int64    `json:"expiresAt"`
+    RevokedAt          int64    `json:"revokedAt"`
+    RevocationReason   string   `json:"revocationReason,omitempty"` // код причини за RFC 5280
+    CompromisedAt      int64    `json:"compromisedAt,omitempty"`
+    DestroyedAt        int64    `json:"destroyedAt,omitempty"`
+    Metadata           string   `json:"metadata"` // SHA-256 приватних метаданих, hex
+    MetadataCollection string   `json:"metadataCollection,omitempty"` // колекція приватних даних з метаданими
+    ApprovalThreshold  int      `json:"approvalThreshold,omitempty"` // кворум власників для незворотних операцій; 0 - більшість
+    PreviousKeyID      string   `json:"previousKeyId,omitempty"` // попередня версія ключа до ротації
+    ReplacedBy         string   `json:"replacedBy,omitempty"` // нова версія ключа після ротації
+    RotationPolicy     *RotationPolicy `json:"rotationPolicy,omitempty"` // політика планової ротації
+    OwnersChangedAt    int64    `json:"ownersChangedAt,omitempty"` // час останньої зміни складу власників
+    Role               string   `json:"role,omitempty"` // root, kek, dek; порожня - ключ поза ієрархією
+    ParentKeyID        string   `json:"parentKeyId,omitempty"` // ключ, яким захищено цей ключ
+    ChildKeyCount      int      `json:"childKeyCount,omitempty"` // кількість дочірніх ключів
+    CascadePending     bool     `json:"cascadePending,omitempty"` // зміну стану ще не поширено на всіх нащадків
 }
 
 // KeyAccess структура доступу до ключа
@@ -35,63 +51,203 @@ type KeyAccess struct {
     GrantedAt  int64    `json:"grantedAt"`
     ExpiresAt  int64    `json:"expiresAt"`
     GrantedBy  string   `json:"grantedBy"`
+    UsageCount int64    `json:"usageCount,omitempty"` // кількість використань за цим доступом
+    UsageQuota int64    `json:"usageQuota,omitempty"` // ліміт використань; 0 - без обмежень
+    CanDelegate     bool `json:"canDelegate,omitempty"` // чи може користувач надавати доступ далі
+    DelegationDepth int  `json:"delegationDepth,omitempty"` // скільки рівнів повторної передачі ще дозволено
 }
 
-// Префікси для ключів у world state
+// Префікс для ключів у world state
+const keyPrefix = "cryptokey:"
+
+// Типи складених ключів у world state
 const (
-    keyPrefix    = "cryptokey:"
-    accessPrefix = "keyaccess:"
+    accessObjectType  = "keyaccess"      // keyID, userID -> KeyAccess
+    accessByUserIndex = "keyaccess~user" // userID, keyID -> індекс
+    ownerIndex        = "owner~key"      // ownerID, keyID -> індекс
+    childIndex        = "parent~child"   // parentKeyID, keyID -> індекс
+    usageObjectType   = "keyusage"       // keyID, userID, година -> UsageBucket
+    certObjectType    = "certificate"    // issuerID, serialNumber -> CertificateRecord
+    certByKeyIndex    = "key~cert"       // keyID, issuerID, serialNumber -> індекс
 )
 
+// Максимальна довжина ланцюжка ротацій, який обходить GetRotationChain
+const maxRotationChain = 100
+
 // InitLedger ініціалізує стан смарт-контракту
 func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
     fmt.Println("Контракт управління ключами ініціалізовано")
     return nil
 }
 
-// GenerateKey створює новий криптографічний ключ
-func (s *SmartContract) GenerateKey(ctx contractapi.TransactionContextInterface, id string, keyType string, algorithm string, ownerIDs string, expirationDays int) error {
-    // Створюємо унікальний ідентифікатор ключа
-    txID := ctx.GetStub().GetTxID()
-    keyID := fmt.Sprintf("%s-%s", id, txID[:8])
+// GenerateKey створює новий активний криптографічний ключ
+func (s *SmartContract) GenerateKey(ctx contractapi.TransactionContextInterface, id string, keyType string, algorithm string, keySize int, ownerIDs string, expirationDays int) error {
+    if err := createKey(ctx, id, keyType, algorithm, keySize, ownerIDs, expirationDays, statusActive, "", nil); err != nil {
+        return err
+    }
+    return recordKeyAudit(ctx, auditActionGenerate, generatedKeyID(ctx, id), auditResultSuccess, map[string]string{"algorithm": algorithm, "status": statusActive})
+}
+
+// GeneratePendingKey створює ключ у стані pre-activation, який потребує виклику ActivateKey
+func (s *SmartContract) GeneratePendingKey(ctx contractapi.TransactionContextInterface, id string, keyType string, algorithm string, keySize int, ownerIDs string, expirationDays int) error {
+    if err := createKey(ctx, id, keyType, algorithm, keySize, ownerIDs, expirationDays, statusPreActivation, "", nil); err != nil {
+        return err
+    }
+    return recordKeyAudit(ctx, auditActionGenerate, generatedKeyID(ctx, id), auditResultSuccess, map[string]string{"algorithm": algorithm, "status": statusPreActivation})
+}
+
+// createKey створює запис ключа у вказаному початковому стані; parent задається для ключів ієрархії
+func createKey(ctx contractapi.TransactionContextInterface, id string, keyType string, algorithm string, keySize int, ownerIDs string, expirationDays int, status string, role string, parent *CryptoKey) error {
+    // Перевіряємо параметри до будь-яких звернень до стану
+    if err := validateKeyID(id); err != nil {
+        return err
+    }
+    if err := validateKeySpec(keyType, algorithm, keySize); err != nil {
+        return err
+    }
+    if err := validateLifetime(expirationDays); err != nil {
+        return err
+    }
     
     // Парсимо власників з JSON рядка
     var ownersList []string
     err := json.Unmarshal([]byte(ownerIDs), &ownersList)
     if err != nil {
-        return fmt.Errorf("помилка при розборі власників: %v", err)
+        return invalidArgument("ownerIDs", "помилка при розборі власників: %v", err)
+    }
+    if err := validateOwners(ownersList); err != nil {
+        return err
+    }
+    
+    // Чутливі метадані передаються лише через transient map
+    metadataJSON, err := transientMetadata(ctx)
+    if err != nil {
+        return err
+    }
+    
+    // Створюємо унікальний ідентифікатор ключа
+    keyID := generatedKeyID(ctx, id)
+    
+    // Ключ з таким ідентифікатором не можна перезаписати
+    exists, err := keyExists(ctx, keyID)
+    if err != nil {
+        return err
+    }
+    if exists {
+        return &ValidationError{Kind: ErrAlreadyExists, Field: "id", Message: fmt.Sprintf("ключ %s вже існує", keyID)}
+    }
+    
+    // Клієнт, що створює ключ, завжди є його власником
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return err
+    }
+    if !containsString(ownersList, caller) {
+        ownersList = append([]string{caller}, ownersList...)
+        if err := validateOwners(ownersList); err != nil {
+            return err
+        }
+    }
+    
+    // Встановлюємо час дії за часом транзакції
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return err
+    }
+    expiresAt := clock.AfterDays(now, expirationDays)
+    
+    // Дочірній ключ не може пережити батьківський
+    if parent != nil && parent.ExpiresAt > 0 && expiresAt > parent.ExpiresAt {
+        expiresAt = parent.ExpiresAt
     }
     
-    // Встановлюємо час дії
-    now := time.Now().Unix()
-    expiresAt := now + int64(expirationDays*24*60*60)
+    // Ключ, що очікує активації, ще не має дати активації
+    activatedAt := now
+    if status != statusActive {
+        activatedAt = 0
+    }
     
     // Створюємо запис ключа
     key := CryptoKey{
         ID:          keyID,
         Type:        keyType,
         Algorithm:   algorithm,
-        Status:      "active",
+        KeySize:     keySize,
+        Status:      status,
         OwnerIDs:    ownersList,
         CreatedAt:   now,
-        ActivatedAt: now,
+        ActivatedAt: activatedAt,
         ExpiresAt:   expiresAt,
         RevokedAt:   0,
-        Metadata:    "", // В реальному коді тут були б шифровані метадані
+        Role:        role,
+    }
+    if parent != nil {
+        key.ParentKeyID = parent.ID
     }
     
-    // Серіалізуємо ключ
-    keyJSON, err := json.Marshal(key)
-    if err != nil {
+    // У публічному записі залишається лише хеш приватних метаданих
+    if metadataJSON != nil {
+        if err := storeKeyMetadata(ctx, &key, metadataJSON); err != nil {
+            return err
+        }
+    }
+    
+    // Зберігаємо в state database разом з індексом власників
+    if err := putKey(ctx, &key); err != nil {
+        return err
+    }
+    if err := indexKeyOwners(ctx, &key); err != nil {
         return err
     }
+    if parent != nil {
+        if err := attachChildKey(ctx, parent, &key); err != nil {
+            return err
+        }
+    }
     
-    // Зберігаємо в state database
-    return ctx.GetStub().PutState(keyPrefix+keyID, keyJSON)
+    return emitKeyEvent(ctx, KeyEvent{
+        Type:        eventKeyGenerated,
+        KeyID:       key.ID,
+        KeyType:     key.Type,
+        Algorithm:   key.Algorithm,
+        KeySize:     key.KeySize,
+        Role:        key.Role,
+        ParentKeyID: key.ParentKeyID,
+        Status:      key.Status,
+        ExpiresAt:   key.ExpiresAt,
+    })
+}
+
+// GrantKeyAccess надає доступ до ключа певному користувачу без права подальшої передачі
+func (s *SmartContract) GrantKeyAccess(ctx contractapi.TransactionContextInterface, keyID string, userID string, accessType string, expirationDays int) error {
+    if err := grantKeyAccess(ctx, keyID, userID, accessType, expirationDays, 0); err != nil {
+        return err
+    }
+    return recordKeyAudit(ctx, auditActionGrant, keyID, auditResultSuccess, map[string]string{"userId": userID, "accessType": accessType})
+}
+
+// GrantDelegableKeyAccess надає доступ, який користувач може передавати далі на delegationDepth рівнів
+func (s *SmartContract) GrantDelegableKeyAccess(ctx contractapi.TransactionContextInterface, keyID string, userID string, accessType string, expirationDays int, delegationDepth int) error {
+    if delegationDepth < 0 || delegationDepth > maxDelegationDepth {
+        return invalidArgument("delegationDepth", "глибина передачі має бути від 0 до %d", maxDelegationDepth)
+    }
+    if err := grantKeyAccess(ctx, keyID, userID, accessType, expirationDays, delegationDepth); err != nil {
+        return err
+    }
+    return recordKeyAudit(ctx, auditActionGrant, keyID, auditResultSuccess, map[string]string{
+        "userId":          userID,
+        "accessType":      accessType,
+        "delegationDepth": strconv.Itoa(delegationDepth),
+    })
 }
 
-// GrantKeyAccess надає доступ до ключа певному користувачу
-func (s *SmartContract) GrantKeyAccess(ctx contractapi.TransactionContextInterface, keyID string, userID string, accessType string, grantedBy string, expirationDays int) error {
+// grantKeyAccess надає доступ від імені власника ключа або користувача з правом передачі доступу
+func grantKeyAccess(ctx contractapi.TransactionContextInterface, keyID string, userID string, accessType string, expirationDays int, delegationDepth int) error {
+    // Строк дії доступу обмежений так само, як строк дії ключа
+    if err := validateLifetime(expirationDays); err != nil {
+        return err
+    }
+    
     // Отримання даних ключа
     keyJSON, err := ctx.GetStub().GetState(keyPrefix + keyID)
     if err != nil {
@@ -107,40 +263,377 @@ func (s *SmartContract) GrantKeyAccess(ctx contractapi.TransactionContextInterfa
         return fmt.Errorf("помилка десеріалізації даних ключа: %v", err)
     }
     
-    // Перевірка статусу ключа
-    if key.Status != "active" {
+    if !containsString(accessTypes, accessType) {
+        return fmt.Errorf("невідомий тип доступу: %s", accessType)
+    }
+    
+    // Перевірка статусу та строку дії ключа за часом транзакції
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return err
+    }
+    if effectiveKeyStatus(&key, now) != statusActive {
         return fmt.Errorf("ключ %s не активний", keyID)
     }
     
-    // Встановлюємо час дії
-    now := time.Now().Unix()
-    expiresAt := now + int64(expirationDays*24*60*60)
+    // Доступ не може пережити сам ключ
+    expiresAt := clock.AfterDays(now, expirationDays)
+    if key.ExpiresAt > 0 && expiresAt > key.ExpiresAt {
+        expiresAt = key.ExpiresAt
+    }
+    
+    // Надавати доступ може власник ключа або користувач з правом передачі доступу
+    grantedBy, err := callerIdentity(ctx)
+    if err != nil {
+        return err
+    }
+    existing, err := readAccess(ctx, keyID, userID)
+    if err != nil {
+        return err
+    }
+    var quota int64
+    if !isKeyOwner(&key, grantedBy) {
+        grantor, err := authorizeDelegation(ctx, &key, grantedBy, userID, accessType, delegationDepth, now)
+        if err != nil {
+            return err
+        }
+        // Користувач з правом передачі не може замінити чужий доступ, зокрема ширший доступ
+        // від власника чи доступ того, хто передав йому право
+        if existing != nil && existing.GrantedBy != grantedBy {
+            return &ValidationError{Kind: ErrAlreadyExists, Field: "userID", Message: fmt.Sprintf("доступ користувача %s до ключа %s надав %s", userID, keyID, existing.GrantedBy)}
+        }
+        // Похідний доступ не може пережити доступ, з якого він переданий
+        if expiresAt > grantor.ExpiresAt {
+            expiresAt = grantor.ExpiresAt
+        }
+        // і не може мати більший ліміт, ніж невикористаний залишок ліміту того, хто передає доступ
+        if grantor.UsageQuota > 0 {
+            quota = grantor.UsageQuota - grantor.UsageCount
+            if quota <= 0 {
+                return &ValidationError{Kind: ErrQuotaExceeded, Field: "userID", Message: fmt.Sprintf("клієнт %s вичерпав ліміт %d використань ключа %s і не може передати доступ", grantedBy, grantor.UsageQuota, keyID)}
+            }
+        }
+    }
     
     // Створюємо запис доступу
     access := KeyAccess{
+        KeyID:           keyID,
+        UserID:          userID,
+        AccessType:      accessType,
+        GrantedAt:       now,
+        ExpiresAt:       expiresAt,
+        GrantedBy:       grantedBy,
+        UsageQuota:      quota,
+        CanDelegate:     delegationDepth > 0,
+        DelegationDepth: delegationDepth,
+    }
+    
+    // Повторне надання доступу не скидає лічильник і не знімає встановлений ліміт
+    if existing != nil {
+        access.UsageCount = existing.UsageCount
+        if existing.UsageQuota > 0 && (access.UsageQuota == 0 || existing.UsageQuota < access.UsageQuota) {
+            access.UsageQuota = existing.UsageQuota
+        }
+    }
+    
+    // Зберігаємо в state database
+    if err := putAccess(ctx, &access); err != nil {
+        return err
+    }
+    return emitKeyEvent(ctx, KeyEvent{
+        Type:       eventKeyAccessGranted,
         KeyID:      keyID,
         UserID:     userID,
         AccessType: accessType,
-        GrantedAt:  now,
-        ExpiresAt:  expiresAt,
         GrantedBy:  grantedBy,
+        ExpiresAt:  expiresAt,
+    })
+}
+
+// RevokeKeyAccess відкликає доступ користувача до ключа
+func (s *SmartContract) RevokeKeyAccess(ctx contractapi.TransactionContextInterface, keyID string, userID string) error {
+    // Перевіряємо, що доступ існує
+    access, err := readAccess(ctx, keyID, userID)
+    if err != nil {
+        return err
+    }
+    if access == nil {
+        return fmt.Errorf("доступ користувача %s до ключа %s не існує", userID, keyID)
+    }
+    
+    // Відкликати доступ може власник ключа, користувач з повним доступом або той, хто його надав
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return err
+    }
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return err
+    }
+    if access.GrantedBy != caller {
+        if err := authorizeKeyManager(ctx, key, caller); err != nil {
+            return err
+        }
+    }
+    
+    // Разом з доступом відкликаються всі доступи, передані з нього
+    if err := revokeAccessCascade(ctx, key, userID); err != nil {
+        return err
+    }
+    return recordKeyAudit(ctx, auditActionRevokeAccess, keyID, auditResultSuccess, map[string]string{"userId": userID})
+}
+
+// RotateKey замінює активний ключ новим і повертає ідентифікатор нового ключа
+func (s *SmartContract) RotateKey(ctx contractapi.TransactionContextInterface, keyID string) (string, error) {
+    oldKey, err := readKey(ctx, keyID)
+    if err != nil {
+        return "", err
+    }
+    
+    // Ротацію може виконати лише власник ключа
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return "", err
+    }
+    if err := authorizeKeyOwner(oldKey, caller); err != nil {
+        return "", err
+    }
+    if err := requireSingleApproval(oldKey); err != nil {
+        return "", err
+    }
+    
+    return rotateKey(ctx, oldKey)
+}
+
+// rotateKey створює нову версію активного ключа, деактивує стару і фіксує ротацію в аудиті.
+// Використовується прямою, плановою і схваленою кворумом ротацією.
+func rotateKey(ctx contractapi.TransactionContextInterface, oldKey *CryptoKey) (string, error) {
+    keyID := oldKey.ID
+    
+    // Новий ключ отримує ідентифікатор на основі старого
+    txID := ctx.GetStub().GetTxID()
+    newKeyID := fmt.Sprintf("%s-%s", keyID, shortTxID(txID))
+    
+    // Ротувати можна лише ключ, активний на час транзакції
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return "", err
+    }
+    if effectiveKeyStatus(oldKey, now) != statusActive {
+        return "", fmt.Errorf("ключ %s не активний", keyID)
+    }
+    
+    // Нова версія не може перезаписати наявний ключ
+    exists, err := keyExists(ctx, newKeyID)
+    if err != nil {
+        return "", err
+    }
+    if exists {
+        return "", &ValidationError{Kind: ErrAlreadyExists, Field: "id", Message: fmt.Sprintf("ключ %s вже існує", newKeyID)}
+    }
+    
+    // Новий ключ діє стільки ж, скільки діяв старий
+    lifetime := oldKey.ExpiresAt - oldKey.ActivatedAt
+    
+    newKey := CryptoKey{
+        ID:                newKeyID,
+        Type:              oldKey.Type,
+        Algorithm:         oldKey.Algorithm,
+        KeySize:           oldKey.KeySize,
+        Status:            statusActive,
+        OwnerIDs:          oldKey.OwnerIDs,
+        ApprovalThreshold: oldKey.ApprovalThreshold,
+        RotationPolicy:    oldKey.RotationPolicy,
+        Role:              oldKey.Role,
+        ParentKeyID:       oldKey.ParentKeyID,
+        CreatedAt:         now,
+        ActivatedAt:       now,
+        ExpiresAt:         now + lifetime,
+        RevokedAt:         0,
+        PreviousKeyID:     keyID, // метадані описують ключовий матеріал і задаються для нової версії окремо
+    }
+    
+    // Старий ключ деактивується: ним можна лише розшифрувати наявні дані
+    if err := transitionKey(ctx, oldKey, statusDeactivated, "", now); err != nil {
+        return "", err
+    }
+    oldKey.ReplacedBy = newKeyID
+    
+    if err := putKey(ctx, oldKey); err != nil {
+        return "", err
+    }
+    if err := putKey(ctx, &newKey); err != nil {
+        return "", err
+    }
+    if err := indexKeyOwners(ctx, &newKey); err != nil {
+        return "", err
+    }
+    
+    // Нова версія ключа ієрархії залишається під тим самим батьківським ключем
+    if oldKey.ParentKeyID != "" {
+        parent, err := readKey(ctx, oldKey.ParentKeyID)
+        if err != nil {
+            return "", err
+        }
+        if err := attachChildKey(ctx, parent, &newKey); err != nil {
+            return "", err
+        }
+    }
+    
+    // Переносимо чинні доступи на новий ключ
+    if err := copyActiveAccess(ctx, keyID, newKeyID, now); err != nil {
+        return "", err
+    }
+    
+    if err := emitKeyEvent(ctx, KeyEvent{Type: eventKeyRotated, KeyID: keyID, NewKeyID: newKeyID, ExpiresAt: newKey.ExpiresAt}); err != nil {
+        return "", err
+    }
+    if err := recordKeyAudit(ctx, auditActionRotate, keyID, auditResultSuccess, map[string]string{"newKeyId": newKeyID}); err != nil {
+        return "", err
+    }
+    return newKeyID, nil
+}
+
+// GetRotationChain повертає всі версії ключа від найстарішої до найновішої
+func (s *SmartContract) GetRotationChain(ctx contractapi.TransactionContextInterface, keyID string) ([]*CryptoKey, error) {
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return nil, err
+    }
+    
+    // Спочатку знаходимо першу версію ключа
+    first := key
+    for i := 0; first.PreviousKeyID != ""; i++ {
+        if i >= maxRotationChain {
+            return nil, fmt.Errorf("ланцюжок ротацій ключа %s задовгий", keyID)
+        }
+        first, err = readKey(ctx, first.PreviousKeyID)
+        if err != nil {
+            return nil, err
+        }
+    }
+    
+    // Потім проходимо ланцюжок до актуальної версії
+    chain := []*CryptoKey{first}
+    for current := first; current.ReplacedBy != ""; {
+        if len(chain) >= maxRotationChain {
+            return nil, fmt.Errorf("ланцюжок ротацій ключа %s задовгий", keyID)
+        }
+        current, err = readKey(ctx, current.ReplacedBy)
+        if err != nil {
+            return nil, err
+        }
+        chain = append(chain, current)
+    }
+    
+    return chain, nil
+}
+
+// readKey читає ключ з world state
+func readKey(ctx contractapi.TransactionContextInterface, keyID string) (*CryptoKey, error) {
+    keyJSON, err := ctx.GetStub().GetState(keyPrefix + keyID)
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання ключа: %v", err)
+    }
+    if keyJSON == nil {
+        return nil, fmt.Errorf("ключ %s не існує", keyID)
+    }
+    
+    var key CryptoKey
+    err = json.Unmarshal(keyJSON, &key)
+    if err != nil {
+        return nil, fmt.Errorf("помилка десеріалізації даних ключа: %v", err)
+    }
+    
+    return &key, nil
+}
+
+// readAccess читає доступ користувача до ключа; повертає nil, якщо доступу немає
+func readAccess(ctx contractapi.TransactionContextInterface, keyID string, userID string) (*KeyAccess, error) {
+    accessKey, err := accessStateKey(ctx, keyID, userID)
+    if err != nil {
+        return nil, err
     }
     
-    // Серіалізуємо доступ
-    accessJSON, err := json.Marshal(access)
+    accessJSON, err := ctx.GetStub().GetState(accessKey)
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання доступу: %v", err)
+    }
+    if accessJSON == nil {
+        return nil, nil
+    }
+    
+    var access KeyAccess
+    err = json.Unmarshal(accessJSON, &access)
+    if err != nil {
+        return nil, fmt.Errorf("помилка десеріалізації доступу: %v", err)
+    }
+    
+    return &access, nil
+}
+
+// putKey зберігає ключ у world state
+func putKey(ctx contractapi.TransactionContextInterface, key *CryptoKey) error {
+    keyJSON, err := json.Marshal(key)
     if err != nil {
         return err
     }
     
-    // Створюємо складений ключ для доступу
-    accessKey := fmt.Sprintf("%s%s-%s", accessPrefix, keyID, userID)
+    return ctx.GetStub().PutState(keyPrefix+key.ID, keyJSON)
+}
+
+// copyActiveAccess копіює всі непрострочені доступи з одного ключа на інший
+func copyActiveAccess(ctx contractapi.TransactionContextInterface, fromKeyID string, toKeyID string, now int64) error {
+    iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(accessObjectType, []string{fromKeyID})
+    if err != nil {
+        return fmt.Errorf("помилка читання доступів: %v", err)
+    }
+    defer iterator.Close()
+    
+    for iterator.HasNext() {
+        kv, err := iterator.Next()
+        if err != nil {
+            return fmt.Errorf("помилка читання доступу: %v", err)
+        }
+        
+        var access KeyAccess
+        if err := json.Unmarshal(kv.Value, &access); err != nil {
+            return fmt.Errorf("помилка десеріалізації доступу %s: %v", kv.Key, err)
+        }
+        
+        if isAccessExpired(&access, now) {
+            continue
+        }
+        
+        // Ліміт і лічильник використань переносяться, щоб ротація не поновлювала ліміт користувача
+        access.KeyID = toKeyID
+        if err := putAccess(ctx, &access); err != nil {
+            return err
+        }
+    }
     
-    // Зберігаємо в state database
-    return ctx.GetStub().PutState(accessKey, accessJSON)
+    return nil
+}
+
+// generatedKeyID повертає ідентифікатор ключа, який створює транзакція з клієнтського ідентифікатора id
+func generatedKeyID(ctx contractapi.TransactionContextInterface, id string) string {
+    return fmt.Sprintf("%s-%s", id, shortTxID(ctx.GetStub().GetTxID()))
+}
+
+// shortTxID повертає короткий суфікс ідентифікатора транзакції для ідентифікаторів ключів
+func shortTxID(txID string) string {
+    if len(txID) > 8 {
+        return txID[:8]
+    }
+    return txID
 }
 
 func main() {
-    chaincode, err := contractapi.NewChaincode(new(SmartContract))
+    // Власний контекст транзакції накопичує події змін стану ключів
+    contract := new(SmartContract)
+    contract.TransactionContextHandler = new(KeyManagementContext)
+    
+    chaincode, err := contractapi.NewChaincode(contract)
     if err != nil {
         fmt.Printf("Помилка створення чейнкоду: %s", err.Error())
         return
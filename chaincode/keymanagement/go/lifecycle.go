@@ -0,0 +1,220 @@
+package main
+
+import (
+    "fmt"
+
+    "github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+    "blockchain-security/chaincode/common/clock"
+)
+
+// Стани життєвого циклу ключа за NIST SP 800-57
+const (
+    statusPreActivation = "pre-activation"
+    statusActive        = "active"
+    statusSuspended     = "suspended"
+    statusDeactivated   = "deactivated"
+    statusCompromised   = "compromised"
+    statusDestroyed     = "destroyed"
+)
+
+// keyTransitions визначає дозволені переходи між станами ключа
+var keyTransitions = map[string][]string{
+    statusPreActivation: {statusActive, statusCompromised, statusDestroyed},
+    statusActive:        {statusSuspended, statusDeactivated, statusCompromised},
+    statusSuspended:     {statusActive, statusDeactivated, statusCompromised},
+    statusDeactivated:   {statusCompromised, statusDestroyed},
+    statusCompromised:   {statusDestroyed},
+    statusDestroyed:     {},
+}
+
+// Причини відкликання ключа (коди RFC 5280)
+const (
+    reasonUnspecified          = "unspecified"
+    reasonKeyCompromise        = "keyCompromise"
+    reasonCACompromise         = "cACompromise"
+    reasonAffiliationChanged   = "affiliationChanged"
+    reasonSuperseded           = "superseded"
+    reasonCessationOfOperation = "cessationOfOperation"
+    reasonPrivilegeWithdrawn   = "privilegeWithdrawn"
+)
+
+// revocationReasons перелік допустимих причин відкликання
+var revocationReasons = []string{
+    reasonUnspecified,
+    reasonKeyCompromise,
+    reasonCACompromise,
+    reasonAffiliationChanged,
+    reasonSuperseded,
+    reasonCessationOfOperation,
+    reasonPrivilegeWithdrawn,
+}
+
+// ActivateKey переводить ключ зі стану pre-activation в active
+func (s *SmartContract) ActivateKey(ctx contractapi.TransactionContextInterface, keyID string) error {
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return err
+    }
+    if key.Status != statusPreActivation {
+        return fmt.Errorf("ключ %s вже активовано", keyID)
+    }
+    return s.changeKeyStatus(ctx, keyID, statusActive, "")
+}
+
+// SuspendKey тимчасово призупиняє використання активного ключа
+func (s *SmartContract) SuspendKey(ctx contractapi.TransactionContextInterface, keyID string) error {
+    return s.changeKeyStatus(ctx, keyID, statusSuspended, "")
+}
+
+// ReactivateKey відновлює використання призупиненого ключа
+func (s *SmartContract) ReactivateKey(ctx contractapi.TransactionContextInterface, keyID string) error {
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return err
+    }
+    if key.Status != statusSuspended {
+        return fmt.Errorf("ключ %s не призупинений", keyID)
+    }
+    return s.changeKeyStatus(ctx, keyID, statusActive, "")
+}
+
+// RevokeKey відкликає ключ із вказаною причиною; при компрометації ключ позначається як compromised
+func (s *SmartContract) RevokeKey(ctx contractapi.TransactionContextInterface, keyID string, reasonCode string) error {
+    if !containsString(revocationReasons, reasonCode) {
+        return fmt.Errorf("невідома причина відкликання: %s", reasonCode)
+    }
+
+    target := statusDeactivated
+    if reasonCode == reasonKeyCompromise || reasonCode == reasonCACompromise {
+        target = statusCompromised
+    }
+
+    return s.changeKeyStatus(ctx, keyID, target, reasonCode)
+}
+
+// MarkCompromised позначає ключ як скомпрометований
+func (s *SmartContract) MarkCompromised(ctx contractapi.TransactionContextInterface, keyID string) error {
+    return s.changeKeyStatus(ctx, keyID, statusCompromised, reasonKeyCompromise)
+}
+
+// DestroyKey фіксує знищення ключового матеріалу; запис ключа зберігається для аудиту
+func (s *SmartContract) DestroyKey(ctx contractapi.TransactionContextInterface, keyID string) error {
+    return s.changeKeyStatus(ctx, keyID, statusDestroyed, "")
+}
+
+// changeKeyStatus виконує перехід стану ключа від імені його власника.
+// Призупинення доступне кожному власнику як екстрений захід до схвалення відкликання.
+func (s *SmartContract) changeKeyStatus(ctx contractapi.TransactionContextInterface, keyID string, target string, reasonCode string) error {
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return err
+    }
+
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return err
+    }
+    if err := authorizeKeyOwner(key, caller); err != nil {
+        return err
+    }
+
+    // Незворотні переходи ключа зі спільним володінням виконуються лише через кворум
+    if isDestructiveStatus(target) {
+        if err := requireSingleApproval(key); err != nil {
+            return err
+        }
+    }
+
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return err
+    }
+
+    // Ключ ієрархії можна використовувати лише поки активний його батьківський ключ
+    if target == statusActive && key.ParentKeyID != "" {
+        parent, err := readKey(ctx, key.ParentKeyID)
+        if err != nil {
+            return err
+        }
+        if effectiveKeyStatus(parent, now) != statusActive {
+            return fmt.Errorf("батьківський ключ %s не активний", key.ParentKeyID)
+        }
+    }
+
+    return applyKeyStatus(ctx, key, target, reasonCode, now)
+}
+
+// applyKeyStatus виконує перехід стану ключа, зберігає його і фіксує перехід в аудиті
+// разом зі зміненими нащадками.
+// Компрометація та відкликання поширюються на нащадків ключа в тій самій транзакції;
+// якщо нащадків більше за maxCascadeBatch, решту обробляє PropagateKeyStatus.
+func applyKeyStatus(ctx contractapi.TransactionContextInterface, key *CryptoKey, target string, reasonCode string, now int64) error {
+    if err := transitionKey(ctx, key, target, reasonCode, now); err != nil {
+        return err
+    }
+
+    var cascaded []string
+    if _, _, cascade := cascadeTarget(key); cascade && key.ChildKeyCount > 0 {
+        updated, complete, err := cascadeKeyStatus(ctx, key, now, maxCascadeBatch)
+        if err != nil {
+            return err
+        }
+        cascaded = updated
+        key.CascadePending = !complete
+    }
+
+    if err := putKey(ctx, key); err != nil {
+        return err
+    }
+    metadata := cascadeAuditMetadata(target, reasonCode, cascaded)
+    return recordKeyAudit(ctx, statusAuditAction(target, reasonCode), key.ID, auditResultSuccess, metadata)
+}
+
+// statusAuditAction повертає дію аудиту для переходу ключа в стан target
+func statusAuditAction(target string, reasonCode string) string {
+    switch {
+    case target == statusDestroyed:
+        return auditActionDestroy
+    case reasonCode != "":
+        return auditActionRevoke
+    }
+    return auditActionStatus
+}
+
+// isDestructiveStatus перевіряє, чи є перехід у стан незворотним виведенням ключа з обігу
+func isDestructiveStatus(status string) bool {
+    return status == statusDeactivated || status == statusCompromised || status == statusDestroyed
+}
+
+// transitionKey перевіряє допустимість переходу, оновлює часові мітки ключа і публікує подію KeyStatusChanged
+func transitionKey(ctx contractapi.TransactionContextInterface, key *CryptoKey, target string, reasonCode string, now int64) error {
+    allowed, known := keyTransitions[key.Status]
+    if !known {
+        return fmt.Errorf("ключ %s має невідомий стан %s", key.ID, key.Status)
+    }
+    if !containsString(allowed, target) {
+        return fmt.Errorf("недопустимий перехід ключа %s зі стану %s у стан %s", key.ID, key.Status, target)
+    }
+
+    switch target {
+    case statusActive:
+        // Повторна активація після призупинення зберігає першу дату активації
+        if key.ActivatedAt == 0 {
+            key.ActivatedAt = now
+        }
+    case statusCompromised:
+        key.CompromisedAt = now
+    case statusDestroyed:
+        key.DestroyedAt = now
+    }
+
+    if reasonCode != "" {
+        key.RevokedAt = now
+        key.RevocationReason = reasonCode
+    }
+
+    previous := key.Status
+    key.Status = target
+    return emitKeyEvent(ctx, KeyEvent{Type: eventKeyStatusChanged, KeyID: key.ID, PreviousStatus: previous, Status: target, Reason: reasonCode})
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+
+    "github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Ключ transient map, у якому клієнт передає приватні метадані ключа
+const transientMetadataKey = "metadata"
+
+// Суфікс назви колекції приватних даних організації (див. collections_config.json)
+const metadataCollectionSuffix = "KeyMetadata"
+
+// KeyMetadata чутливі метадані ключа, що зберігаються лише в колекції приватних даних
+type KeyMetadata struct {
+    Custodian  string `json:"custodian"`
+    HSMSlot    string `json:"hsmSlot,omitempty"`
+    WrappedKey string `json:"wrappedKey,omitempty"` // загорнутий ключовий матеріал, base64
+}
+
+// SetKeyMetadata зберігає приватні метадані з transient map у колекції організації клієнта
+func (s *SmartContract) SetKeyMetadata(ctx contractapi.TransactionContextInterface, keyID string) error {
+    metadataJSON, err := transientMetadata(ctx)
+    if err != nil {
+        return err
+    }
+    if metadataJSON == nil {
+        return invalidArgument(transientMetadataKey, "transient map не містить метаданих")
+    }
+
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return err
+    }
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return err
+    }
+    if err := authorizeKeyOwner(key, caller); err != nil {
+        return err
+    }
+
+    if err := storeKeyMetadata(ctx, key, metadataJSON); err != nil {
+        return err
+    }
+    return putKey(ctx, key)
+}
+
+// GetKeyMetadata повертає приватні метадані ключа власнику або користувачу з повним доступом.
+// Виконується лише на пірах організації, що входить до колекції.
+func (s *SmartContract) GetKeyMetadata(ctx contractapi.TransactionContextInterface, keyID string) (*KeyMetadata, error) {
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return nil, err
+    }
+    if key.MetadataCollection == "" {
+        return nil, fmt.Errorf("ключ %s не має приватних метаданих", keyID)
+    }
+
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return nil, err
+    }
+    if err := authorizeKeyManager(ctx, key, caller); err != nil {
+        return nil, err
+    }
+
+    metadataJSON, err := ctx.GetStub().GetPrivateData(key.MetadataCollection, keyPrefix+keyID)
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання приватних метаданих: %v", err)
+    }
+    if metadataJSON == nil {
+        return nil, fmt.Errorf("приватні метадані ключа %s недоступні на цьому пірі", keyID)
+    }
+    if metadataHash(metadataJSON) != key.Metadata {
+        return nil, fmt.Errorf("хеш приватних метаданих ключа %s не збігається з публічним записом", keyID)
+    }
+
+    var metadata KeyMetadata
+    if err := json.Unmarshal(metadataJSON, &metadata); err != nil {
+        return nil, fmt.Errorf("помилка десеріалізації приватних метаданих: %v", err)
+    }
+    return &metadata, nil
+}
+
+// VerifyKeyMetadata перевіряє хеш приватних метаданих за GetPrivateDataHash.
+// Якщо transient map містить метадані, перевіряється також їхня відповідність запису.
+// Доступна будь-якому учаснику каналу, зокрема організаціям поза колекцією.
+func (s *SmartContract) VerifyKeyMetadata(ctx contractapi.TransactionContextInterface, keyID string) (bool, error) {
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return false, err
+    }
+    if key.MetadataCollection == "" {
+        return false, nil
+    }
+
+    onChainHash, err := ctx.GetStub().GetPrivateDataHash(key.MetadataCollection, keyPrefix+keyID)
+    if err != nil {
+        return false, fmt.Errorf("помилка читання хешу приватних метаданих: %v", err)
+    }
+    if onChainHash == nil || hex.EncodeToString(onChainHash) != key.Metadata {
+        return false, nil
+    }
+
+    candidate, err := transientMetadata(ctx)
+    if err != nil {
+        return false, err
+    }
+    if candidate != nil {
+        candidateHash := sha256.Sum256(candidate)
+        return bytes.Equal(candidateHash[:], onChainHash), nil
+    }
+
+    return true, nil
+}
+
+// storeKeyMetadata записує приватні метадані в колекцію організації клієнта
+// та фіксує їхній хеш у публічному записі ключа
+func storeKeyMetadata(ctx contractapi.TransactionContextInterface, key *CryptoKey, metadataJSON []byte) error {
+    var metadata KeyMetadata
+    if err := json.Unmarshal(metadataJSON, &metadata); err != nil {
+        return invalidArgument(transientMetadataKey, "помилка розбору метаданих: %v", err)
+    }
+    if metadata.Custodian == "" {
+        return invalidArgument(transientMetadataKey, "не вказано відповідального за ключ")
+    }
+
+    mspID, err := ctx.GetClientIdentity().GetMSPID()
+    if err != nil {
+        return fmt.Errorf("помилка отримання MSP ID клієнта: %v", err)
+    }
+    collection := metadataCollection(mspID)
+
+    // Співвласник з іншої організації переносить метадані у свою колекцію;
+    // копію в попередній колекції видаляємо, щоб вона не застаріла
+    if key.MetadataCollection != "" && key.MetadataCollection != collection {
+        if err := ctx.GetStub().DelPrivateData(key.MetadataCollection, keyPrefix+key.ID); err != nil {
+            return fmt.Errorf("помилка видалення приватних метаданих з колекції %s: %v", key.MetadataCollection, err)
+        }
+    }
+
+    if err := ctx.GetStub().PutPrivateData(collection, keyPrefix+key.ID, metadataJSON); err != nil {
+        return fmt.Errorf("помилка запису приватних метаданих: %v", err)
+    }
+
+    key.Metadata = metadataHash(metadataJSON)
+    key.MetadataCollection = collection
+    return nil
+}
+
+// transientMetadata повертає метадані з transient map або nil, якщо їх не передано
+func transientMetadata(ctx contractapi.TransactionContextInterface) ([]byte, error) {
+    transient, err := ctx.GetStub().GetTransient()
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання transient map: %v", err)
+    }
+    return transient[transientMetadataKey], nil
+}
+
+// metadataCollection повертає назву колекції приватних даних організації
+func metadataCollection(mspID string) string {
+    return mspID + metadataCollectionSuffix
+}
+
+// metadataHash обчислює хеш так само, як пір для GetPrivateDataHash
+func metadataHash(metadataJSON []byte) string {
+    hash := sha256.Sum256(metadataJSON)
+    return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "strconv"
+
+    "github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+    "blockchain-security/chaincode/common/clock"
+)
+
+// Префікс для пропозицій операцій з ключами у world state
+const proposalPrefix = "keyproposal:"
+
+// Строк дії пропозиції в днях
+const proposalLifetimeDays = 7
+
+// Операції з ключем, що потребують кворуму власників
+const (
+    proposalRevoke    = "revoke"    // параметр - код причини за RFC 5280
+    proposalDestroy   = "destroy"
+    proposalRotate    = "rotate"
+    proposalThreshold = "threshold" // параметр - новий кворум M
+    proposalOwners    = "owners"    // параметр - JSON масив нових власників
+    proposalPolicy    = "policy"    // параметр - JSON політики ротації або порожній рядок
+)
+
+// Зміна налаштувань аудиту; параметр - JSON налаштувань, голосують організації
+const proposalAuditConfig = "audit-config"
+
+// Стани пропозиції
+const (
+    proposalPending  = "pending"
+    proposalExecuted = "executed"
+    proposalExpired  = "expired"
+)
+
+// ProposalVote голос власника ключа або, для налаштувань аудиту, організації за пропозицію
+type ProposalVote struct {
+    Voter   string `json:"voter"`
+    VotedAt int64  `json:"votedAt"`
+    TxID    string `json:"txId"`
+}
+
+// KeyOperationProposal пропозиція незворотної операції з ключем, що очікує кворуму
+type KeyOperationProposal struct {
+    ID         string         `json:"id"`
+    KeyID      string         `json:"keyId"`     // порожній для пропозиції налаштувань аудиту
+    Operation  string         `json:"operation"` // revoke, destroy, rotate, threshold, owners, policy, audit-config
+    Parameter  string         `json:"parameter,omitempty"`
+    Threshold  int            `json:"threshold"` // кворум ключа на момент останнього голосу
+    Status     string         `json:"status"`    // pending, executed, expired
+    ProposedBy string         `json:"proposedBy"`
+    CreatedAt  int64          `json:"createdAt"`
+    ExpiresAt  int64          `json:"expiresAt"`
+    ExecutedAt int64          `json:"executedAt,omitempty"`
+    Result     string         `json:"result,omitempty"` // ідентифікатор нового ключа після ротації
+    Votes      []ProposalVote `json:"votes"`
+}
+
+// ProposeKeyOperation створює пропозицію операції з ключем; голос ініціатора зараховується одразу
+func (s *SmartContract) ProposeKeyOperation(ctx contractapi.TransactionContextInterface, keyID string, operation string, parameter string) (*KeyOperationProposal, error) {
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return nil, err
+    }
+    if err := validateProposal(key, operation, parameter); err != nil {
+        return nil, err
+    }
+
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return nil, err
+    }
+    if err := authorizeKeyOwner(key, caller); err != nil {
+        return nil, err
+    }
+
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return nil, err
+    }
+
+    proposal := &KeyOperationProposal{
+        ID:         ctx.GetStub().GetTxID(),
+        KeyID:      keyID,
+        Operation:  operation,
+        Parameter:  parameter,
+        Threshold:  approvalThreshold(key),
+        Status:     proposalPending,
+        ProposedBy: caller,
+        CreatedAt:  now,
+        ExpiresAt:  clock.AfterDays(now, proposalLifetimeDays),
+        Votes:      []ProposalVote{},
+    }
+
+    return recordVote(ctx, proposal, key, caller, now)
+}
+
+// ApproveKeyOperation додає голос власника; при досягненні кворуму операція виконується
+func (s *SmartContract) ApproveKeyOperation(ctx contractapi.TransactionContextInterface, proposalID string) (*KeyOperationProposal, error) {
+    proposal, err := readProposal(ctx, proposalID)
+    if err != nil {
+        return nil, err
+    }
+
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return nil, err
+    }
+    if status := effectiveProposalStatus(proposal, now); status != proposalPending {
+        return nil, fmt.Errorf("пропозиція %s має стан %s", proposalID, status)
+    }
+    if proposal.Operation == proposalAuditConfig {
+        return nil, fmt.Errorf("пропозицію %s схвалюють адміністратори організацій через ApproveAuditConfig", proposalID)
+    }
+
+    key, err := readKey(ctx, proposal.KeyID)
+    if err != nil {
+        return nil, err
+    }
+
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return nil, err
+    }
+    if err := authorizeKeyOwner(key, caller); err != nil {
+        return nil, err
+    }
+    for _, vote := range proposal.Votes {
+        if vote.Voter == caller {
+            return nil, fmt.Errorf("власник %s вже проголосував за пропозицію %s", caller, proposalID)
+        }
+    }
+
+    return recordVote(ctx, proposal, key, caller, now)
+}
+
+// GetKeyOperationProposal повертає пропозицію з урахуванням закінчення її строку
+func (s *SmartContract) GetKeyOperationProposal(ctx contractapi.TransactionContextInterface, proposalID string) (*KeyOperationProposal, error) {
+    proposal, err := readProposal(ctx, proposalID)
+    if err != nil {
+        return nil, err
+    }
+
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return nil, err
+    }
+    proposal.Status = effectiveProposalStatus(proposal, now)
+
+    return proposal, nil
+}
+
+// recordVote зараховує голос і виконує операцію, якщо кворум досягнуто.
+// Власники і кворум могли змінитися після створення пропозиції, тому враховуються
+// лише голоси чинних власників, а кворум береться з поточного запису ключа.
+func recordVote(ctx contractapi.TransactionContextInterface, proposal *KeyOperationProposal, key *CryptoKey, voter string, now int64) (*KeyOperationProposal, error) {
+    proposal.Votes = append(proposal.Votes, ProposalVote{
+        Voter:   voter,
+        VotedAt: now,
+        TxID:    ctx.GetStub().GetTxID(),
+    })
+
+    proposal.Threshold = approvalThreshold(key)
+    if countOwnerVotes(proposal, key) >= proposal.Threshold {
+        result, err := executeProposal(ctx, proposal, key, now)
+        if err != nil {
+            return nil, err
+        }
+        proposal.Status = proposalExecuted
+        proposal.ExecutedAt = now
+        proposal.Result = result
+    }
+
+    if err := putProposal(ctx, proposal); err != nil {
+        return nil, err
+    }
+    return proposal, nil
+}
+
+// countOwnerVotes повертає кількість голосів за пропозицію від чинних власників ключа
+func countOwnerVotes(proposal *KeyOperationProposal, key *CryptoKey) int {
+    count := 0
+    for _, vote := range proposal.Votes {
+        if isKeyOwner(key, vote.Voter) {
+            count++
+        }
+    }
+    return count
+}
+
+// executeProposal виконує схвалену операцію з ключем
+func executeProposal(ctx contractapi.TransactionContextInterface, proposal *KeyOperationProposal, key *CryptoKey, now int64) (string, error) {
+    switch proposal.Operation {
+    case proposalRevoke:
+        target := statusDeactivated
+        if proposal.Parameter == reasonKeyCompromise || proposal.Parameter == reasonCACompromise {
+            target = statusCompromised
+        }
+        return "", applyKeyStatus(ctx, key, target, proposal.Parameter, now)
+    case proposalDestroy:
+        return "", applyKeyStatus(ctx, key, statusDestroyed, "", now)
+    case proposalRotate:
+        return rotateKey(ctx, key)
+    case proposalThreshold:
+        threshold, _ := strconv.Atoi(proposal.Parameter)
+        key.ApprovalThreshold = threshold
+        return "", putKey(ctx, key)
+    case proposalOwners:
+        var owners []string
+        if err := json.Unmarshal([]byte(proposal.Parameter), &owners); err != nil {
+            return "", fmt.Errorf("помилка при розборі власників: %v", err)
+        }
+        return "", changeKeyOwners(ctx, key, owners, now)
+    case proposalPolicy:
+        policy, err := parseRotationPolicy(proposal.Parameter)
+        if err != nil {
+            return "", err
+        }
+        key.RotationPolicy = policy
+        return "", putKey(ctx, key)
+    default:
+        return "", fmt.Errorf("невідома операція пропозиції: %s", proposal.Operation)
+    }
+}
+
+// validateProposal перевіряє операцію та її параметр до створення пропозиції
+func validateProposal(key *CryptoKey, operation string, parameter string) error {
+    switch operation {
+    case proposalRevoke:
+        if !containsString(revocationReasons, parameter) {
+            return invalidArgument("parameter", "невідома причина відкликання: %s", parameter)
+        }
+    case proposalDestroy, proposalRotate:
+        if parameter != "" {
+            return invalidArgument("parameter", "операція %s не має параметрів", operation)
+        }
+    case proposalThreshold:
+        threshold, err := strconv.Atoi(parameter)
+        if err != nil || threshold < 1 || threshold > len(key.OwnerIDs) {
+            return invalidArgument("parameter", "кворум має бути від 1 до %d", len(key.OwnerIDs))
+        }
+    case proposalOwners:
+        var owners []string
+        if err := json.Unmarshal([]byte(parameter), &owners); err != nil {
+            return invalidArgument("parameter", "помилка при розборі власників: %v", err)
+        }
+        if len(owners) == 0 {
+            return invalidArgument("parameter", "ключ повинен мати хоча б одного власника")
+        }
+        return validateOwners(owners)
+    case proposalPolicy:
+        _, err := parseRotationPolicy(parameter)
+        return err
+    default:
+        return invalidArgument("operation", "невідома операція: %s", operation)
+    }
+    return nil
+}
+
+// changeKeyOwners замінює власників ключа та оновлює індекс власників
+func changeKeyOwners(ctx contractapi.TransactionContextInterface, key *CryptoKey, owners []string, now int64) error {
+    for _, ownerID := range key.OwnerIDs {
+        if containsString(owners, ownerID) {
+            continue
+        }
+        indexKey, err := ctx.GetStub().CreateCompositeKey(ownerIndex, []string{ownerID, key.ID})
+        if err != nil {
+            return fmt.Errorf("помилка створення індексу власника: %v", err)
+        }
+        if err := ctx.GetStub().DelState(indexKey); err != nil {
+            return err
+        }
+    }
+
+    key.OwnerIDs = owners
+    key.OwnersChangedAt = now
+    if err := putKey(ctx, key); err != nil {
+        return err
+    }
+    return indexKeyOwners(ctx, key)
+}
+
+// approvalThreshold повертає кворум власників ключа; за замовчуванням - більшість
+func approvalThreshold(key *CryptoKey) int {
+    threshold := key.ApprovalThreshold
+    if threshold <= 0 {
+        threshold = len(key.OwnerIDs)/2 + 1
+    }
+    if threshold > len(key.OwnerIDs) {
+        threshold = len(key.OwnerIDs)
+    }
+    if threshold < 1 {
+        threshold = 1
+    }
+    return threshold
+}
+
+// requireSingleApproval забороняє пряму незворотну операцію, якщо ключ потребує кворуму
+func requireSingleApproval(key *CryptoKey) error {
+    if threshold := approvalThreshold(key); threshold > 1 {
+        return fmt.Errorf("операція з ключем %s потребує схвалення %d з %d власників, використайте ProposeKeyOperation", key.ID, threshold, len(key.OwnerIDs))
+    }
+    return nil
+}
+
+// effectiveProposalStatus повертає стан пропозиції з урахуванням строку дії
+func effectiveProposalStatus(proposal *KeyOperationProposal, now int64) string {
+    if proposal.Status == proposalPending && proposal.ExpiresAt <= now {
+        return proposalExpired
+    }
+    return proposal.Status
+}
+
+// readProposal читає пропозицію з world state
+func readProposal(ctx contractapi.TransactionContextInterface, proposalID string) (*KeyOperationProposal, error) {
+    proposalJSON, err := ctx.GetStub().GetState(proposalPrefix + proposalID)
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання пропозиції: %v", err)
+    }
+    if proposalJSON == nil {
+        return nil, fmt.Errorf("пропозиція %s не існує", proposalID)
+    }
+
+    var proposal KeyOperationProposal
+    if err := json.Unmarshal(proposalJSON, &proposal); err != nil {
+        return nil, fmt.Errorf("помилка десеріалізації пропозиції: %v", err)
+    }
+    return &proposal, nil
+}
+
+// putProposal зберігає пропозицію у world state
+func putProposal(ctx contractapi.TransactionContextInterface, proposal *KeyOperationProposal) error {
+    proposalJSON, err := json.Marshal(proposal)
+    if err != nil {
+        return err
+    }
+    return ctx.GetStub().PutState(proposalPrefix+proposal.ID, proposalJSON)
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+    "crypto"
+    "crypto/ecdsa"
+    "crypto/ed25519"
+    "crypto/rsa"
+    "crypto/sha256"
+    "crypto/sha512"
+    "crypto/x509"
+    "encoding/base64"
+    "encoding/hex"
+    "encoding/json"
+    "encoding/pem"
+    "fmt"
+
+    "github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+    "blockchain-security/chaincode/common/clock"
+)
+
+// Префікс для відкритих ключів у world state
+const publicKeyPrefix = "pubkey:"
+
+// Операція перевірки підпису: дозволена і для деактивованого ключа
+const operationVerify = "verify"
+
+// PublicKeyRecord відкритий ключ, закріплений за CryptoKey
+type PublicKeyRecord struct {
+    KeyID        string `json:"keyId"`
+    Algorithm    string `json:"algorithm"`
+    KeySize      int    `json:"keySize"`
+    PEM          string `json:"pem"`         // SubjectPublicKeyInfo у форматі PEM
+    Fingerprint  string `json:"fingerprint"` // SHA-256 від DER SubjectPublicKeyInfo, hex
+    RegisteredAt int64  `json:"registeredAt"`
+    RegisteredBy string `json:"registeredBy"`
+}
+
+// RegisterPublicKey закріплює відкритий ключ (PEM або DER у base64) за асиметричним CryptoKey
+func (s *SmartContract) RegisterPublicKey(ctx contractapi.TransactionContextInterface, keyID string, publicKey string) (*PublicKeyRecord, error) {
+    der, err := decodePublicKey(publicKey)
+    if err != nil {
+        return nil, err
+    }
+    parsed, err := x509.ParsePKIXPublicKey(der)
+    if err != nil {
+        return nil, invalidArgument("publicKey", "помилка розбору відкритого ключа: %v", err)
+    }
+    algorithm, keySize, err := publicKeySpec(parsed)
+    if err != nil {
+        return nil, err
+    }
+
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return nil, err
+    }
+    if key.Type != keyTypeAsymmetric {
+        return nil, invalidArgument("keyID", "ключ %s не є асиметричним", keyID)
+    }
+    if algorithm != key.Algorithm || keySize != key.KeySize {
+        return nil, invalidArgument("publicKey", "ключ %s-%d не відповідає запису %s-%d", algorithm, keySize, key.Algorithm, key.KeySize)
+    }
+    if key.Status != statusPreActivation && key.Status != statusActive {
+        return nil, fmt.Errorf("ключ %s у стані %s не приймає відкритий ключ", keyID, key.Status)
+    }
+
+    // Закріпити відкритий ключ може лише власник
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return nil, err
+    }
+    if err := authorizeKeyOwner(key, caller); err != nil {
+        return nil, err
+    }
+
+    // Відкритий ключ незмінний: заміна можлива лише через ротацію
+    existing, err := ctx.GetStub().GetState(publicKeyPrefix + keyID)
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання відкритого ключа: %v", err)
+    }
+    if existing != nil {
+        return nil, &ValidationError{Kind: ErrAlreadyExists, Field: "keyID", Message: fmt.Sprintf("відкритий ключ для %s вже зареєстровано", keyID)}
+    }
+
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return nil, err
+    }
+
+    fingerprint := sha256.Sum256(der)
+    record := PublicKeyRecord{
+        KeyID:        keyID,
+        Algorithm:    algorithm,
+        KeySize:      keySize,
+        PEM:          string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+        Fingerprint:  hex.EncodeToString(fingerprint[:]),
+        RegisteredAt: now,
+        RegisteredBy: caller,
+    }
+
+    recordJSON, err := json.Marshal(record)
+    if err != nil {
+        return nil, err
+    }
+    if err := ctx.GetStub().PutState(publicKeyPrefix+keyID, recordJSON); err != nil {
+        return nil, err
+    }
+
+    return &record, nil
+}
+
+// GetPublicKey повертає відкритий ключ, закріплений за CryptoKey
+func (s *SmartContract) GetPublicKey(ctx contractapi.TransactionContextInterface, keyID string) (*PublicKeyRecord, error) {
+    return readPublicKey(ctx, keyID)
+}
+
+// VerifySignature перевіряє підпис повідомлення закріпленим відкритим ключем.
+// Повідомлення та підпис передаються у base64. RSA використовує PKCS#1 v1.5,
+// ECDSA - підпис ASN.1 DER; хеш SHA-256 (SHA-384 для P-384).
+func (s *SmartContract) VerifySignature(ctx contractapi.TransactionContextInterface, keyID string, message string, signature string) (bool, error) {
+    messageBytes, err := base64.StdEncoding.DecodeString(message)
+    if err != nil {
+        return false, invalidArgument("message", "повідомлення має бути в base64: %v", err)
+    }
+    signatureBytes, err := base64.StdEncoding.DecodeString(signature)
+    if err != nil {
+        return false, invalidArgument("signature", "підпис має бути в base64: %v", err)
+    }
+
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return false, err
+    }
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return false, err
+    }
+    if !keyPermits(key, operationVerify, now) {
+        return false, nil
+    }
+
+    record, err := readPublicKey(ctx, keyID)
+    if err != nil {
+        return false, err
+    }
+    block, _ := pem.Decode([]byte(record.PEM))
+    if block == nil {
+        return false, fmt.Errorf("пошкоджений відкритий ключ %s", keyID)
+    }
+    parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+    if err != nil {
+        return false, fmt.Errorf("пошкоджений відкритий ключ %s: %v", keyID, err)
+    }
+
+    return verifyWithPublicKey(parsed, messageBytes, signatureBytes), nil
+}
+
+// readPublicKey читає відкритий ключ з world state
+func readPublicKey(ctx contractapi.TransactionContextInterface, keyID string) (*PublicKeyRecord, error) {
+    recordJSON, err := ctx.GetStub().GetState(publicKeyPrefix + keyID)
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання відкритого ключа: %v", err)
+    }
+    if recordJSON == nil {
+        return nil, fmt.Errorf("відкритий ключ для %s не зареєстровано", keyID)
+    }
+
+    var record PublicKeyRecord
+    if err := json.Unmarshal(recordJSON, &record); err != nil {
+        return nil, fmt.Errorf("помилка десеріалізації відкритого ключа: %v", err)
+    }
+    return &record, nil
+}
+
+// decodePublicKey приймає відкритий ключ у PEM або DER, закодованому в base64
+func decodePublicKey(publicKey string) ([]byte, error) {
+    if block, _ := pem.Decode([]byte(publicKey)); block != nil {
+        if block.Type != "PUBLIC KEY" {
+            return nil, invalidArgument("publicKey", "очікується блок PEM PUBLIC KEY, отримано %s", block.Type)
+        }
+        return block.Bytes, nil
+    }
+
+    der, err := base64.StdEncoding.DecodeString(publicKey)
+    if err != nil {
+        return nil, invalidArgument("publicKey", "відкритий ключ має бути в PEM або DER у base64")
+    }
+    return der, nil
+}
+
+// publicKeySpec визначає алгоритм і розмір відкритого ключа в термінах CryptoKey
+func publicKeySpec(publicKey interface{}) (string, int, error) {
+    switch k := publicKey.(type) {
+    case *rsa.PublicKey:
+        return "RSA", k.N.BitLen(), nil
+    case *ecdsa.PublicKey:
+        return "ECDSA", k.Curve.Params().BitSize, nil
+    case ed25519.PublicKey:
+        return "Ed25519", 256, nil
+    default:
+        return "", 0, invalidArgument("publicKey", "непідтримуваний тип відкритого ключа %T", publicKey)
+    }
+}
+
+// verifyWithPublicKey перевіряє підпис відповідно до типу відкритого ключа
+func verifyWithPublicKey(publicKey interface{}, message []byte, signature []byte) bool {
+    switch k := publicKey.(type) {
+    case *rsa.PublicKey:
+        digest := sha256.Sum256(message)
+        return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) == nil
+    case *ecdsa.PublicKey:
+        if k.Curve.Params().BitSize == 384 {
+            digest := sha512.Sum384(message)
+            return ecdsa.VerifyASN1(k, digest[:], signature)
+        }
+        digest := sha256.Sum256(message)
+        return ecdsa.VerifyASN1(k, digest[:], signature)
+    case ed25519.PublicKey:
+        return ed25519.Verify(k, message, signature)
+    default:
+        return false
+    }
+}
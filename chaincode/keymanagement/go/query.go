@@ -0,0 +1,325 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+
+    "github.com/hyperledger/fabric-chaincode-go/shim"
+    "github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+    "blockchain-security/chaincode/common/clock"
+)
+
+// Значення записів-індексів складених ключів
+var indexValue = []byte{0x00}
+
+// Максимальний розмір сторінки пагінованих запитів
+const maxPageSize = 200
+
+// KeyQueryResult сторінка результатів запиту ключів
+type KeyQueryResult struct {
+    Records             []*CryptoKey `json:"records"`
+    FetchedRecordsCount int32        `json:"fetchedRecordsCount"`
+    Bookmark            string       `json:"bookmark"`
+}
+
+// AccessQueryResult сторінка результатів запиту доступів
+type AccessQueryResult struct {
+    Records             []*KeyAccess `json:"records"`
+    FetchedRecordsCount int32        `json:"fetchedRecordsCount"`
+    Bookmark            string       `json:"bookmark"`
+}
+
+// ReadKey повертає ключ з урахуванням закінчення строку його дії
+func (s *SmartContract) ReadKey(ctx contractapi.TransactionContextInterface, keyID string) (*CryptoKey, error) {
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return nil, err
+    }
+
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return nil, err
+    }
+    key.Status = effectiveKeyStatus(key, now)
+
+    return key, nil
+}
+
+// KeyExists перевіряє наявність ключа
+func (s *SmartContract) KeyExists(ctx contractapi.TransactionContextInterface, keyID string) (bool, error) {
+    return keyExists(ctx, keyID)
+}
+
+// GetKeysByOwner повертає всі ключі власника
+func (s *SmartContract) GetKeysByOwner(ctx contractapi.TransactionContextInterface, ownerID string) ([]*CryptoKey, error) {
+    iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(ownerIndex, []string{ownerID})
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання індексу власників: %v", err)
+    }
+    defer iterator.Close()
+
+    return collectIndexedKeys(ctx, iterator)
+}
+
+// GetKeysByOwnerWithPagination повертає сторінку ключів власника
+func (s *SmartContract) GetKeysByOwnerWithPagination(ctx contractapi.TransactionContextInterface, ownerID string, pageSize int32, bookmark string) (*KeyQueryResult, error) {
+    if err := validatePageSize(pageSize); err != nil {
+        return nil, err
+    }
+
+    iterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(ownerIndex, []string{ownerID}, pageSize, bookmark)
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання індексу власників: %v", err)
+    }
+    defer iterator.Close()
+
+    keys, err := collectIndexedKeys(ctx, iterator)
+    if err != nil {
+        return nil, err
+    }
+
+    return &KeyQueryResult{
+        Records:             keys,
+        FetchedRecordsCount: metadata.GetFetchedRecordsCount(),
+        Bookmark:            metadata.GetBookmark(),
+    }, nil
+}
+
+// GetAccessGrantsForKey повертає чинні доступи до ключа
+func (s *SmartContract) GetAccessGrantsForKey(ctx contractapi.TransactionContextInterface, keyID string) ([]*KeyAccess, error) {
+    iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(accessObjectType, []string{keyID})
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання доступів: %v", err)
+    }
+    defer iterator.Close()
+
+    return collectAccess(ctx, iterator)
+}
+
+// GetAccessGrantsForKeyWithPagination повертає сторінку чинних доступів до ключа
+func (s *SmartContract) GetAccessGrantsForKeyWithPagination(ctx contractapi.TransactionContextInterface, keyID string, pageSize int32, bookmark string) (*AccessQueryResult, error) {
+    if err := validatePageSize(pageSize); err != nil {
+        return nil, err
+    }
+
+    iterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(accessObjectType, []string{keyID}, pageSize, bookmark)
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання доступів: %v", err)
+    }
+    defer iterator.Close()
+
+    grants, err := collectAccess(ctx, iterator)
+    if err != nil {
+        return nil, err
+    }
+
+    return &AccessQueryResult{
+        Records:             grants,
+        FetchedRecordsCount: metadata.GetFetchedRecordsCount(),
+        Bookmark:            metadata.GetBookmark(),
+    }, nil
+}
+
+// GetAccessGrantsForUser повертає чинні доступи користувача до всіх ключів
+func (s *SmartContract) GetAccessGrantsForUser(ctx contractapi.TransactionContextInterface, userID string) ([]*KeyAccess, error) {
+    iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(accessByUserIndex, []string{userID})
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання індексу доступів: %v", err)
+    }
+    defer iterator.Close()
+
+    return collectIndexedAccess(ctx, iterator)
+}
+
+// GetAccessGrantsForUserWithPagination повертає сторінку чинних доступів користувача
+func (s *SmartContract) GetAccessGrantsForUserWithPagination(ctx contractapi.TransactionContextInterface, userID string, pageSize int32, bookmark string) (*AccessQueryResult, error) {
+    if err := validatePageSize(pageSize); err != nil {
+        return nil, err
+    }
+
+    iterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(accessByUserIndex, []string{userID}, pageSize, bookmark)
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання індексу доступів: %v", err)
+    }
+    defer iterator.Close()
+
+    grants, err := collectIndexedAccess(ctx, iterator)
+    if err != nil {
+        return nil, err
+    }
+
+    return &AccessQueryResult{
+        Records:             grants,
+        FetchedRecordsCount: metadata.GetFetchedRecordsCount(),
+        Bookmark:            metadata.GetBookmark(),
+    }, nil
+}
+
+// keyExists перевіряє наявність запису ключа у world state
+func keyExists(ctx contractapi.TransactionContextInterface, keyID string) (bool, error) {
+    keyJSON, err := ctx.GetStub().GetState(keyPrefix + keyID)
+    if err != nil {
+        return false, fmt.Errorf("помилка читання ключа: %v", err)
+    }
+    return keyJSON != nil, nil
+}
+
+// accessStateKey формує складений ключ world state для доступу користувача до ключа
+func accessStateKey(ctx contractapi.TransactionContextInterface, keyID string, userID string) (string, error) {
+    accessKey, err := ctx.GetStub().CreateCompositeKey(accessObjectType, []string{keyID, userID})
+    if err != nil {
+        return "", fmt.Errorf("помилка створення ключа доступу: %v", err)
+    }
+    return accessKey, nil
+}
+
+// putAccess зберігає доступ та індекс доступів користувача
+func putAccess(ctx contractapi.TransactionContextInterface, access *KeyAccess) error {
+    accessJSON, err := json.Marshal(access)
+    if err != nil {
+        return err
+    }
+
+    accessKey, err := accessStateKey(ctx, access.KeyID, access.UserID)
+    if err != nil {
+        return err
+    }
+    if err := ctx.GetStub().PutState(accessKey, accessJSON); err != nil {
+        return err
+    }
+
+    indexKey, err := ctx.GetStub().CreateCompositeKey(accessByUserIndex, []string{access.UserID, access.KeyID})
+    if err != nil {
+        return fmt.Errorf("помилка створення індексу доступу: %v", err)
+    }
+    return ctx.GetStub().PutState(indexKey, indexValue)
+}
+
+// deleteAccess видаляє доступ та відповідний запис індексу
+func deleteAccess(ctx contractapi.TransactionContextInterface, keyID string, userID string) error {
+    accessKey, err := accessStateKey(ctx, keyID, userID)
+    if err != nil {
+        return err
+    }
+    if err := ctx.GetStub().DelState(accessKey); err != nil {
+        return err
+    }
+
+    indexKey, err := ctx.GetStub().CreateCompositeKey(accessByUserIndex, []string{userID, keyID})
+    if err != nil {
+        return fmt.Errorf("помилка створення індексу доступу: %v", err)
+    }
+    return ctx.GetStub().DelState(indexKey)
+}
+
+// indexKeyOwners додає ключ до індексу власників
+func indexKeyOwners(ctx contractapi.TransactionContextInterface, key *CryptoKey) error {
+    for _, ownerID := range key.OwnerIDs {
+        indexKey, err := ctx.GetStub().CreateCompositeKey(ownerIndex, []string{ownerID, key.ID})
+        if err != nil {
+            return fmt.Errorf("помилка створення індексу власника: %v", err)
+        }
+        if err := ctx.GetStub().PutState(indexKey, indexValue); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// collectIndexedKeys читає ключі, на які посилаються записи індексу власників
+func collectIndexedKeys(ctx contractapi.TransactionContextInterface, iterator shim.StateQueryIteratorInterface) ([]*CryptoKey, error) {
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return nil, err
+    }
+
+    keys := []*CryptoKey{}
+    for iterator.HasNext() {
+        kv, err := iterator.Next()
+        if err != nil {
+            return nil, fmt.Errorf("помилка читання індексу: %v", err)
+        }
+
+        _, attributes, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+        if err != nil || len(attributes) != 2 {
+            return nil, fmt.Errorf("пошкоджений запис індексу %q", kv.Key)
+        }
+
+        key, err := readKey(ctx, attributes[1])
+        if err != nil {
+            return nil, err
+        }
+        key.Status = effectiveKeyStatus(key, now)
+        keys = append(keys, key)
+    }
+
+    return keys, nil
+}
+
+// collectAccess десеріалізує доступи з ітератора, пропускаючи прострочені
+func collectAccess(ctx contractapi.TransactionContextInterface, iterator shim.StateQueryIteratorInterface) ([]*KeyAccess, error) {
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return nil, err
+    }
+
+    grants := []*KeyAccess{}
+    for iterator.HasNext() {
+        kv, err := iterator.Next()
+        if err != nil {
+            return nil, fmt.Errorf("помилка читання доступу: %v", err)
+        }
+
+        var access KeyAccess
+        if err := json.Unmarshal(kv.Value, &access); err != nil {
+            return nil, fmt.Errorf("помилка десеріалізації доступу: %v", err)
+        }
+        if isAccessExpired(&access, now) {
+            continue
+        }
+        grants = append(grants, &access)
+    }
+
+    return grants, nil
+}
+
+// collectIndexedAccess читає доступи, на які посилаються записи індексу користувача
+func collectIndexedAccess(ctx contractapi.TransactionContextInterface, iterator shim.StateQueryIteratorInterface) ([]*KeyAccess, error) {
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return nil, err
+    }
+
+    grants := []*KeyAccess{}
+    for iterator.HasNext() {
+        kv, err := iterator.Next()
+        if err != nil {
+            return nil, fmt.Errorf("помилка читання індексу: %v", err)
+        }
+
+        _, attributes, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+        if err != nil || len(attributes) != 2 {
+            return nil, fmt.Errorf("пошкоджений запис індексу %q", kv.Key)
+        }
+
+        access, err := readAccess(ctx, attributes[1], attributes[0])
+        if err != nil {
+            return nil, err
+        }
+        if access == nil || isAccessExpired(access, now) {
+            continue
+        }
+        grants = append(grants, access)
+    }
+
+    return grants, nil
+}
+
+// validatePageSize перевіряє розмір сторінки пагінованого запиту
+func validatePageSize(pageSize int32) error {
+    if pageSize <= 0 || pageSize > maxPageSize {
+        return fmt.Errorf("розмір сторінки має бути від 1 до %d", maxPageSize)
+    }
+    return nil
+}
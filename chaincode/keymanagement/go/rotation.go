@@ -0,0 +1,221 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+
+    "github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+    "blockchain-security/chaincode/common/clock"
+)
+
+// Причини планової ротації ключа
+const (
+    rotationReasonInterval    = "interval"     // минув інтервал ротації
+    rotationReasonUsage       = "usage"        // вичерпано ліміт використань
+    rotationReasonOwnerChange = "owner-change" // змінився склад власників
+)
+
+// RotationPolicy політика планової ротації ключа; нульове значення поля вимикає відповідну умову
+type RotationPolicy struct {
+    IntervalDays        int      `json:"intervalDays,omitempty"`        // ротація кожні N днів від активації
+    MaxUsageCount       int64    `json:"maxUsageCount,omitempty"`       // ротація після N використань
+    RotateOnOwnerChange bool     `json:"rotateOnOwnerChange,omitempty"` // ротація після зміни власників
+    Workers             []string `json:"workers,omitempty"`             // виконавці, яким окрім власників дозволено RotateDueKey
+}
+
+// RotationDue ключ, що підлягає плановій ротації
+type RotationDue struct {
+    KeyID  string `json:"keyId"`
+    Reason string `json:"reason"` // interval, usage, owner-change
+    DueAt  int64  `json:"dueAt"`
+}
+
+// RotationDueResult сторінка ключів, що підлягають плановій ротації
+type RotationDueResult struct {
+    Records             []*RotationDue `json:"records"`
+    FetchedRecordsCount int32          `json:"fetchedRecordsCount"`
+    Bookmark            string         `json:"bookmark"`
+}
+
+// SetRotationPolicy встановлює політику ротації ключа; порожній рядок знімає політику.
+// Політика дозволяє будь-кому ініціювати ротацію за розкладом, тому для спільних ключів
+// вона встановлюється лише через ProposeKeyOperation.
+func (s *SmartContract) SetRotationPolicy(ctx contractapi.TransactionContextInterface, keyID string, policyJSON string) error {
+    policy, err := parseRotationPolicy(policyJSON)
+    if err != nil {
+        return err
+    }
+
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return err
+    }
+
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return err
+    }
+    if err := authorizeKeyOwner(key, caller); err != nil {
+        return err
+    }
+    if err := requireSingleApproval(key); err != nil {
+        return err
+    }
+
+    key.RotationPolicy = policy
+    return putKey(ctx, key)
+}
+
+// GetKeysDueForRotation переглядає сторінку з pageSize ключів, починаючи із закладки bookmark,
+// і повертає ті з них, політика яких вимагає ротації на момент asOf. Сторінка може містити
+// менше записів, ніж переглянуто ключів; перегляд завершено, коли закладка порожня.
+// Якщо asOf дорівнює нулю, використовується час транзакції.
+func (s *SmartContract) GetKeysDueForRotation(ctx contractapi.TransactionContextInterface, asOf int64, pageSize int32, bookmark string) (*RotationDueResult, error) {
+    if err := validatePageSize(pageSize); err != nil {
+        return nil, err
+    }
+    if asOf == 0 {
+        now, err := clock.Now(ctx.GetStub())
+        if err != nil {
+            return nil, err
+        }
+        asOf = now
+    }
+
+    iterator, metadata, err := ctx.GetStub().GetStateByRangeWithPagination(keyPrefix, keyRangeEnd, pageSize, bookmark)
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання ключів: %v", err)
+    }
+    defer iterator.Close()
+
+    due := []*RotationDue{}
+    for iterator.HasNext() {
+        kv, err := iterator.Next()
+        if err != nil {
+            return nil, fmt.Errorf("помилка читання ключа: %v", err)
+        }
+        var key CryptoKey
+        if err := json.Unmarshal(kv.Value, &key); err != nil {
+            return nil, fmt.Errorf("помилка десеріалізації даних ключа: %v", err)
+        }
+        reason, dueAt, ok, err := keyRotationDue(ctx, &key, asOf)
+        if err != nil {
+            return nil, err
+        }
+        if ok {
+            due = append(due, &RotationDue{KeyID: key.ID, Reason: reason, DueAt: dueAt})
+        }
+    }
+
+    return &RotationDueResult{
+        Records:             due,
+        FetchedRecordsCount: metadata.GetFetchedRecordsCount(),
+        Bookmark:            metadata.GetBookmark(),
+    }, nil
+}
+
+// RotateDueKey виконує планову ротацію ключа і повертає ідентифікатор нового ключа.
+// Транзакцію може викликати власник ключа або виконавець, вказаний у політиці ротації;
+// потреба в ротації перевіряється за політикою ключа на час транзакції.
+func (s *SmartContract) RotateDueKey(ctx contractapi.TransactionContextInterface, keyID string) (string, error) {
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return "", err
+    }
+
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return "", err
+    }
+    if !isKeyOwner(key, caller) && (key.RotationPolicy == nil || !containsString(key.RotationPolicy.Workers, caller)) {
+        return "", fmt.Errorf("клієнт %s не може виконувати планову ротацію ключа %s", caller, keyID)
+    }
+
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return "", err
+    }
+    _, _, ok, err := keyRotationDue(ctx, key, now)
+    if err != nil {
+        return "", err
+    }
+    if !ok {
+        return "", fmt.Errorf("ключ %s не потребує ротації", keyID)
+    }
+
+    return rotateKey(ctx, key)
+}
+
+// keyRotationDue визначає потребу в ротації ключа; кількість використань читається
+// зі статистики лише для політик з лімітом використань
+func keyRotationDue(ctx contractapi.TransactionContextInterface, key *CryptoKey, now int64) (string, int64, bool, error) {
+    var usageCount int64
+    if key.RotationPolicy != nil && key.RotationPolicy.MaxUsageCount > 0 && effectiveKeyStatus(key, now) == statusActive {
+        count, err := keyUsageCount(ctx, key.ID)
+        if err != nil {
+            return "", 0, false, err
+        }
+        usageCount = count
+    }
+
+    reason, dueAt, ok := rotationDue(key, usageCount, now)
+    return reason, dueAt, ok, nil
+}
+
+// rotationDue визначає, чи вимагає політика ротації ключа з usageCount використаннями,
+// причину та час настання. Прострочені ключі не ротуються: їх деактивує SweepExpired.
+func rotationDue(key *CryptoKey, usageCount int64, now int64) (string, int64, bool) {
+    policy := key.RotationPolicy
+    if policy == nil || effectiveKeyStatus(key, now) != statusActive {
+        return "", 0, false
+    }
+
+    if policy.IntervalDays > 0 {
+        dueAt := clock.AfterDays(key.ActivatedAt, policy.IntervalDays)
+        if dueAt <= now {
+            return rotationReasonInterval, dueAt, true
+        }
+    }
+    if policy.MaxUsageCount > 0 && usageCount >= policy.MaxUsageCount {
+        return rotationReasonUsage, now, true
+    }
+    if policy.RotateOnOwnerChange && key.OwnersChangedAt > key.ActivatedAt {
+        return rotationReasonOwnerChange, key.OwnersChangedAt, true
+    }
+
+    return "", 0, false
+}
+
+// parseRotationPolicy розбирає та перевіряє політику ротації; порожній рядок означає відсутність політики
+func parseRotationPolicy(policyJSON string) (*RotationPolicy, error) {
+    if policyJSON == "" {
+        return nil, nil
+    }
+
+    var policy RotationPolicy
+    if err := json.Unmarshal([]byte(policyJSON), &policy); err != nil {
+        return nil, invalidArgument("policy", "помилка розбору політики ротації: %v", err)
+    }
+    if policy.IntervalDays < 0 || policy.IntervalDays > maxKeyLifetimeDays {
+        return nil, invalidArgument("intervalDays", "інтервал ротації має бути від 1 до %d днів", maxKeyLifetimeDays)
+    }
+    if policy.MaxUsageCount < 0 {
+        return nil, invalidArgument("maxUsageCount", "ліміт використань не може бути від'ємним")
+    }
+    if len(policy.Workers) > maxOwners {
+        return nil, invalidArgument("workers", "політика не може мати більше %d виконавців ротації", maxOwners)
+    }
+    seen := make(map[string]bool, len(policy.Workers))
+    for _, worker := range policy.Workers {
+        if worker == "" || seen[worker] {
+            return nil, invalidArgument("workers", "виконавці ротації мають бути непорожніми і не повторюватися")
+        }
+        seen[worker] = true
+    }
+    if policy.IntervalDays == 0 && policy.MaxUsageCount == 0 && !policy.RotateOnOwnerChange {
+        return nil, invalidArgument("policy", "політика ротації має містити хоча б одну умову")
+    }
+
+    return &policy, nil
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "strconv"
+
+    "github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+    "blockchain-security/chaincode/common/clock"
+)
+
+// Тривалість інтервалу агрегування статистики використання в секундах
+const usageBucketSeconds = 3600
+
+// UsageBucket кількість операцій користувача з ключем за одну годину
+type UsageBucket struct {
+    KeyID      string           `json:"keyId"`
+    UserID     string           `json:"userId"`
+    Hour       int64            `json:"hour"` // початок години, Unix-час
+    Operations map[string]int64 `json:"operations"`
+}
+
+// UsageStats статистика використання ключа за період
+type UsageStats struct {
+    KeyID       string           `json:"keyId"`
+    UserID      string           `json:"userId,omitempty"` // порожній - усі користувачі
+    From        int64            `json:"from"`
+    To          int64            `json:"to"`
+    Total       int64            `json:"total"`
+    ByOperation map[string]int64 `json:"byOperation"`
+    ByUser      map[string]int64 `json:"byUser"`
+    Buckets     []*UsageBucket   `json:"buckets"`
+}
+
+// RecordKeyUsage фіксує операцію користувача з ключем після перевірки його доступу.
+// Збільшує лічильник доступу та погодинну статистику користувача і відмовляє, якщо ліміт
+// доступу вичерпано. Запис ключа не змінюється, тож використання ключа різними
+// користувачами не конфліктують між собою.
+// Використання фіксує сам користувач або власник ключа від його імені.
+func (s *SmartContract) RecordKeyUsage(ctx contractapi.TransactionContextInterface, keyID string, userID string, operation string) error {
+    if operation != operationEncrypt && operation != operationDecrypt {
+        return invalidArgument("operation", "невідома операція: %s", operation)
+    }
+
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return err
+    }
+
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return err
+    }
+    if caller != userID && !isKeyOwner(key, caller) {
+        return fmt.Errorf("клієнт %s не може фіксувати використання ключа %s від імені %s", caller, keyID, userID)
+    }
+
+    now, err := clock.Now(ctx.GetStub())
+    if err != nil {
+        return err
+    }
+
+    access, allowed, err := authorizeKeyOperation(ctx, key, userID, operation, now)
+    if err != nil {
+        return err
+    }
+    if !allowed {
+        return fmt.Errorf("користувач %s не має права на операцію %s з ключем %s", userID, operation, keyID)
+    }
+
+    // Власники ключа не мають запису доступу, тому для них ведеться лише статистика
+    if access != nil {
+        if access.UsageQuota > 0 && access.UsageCount >= access.UsageQuota {
+            return &ValidationError{Kind: ErrQuotaExceeded, Field: "userID", Message: fmt.Sprintf("користувач %s вичерпав ліміт %d використань ключа %s", userID, access.UsageQuota, keyID)}
+        }
+        access.UsageCount++
+        if err := putAccess(ctx, access); err != nil {
+            return err
+        }
+    }
+
+    return addUsage(ctx, keyID, userID, operation, now)
+}
+
+// SetAccessQuota встановлює ліміт використань ключа за доступом користувача; 0 знімає ліміт
+func (s *SmartContract) SetAccessQuota(ctx contractapi.TransactionContextInterface, keyID string, userID string, quota int64) error {
+    if quota < 0 {
+        return invalidArgument("quota", "ліміт використань не може бути від'ємним")
+    }
+
+    access, err := readAccess(ctx, keyID, userID)
+    if err != nil {
+        return err
+    }
+    if access == nil {
+        return fmt.Errorf("доступ користувача %s до ключа %s не існує", userID, keyID)
+    }
+
+    // Ліміт встановлює лише власник, щоб користувач з повним доступом не міг підвищити власний ліміт
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return err
+    }
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return err
+    }
+    if err := authorizeKeyOwner(key, caller); err != nil {
+        return err
+    }
+
+    access.UsageQuota = quota
+    return putAccess(ctx, access)
+}
+
+// GetKeyUsageStats повертає статистику використання ключа за період [from, to).
+// Період округлюється до годин; порожній userID означає всіх користувачів, to = 0 - час транзакції.
+func (s *SmartContract) GetKeyUsageStats(ctx contractapi.TransactionContextInterface, keyID string, userID string, from int64, to int64) (*UsageStats, error) {
+    key, err := readKey(ctx, keyID)
+    if err != nil {
+        return nil, err
+    }
+
+    caller, err := callerIdentity(ctx)
+    if err != nil {
+        return nil, err
+    }
+    if err := authorizeKeyManager(ctx, key, caller); err != nil {
+        return nil, err
+    }
+
+    if to == 0 {
+        to, err = clock.Now(ctx.GetStub())
+        if err != nil {
+            return nil, err
+        }
+    }
+    if from < 0 || from >= to {
+        return nil, invalidArgument("from", "початок періоду має передувати його кінцю")
+    }
+
+    attributes := []string{keyID}
+    if userID != "" {
+        attributes = append(attributes, userID)
+    }
+    iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(usageObjectType, attributes)
+    if err != nil {
+        return nil, fmt.Errorf("помилка читання статистики використання: %v", err)
+    }
+    defer iterator.Close()
+
+    stats := &UsageStats{
+        KeyID:       keyID,
+        UserID:      userID,
+        From:        from,
+        To:          to,
+        ByOperation: map[string]int64{},
+        ByUser:      map[string]int64{},
+        Buckets:     []*UsageBucket{},
+    }
+    for iterator.HasNext() {
+        kv, err := iterator.Next()
+        if err != nil {
+            return nil, fmt.Errorf("помилка читання статистики використання: %v", err)
+        }
+        var bucket UsageBucket
+        if err := json.Unmarshal(kv.Value, &bucket); err != nil {
+            return nil, fmt.Errorf("помилка десеріалізації статистики використання: %v", err)
+        }
+        if bucket.Hour+usageBucketSeconds <= from || bucket.Hour >= to {
+            continue
+        }
+
+        for operation, count := range bucket.Operations {
+            stats.Total += count
+            stats.ByOperation[operation] += count
+            stats.ByUser[bucket.UserID] += count
+        }
+        stats.Buckets = append(stats.Buckets, &bucket)
+    }
+
+    return stats, nil
+}
+
+// keyUsageCount повертає кількість зафіксованих використань ключа всіма користувачами
+func keyUsageCount(ctx contractapi.TransactionContextInterface, keyID string) (int64, error) {
+    iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(usageObjectType, []string{keyID})
+    if err != nil {
+        return 0, fmt.Errorf("помилка читання статистики використання: %v", err)
+    }
+    defer iterator.Close()
+
+    var total int64
+    for iterator.HasNext() {
+        kv, err := iterator.Next()
+        if err != nil {
+            return 0, fmt.Errorf("помилка читання статистики використання: %v", err)
+        }
+        var bucket UsageBucket
+        if err := json.Unmarshal(kv.Value, &bucket); err != nil {
+            return 0, fmt.Errorf("помилка десеріалізації статистики використання: %v", err)
+        }
+        for _, count := range bucket.Operations {
+            total += count
+        }
+    }
+    return total, nil
+}
+
+// addUsage збільшує лічильник операції у погодинній статистиці користувача
+func addUsage(ctx contractapi.TransactionContextInterface, keyID string, userID string, operation string, now int64) error {
+    hour := now - now%usageBucketSeconds
+    bucketKey, err := ctx.GetStub().CreateCompositeKey(usageObjectType, []string{keyID, userID, strconv.FormatInt(hour, 10)})
+    if err != nil {
+        return fmt.Errorf("помилка створення ключа статистики: %v", err)
+    }
+
+    bucketJSON, err := ctx.GetStub().GetState(bucketKey)
+    if err != nil {
+        return fmt.Errorf("помилка читання статистики використання: %v", err)
+    }
+
+    bucket := UsageBucket{KeyID: keyID, UserID: userID, Hour: hour, Operations: map[string]int64{}}
+    if bucketJSON != nil {
+        if err := json.Unmarshal(bucketJSON, &bucket); err != nil {
+            return fmt.Errorf("помилка десеріалізації статистики використання: %v", err)
+        }
+    }
+    bucket.Operations[operation]++
+
+    bucketJSON, err = json.Marshal(bucket)
+    if err != nil {
+        return err
+    }
+    return ctx.GetStub().PutState(bucketKey, bucketJSON)
+}
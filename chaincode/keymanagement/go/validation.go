@@ -0,0 +1,128 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "regexp"
+)
+
+// Типізовані помилки, які REST API відображає у відповіді 4xx.
+// Код помилки завжди стоїть на початку повідомлення, тому його можна
+// виділити і з тексту помилки, який повертає Fabric SDK.
+var (
+    ErrInvalidArgument = errors.New("INVALID_ARGUMENT") // 400
+    ErrAlreadyExists   = errors.New("ALREADY_EXISTS")   // 409
+    ErrQuotaExceeded   = errors.New("QUOTA_EXCEEDED")   // 429
+)
+
+// ValidationError описує некоректний параметр транзакції
+type ValidationError struct {
+    Kind    error  // ErrInvalidArgument, ErrAlreadyExists або ErrQuotaExceeded
+    Field   string // назва параметра транзакції
+    Message string
+}
+
+func (e *ValidationError) Error() string {
+    return fmt.Sprintf("%v: %s: %s", e.Kind, e.Field, e.Message)
+}
+
+// Unwrap дозволяє перевіряти вид помилки через errors.Is
+func (e *ValidationError) Unwrap() error {
+    return e.Kind
+}
+
+// invalidArgument створює помилку некоректного параметра
+func invalidArgument(field string, format string, args ...interface{}) error {
+    return &ValidationError{Kind: ErrInvalidArgument, Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// Типи ключів
+const (
+    keyTypeSymmetric  = "symmetric"
+    keyTypeAsymmetric = "asymmetric"
+)
+
+// keySpecs дозволені комбінації типу ключа, алгоритму та розміру ключа в бітах
+var keySpecs = map[string]map[string][]int{
+    keyTypeSymmetric: {
+        "AES": {128, 256},
+    },
+    keyTypeAsymmetric: {
+        "RSA":     {2048, 3072, 4096},
+        "ECDSA":   {256, 384}, // P-256, P-384
+        "Ed25519": {256},
+    },
+}
+
+// Межі строку дії ключа в днях
+const (
+    minKeyLifetimeDays = 1
+    maxKeyLifetimeDays = 3650
+)
+
+// Обмеження на ідентифікатори ключів і власників
+const (
+    maxKeyIDLength = 64
+    maxOwners      = 32
+)
+
+// keyIDPattern допустимі символи ідентифікатора ключа; роздільники складених ключів заборонені
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// validateKeyID перевіряє ідентифікатор ключа, переданий клієнтом
+func validateKeyID(id string) error {
+    if id == "" {
+        return invalidArgument("id", "ідентифікатор ключа не може бути порожнім")
+    }
+    if len(id) > maxKeyIDLength {
+        return invalidArgument("id", "ідентифікатор ключа довший за %d символів", maxKeyIDLength)
+    }
+    if !keyIDPattern.MatchString(id) {
+        return invalidArgument("id", "ідентифікатор ключа містить недопустимі символи")
+    }
+    return nil
+}
+
+// validateKeySpec перевіряє комбінацію типу ключа, алгоритму та розміру
+func validateKeySpec(keyType string, algorithm string, keySize int) error {
+    algorithms, ok := keySpecs[keyType]
+    if !ok {
+        return invalidArgument("keyType", "невідомий тип ключа: %s", keyType)
+    }
+    sizes, ok := algorithms[algorithm]
+    if !ok {
+        return invalidArgument("algorithm", "алгоритм %s не підтримується для ключів типу %s", algorithm, keyType)
+    }
+    for _, size := range sizes {
+        if size == keySize {
+            return nil
+        }
+    }
+    return invalidArgument("keySize", "розмір %d біт не підтримується для алгоритму %s", keySize, algorithm)
+}
+
+// validateLifetime перевіряє строк дії ключа
+func validateLifetime(expirationDays int) error {
+    if expirationDays < minKeyLifetimeDays || expirationDays > maxKeyLifetimeDays {
+        return invalidArgument("expirationDays", "строк дії має бути від %d до %d днів", minKeyLifetimeDays, maxKeyLifetimeDays)
+    }
+    return nil
+}
+
+// validateOwners перевіряє, що власники непорожні та не повторюються
+func validateOwners(owners []string) error {
+    if len(owners) > maxOwners {
+        return invalidArgument("ownerIDs", "ключ не може мати більше %d власників", maxOwners)
+    }
+    seen := make(map[string]bool, len(owners))
+    for _, owner := range owners {
+        if owner == "" {
+            return invalidArgument("ownerIDs", "ідентифікатор власника не може бути порожнім")
+        }
+        if seen[owner] {
+            return invalidArgument("ownerIDs", "власник %s вказаний двічі", owner)
+        }
+        seen[owner] = true
+    }
+    return nil
+}
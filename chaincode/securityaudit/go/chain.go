@@ -0,0 +1,380 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"blockchain-security/chaincode/common/merkle"
+)
+
+// Типи складених ключів ланцюжка подій у world state
+const (
+	chainHeadObjectType  = "audithead"       // потік -> AuditChainHead
+	chainLinkObjectType  = "auditchain"      // потік, номер -> AuditChainLink
+	checkpointObjectType = "auditcheckpoint" // потік, номер останньої події -> AuditCheckpoint
+)
+
+// Кожні checkpointInterval подій потоку фіксується корінь дерева Меркла над їхніми хешами
+const checkpointInterval = 100
+
+// Максимальна кількість подій, яку перевіряє один виклик VerifyAuditChain
+const maxVerifyRange = 1000
+
+// AuditChainHead остання подія потоку
+type AuditChainHead struct {
+	Stream   string `json:"stream"`
+	Sequence uint64 `json:"sequence"`
+	Hash     string `json:"hash"`
+	EventID  string `json:"eventId"`
+}
+
+// AuditChainLink запис події в ланцюжку потоку
+type AuditChainLink struct {
+	EventID string `json:"eventId"`
+	Hash    string `json:"hash"`
+}
+
+// AuditCheckpoint корінь дерева Меркла (RFC 6962) над хешами подій потоку FromSequence..ToSequence
+type AuditCheckpoint struct {
+	Stream       string `json:"stream"`
+	FromSequence uint64 `json:"fromSequence"`
+	ToSequence   uint64 `json:"toSequence"`
+	MerkleRoot   string `json:"merkleRoot"`
+	CreatedAt    int64  `json:"createdAt"`
+}
+
+// ChainVerification результат перевірки ланцюжка подій потоку
+type ChainVerification struct {
+	Stream              string `json:"stream"`
+	From                uint64 `json:"from"`
+	To                  uint64 `json:"to"`
+	HeadSequence        uint64 `json:"headSequence"`
+	Verified            int    `json:"verified"`            // кількість перевірених подій до першого розриву
+	CheckpointsVerified int    `json:"checkpointsVerified"` // кількість перевірених контрольних точок
+	Valid               bool   `json:"valid"`
+	BreakSequence       uint64 `json:"breakSequence,omitempty"` // номер події, на якій виявлено розрив
+	Reason              string `json:"reason,omitempty"`
+}
+
+// GetAuditHead повертає останню подію потоку
+func (s *SmartContract) GetAuditHead(ctx contractapi.TransactionContextInterface, stream string) (*AuditChainHead, error) {
+	head, err := readChainHead(ctx, stream)
+	if err != nil {
+		return nil, err
+	}
+	if head.Sequence == 0 {
+		return nil, fmt.Errorf("потік %s не містить подій", stream)
+	}
+	return head, nil
+}
+
+// GetAuditCheckpoint повертає контрольну точку потоку, що закінчується подією з номером sequence
+func (s *SmartContract) GetAuditCheckpoint(ctx contractapi.TransactionContextInterface, stream string, sequence uint64) (*AuditCheckpoint, error) {
+	checkpoint, err := readCheckpoint(ctx, stream, sequence)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint == nil {
+		return nil, fmt.Errorf("контрольна точка потоку %s на події %d не існує", stream, sequence)
+	}
+	return checkpoint, nil
+}
+
+// VerifyAuditChain перераховує хеші подій потоку з номерами from..to і повертає перший розрив.
+// Нульовий to означає останню подію потоку. Перевіряються хеш кожної події, її зв'язок
+// з попередньою, збіг з останньою подією потоку та корені контрольних точок у діапазоні.
+func (s *SmartContract) VerifyAuditChain(ctx contractapi.TransactionContextInterface, stream string, from uint64, to uint64) (*ChainVerification, error) {
+	head, err := readChainHead(ctx, stream)
+	if err != nil {
+		return nil, err
+	}
+
+	if from == 0 {
+		from = 1
+	}
+	if to == 0 || to > head.Sequence {
+		to = head.Sequence
+	}
+	result := &ChainVerification{Stream: stream, From: from, To: to, HeadSequence: head.Sequence, Valid: true}
+	if from > to {
+		return result, nil
+	}
+	if to-from >= maxVerifyRange {
+		return nil, fmt.Errorf("діапазон перевірки перевищує %d подій", maxVerifyRange)
+	}
+
+	// Перша подія діапазону має посилатися на хеш попередньої
+	prevHash := ""
+	if from > 1 {
+		link, err := readChainLink(ctx, stream, from-1)
+		if err != nil {
+			return nil, err
+		}
+		if link == nil {
+			result.fail(from-1, "відсутній запис події в ланцюжку")
+			return result, nil
+		}
+		prevHash = link.Hash
+	}
+
+	// Хеші перевірених подій поточного інтервалу контрольної точки
+	var hashes [][]byte
+	for sequence := from; sequence <= to; sequence++ {
+		hash, reason, err := verifyChainEvent(ctx, stream, sequence, prevHash)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			result.fail(sequence, reason)
+			return result, nil
+		}
+		if sequence == head.Sequence && hash != head.Hash {
+			result.fail(sequence, "хеш події не збігається з останньою подією потоку")
+			return result, nil
+		}
+		result.Verified++
+		prevHash = hash
+
+		hashBytes, _ := hex.DecodeString(hash)
+		hashes = append(hashes, hashBytes)
+		if sequence%checkpointInterval != 0 {
+			continue
+		}
+		// Контрольна точка перевіряється, якщо діапазон покриває весь її інтервал
+		if len(hashes) == checkpointInterval {
+			reason, err := verifyCheckpoint(ctx, stream, sequence, hashes)
+			if err != nil {
+				return nil, err
+			}
+			if reason != "" {
+				result.fail(sequence, reason)
+				return result, nil
+			}
+			result.CheckpointsVerified++
+		}
+		hashes = nil
+	}
+
+	return result, nil
+}
+
+// fail фіксує перший розрив ланцюжка
+func (v *ChainVerification) fail(sequence uint64, reason string) {
+	v.Valid = false
+	v.BreakSequence = sequence
+	v.Reason = reason
+}
+
+// verifyChainEvent перевіряє подію потоку з номером sequence і повертає її хеш.
+// Порушення повертається як причина розриву, помилка - лише при збої читання стану.
+func verifyChainEvent(ctx contractapi.TransactionContextInterface, stream string, sequence uint64, prevHash string) (string, string, error) {
+	link, err := readChainLink(ctx, stream, sequence)
+	if err != nil {
+		return "", "", err
+	}
+	if link == nil {
+		return "", "відсутній запис події в ланцюжку", nil
+	}
+
+	eventJSON, err := ctx.GetStub().GetState(eventPrefix + link.EventID)
+	if err != nil {
+		return "", "", fmt.Errorf("помилка читання події: %v", err)
+	}
+	if eventJSON == nil {
+		return "", fmt.Sprintf("подію %s видалено", link.EventID), nil
+	}
+	var event SecurityEvent
+	if err := json.Unmarshal(eventJSON, &event); err != nil {
+		return "", fmt.Sprintf("подію %s пошкоджено: %v", link.EventID, err), nil
+	}
+
+	switch {
+	case event.Stream != stream || event.Sequence != sequence:
+		return "", fmt.Sprintf("подія %s має інше місце в ланцюжку", link.EventID), nil
+	case event.PrevHash != prevHash:
+		return "", "порушено зв'язок з попередньою подією", nil
+	}
+	hash, err := eventHash(&event)
+	if err != nil {
+		return "", "", err
+	}
+	if hash != event.Hash || hash != link.Hash {
+		return "", fmt.Sprintf("хеш події %s не збігається з її вмістом", link.EventID), nil
+	}
+	return hash, "", nil
+}
+
+// verifyCheckpoint порівнює корінь контрольної точки з коренем над хешами подій її інтервалу
+func verifyCheckpoint(ctx contractapi.TransactionContextInterface, stream string, sequence uint64, hashes [][]byte) (string, error) {
+	checkpoint, err := readCheckpoint(ctx, stream, sequence)
+	if err != nil {
+		return "", err
+	}
+	if checkpoint == nil {
+		return "відсутня контрольна точка", nil
+	}
+	if checkpoint.MerkleRoot != hex.EncodeToString(merkle.RootHash(hashes)) {
+		return "корінь контрольної точки не збігається з подіями", nil
+	}
+	return "", nil
+}
+
+// chainEvent ставить подію в кінець ланцюжка її потоку: заповнює номер, хеш попередньої події та власний хеш.
+// Читання в транзакції не бачать її власних записів, тому транзакція додає лише одну подію.
+func chainEvent(ctx contractapi.TransactionContextInterface, event *SecurityEvent) error {
+	head, err := readChainHead(ctx, event.Stream)
+	if err != nil {
+		return err
+	}
+
+	event.Sequence = head.Sequence + 1
+	event.PrevHash = head.Hash
+	event.Hash, err = eventHash(event)
+	return err
+}
+
+// eventStream повертає потік події. Окремий потік для кожного ресурсу дозволяє записувати
+// в одному блоці події про різні ресурси без конфлікту версій останньої події потоку.
+func eventStream(eventType string, resource string) string {
+	if resource == "" {
+		return eventType
+	}
+	return eventType + streamSeparator + resource
+}
+
+// storeChainEvent зберігає запис події в ланцюжку, нову останню подію потоку, листок
+// дерева Меркла потоку і, на межі інтервалу, контрольну точку
+func storeChainEvent(ctx contractapi.TransactionContextInterface, event *SecurityEvent) error {
+	link := AuditChainLink{EventID: event.ID, Hash: event.Hash}
+	if err := putChainState(ctx, chainLinkObjectType, []string{event.Stream, sequenceKey(event.Sequence)}, link); err != nil {
+		return err
+	}
+	head := AuditChainHead{Stream: event.Stream, Sequence: event.Sequence, Hash: event.Hash, EventID: event.ID}
+	if err := putChainState(ctx, chainHeadObjectType, []string{event.Stream}, head); err != nil {
+		return err
+	}
+	if err := appendTreeLeaf(ctx, event.Stream, event.Sequence-1, event.Hash); err != nil {
+		return err
+	}
+
+	if event.Sequence%checkpointInterval == 0 {
+		return putCheckpoint(ctx, event)
+	}
+	return nil
+}
+
+// putCheckpoint фіксує корінь дерева Меркла над останніми checkpointInterval подіями потоку
+func putCheckpoint(ctx contractapi.TransactionContextInterface, event *SecurityEvent) error {
+	from := event.Sequence - checkpointInterval + 1
+	hashes := make([][]byte, 0, checkpointInterval)
+	for sequence := from; sequence < event.Sequence; sequence++ {
+		link, err := readChainLink(ctx, event.Stream, sequence)
+		if err != nil {
+			return err
+		}
+		if link == nil {
+			return fmt.Errorf("ланцюжок потоку %s не містить події %d", event.Stream, sequence)
+		}
+		hash, err := hex.DecodeString(link.Hash)
+		if err != nil {
+			return fmt.Errorf("некоректний хеш події %d: %v", sequence, err)
+		}
+		hashes = append(hashes, hash)
+	}
+	hash, _ := hex.DecodeString(event.Hash)
+	hashes = append(hashes, hash)
+
+	checkpoint := AuditCheckpoint{
+		Stream:       event.Stream,
+		FromSequence: from,
+		ToSequence:   event.Sequence,
+		MerkleRoot:   hex.EncodeToString(merkle.RootHash(hashes)),
+		CreatedAt:    event.Timestamp,
+	}
+	return putChainState(ctx, checkpointObjectType, []string{event.Stream, sequenceKey(event.Sequence)}, checkpoint)
+}
+
+// eventHash обчислює SHA-256 серіалізованої події без поля Hash, hex
+func eventHash(event *SecurityEvent) (string, error) {
+	unsigned := *event
+	unsigned.Hash = ""
+	eventJSON, err := json.Marshal(unsigned)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(eventJSON)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// sequenceKey кодує номер події так, щоб лексикографічний порядок ключів збігався з числовим
+func sequenceKey(sequence uint64) string {
+	return fmt.Sprintf("%020d", sequence)
+}
+
+// readChainHead читає останню подію потоку; порожній потік має нульовий номер
+func readChainHead(ctx contractapi.TransactionContextInterface, stream string) (*AuditChainHead, error) {
+	head := &AuditChainHead{Stream: stream}
+	if _, err := readChainState(ctx, chainHeadObjectType, []string{stream}, head); err != nil {
+		return nil, err
+	}
+	return head, nil
+}
+
+// readChainLink читає запис події потоку з номером sequence; відсутній запис повертається як nil
+func readChainLink(ctx contractapi.TransactionContextInterface, stream string, sequence uint64) (*AuditChainLink, error) {
+	var link AuditChainLink
+	found, err := readChainState(ctx, chainLinkObjectType, []string{stream, sequenceKey(sequence)}, &link)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// readCheckpoint читає контрольну точку потоку; відсутня точка повертається як nil
+func readCheckpoint(ctx contractapi.TransactionContextInterface, stream string, sequence uint64) (*AuditCheckpoint, error) {
+	var checkpoint AuditCheckpoint
+	found, err := readChainState(ctx, checkpointObjectType, []string{stream, sequenceKey(sequence)}, &checkpoint)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+// readChainState читає і десеріалізує запис ланцюжка за складеним ключем
+func readChainState(ctx contractapi.TransactionContextInterface, objectType string, attributes []string, value interface{}) (bool, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return false, fmt.Errorf("помилка створення ключа %s: %v", objectType, err)
+	}
+	valueJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, fmt.Errorf("помилка читання %s: %v", objectType, err)
+	}
+	if valueJSON == nil {
+		return false, nil
+	}
+	if err := json.Unmarshal(valueJSON, value); err != nil {
+		return false, fmt.Errorf("помилка десеріалізації %s: %v", objectType, err)
+	}
+	return true, nil
+}
+
+// putChainState серіалізує і зберігає запис ланцюжка за складеним ключем
+func putChainState(ctx contractapi.TransactionContextInterface, objectType string, attributes []string, value interface{}) error {
+	key, err := ctx.GetStub().CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return fmt.Errorf("помилка створення ключа %s: %v", objectType, err)
+	}
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(key, valueJSON); err != nil {
+		return fmt.Errorf("помилка збереження %s: %v", objectType, err)
+	}
+	return nil
+}
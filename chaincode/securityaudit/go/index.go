@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Типи складених ключів індексів подій. Атрибути ключа: значення поля (крім
+// індексу часу), доба, час події і ідентифікатор; значення - ідентифікатор події.
+// Усередині значення поля записи впорядковані за часом.
+const (
+	actorIndex    = "eventactor"
+	resourceIndex = "eventresource"
+	typeIndex     = "eventtype"
+	timeIndex     = "eventtime"
+)
+
+// Формат доби (UTC), за якою групуються записи індексів
+const timeBucketLayout = "2006-01-02"
+
+// indexEvent записує подію в індекси за актором, ресурсом, типом і часом
+func indexEvent(ctx contractapi.TransactionContextInterface, event *SecurityEvent) error {
+	bucket := timeBucket(event.Timestamp)
+	timestamp := timestampKey(event.Timestamp)
+
+	entries := map[string][]string{
+		actorIndex: {event.Actor, bucket, timestamp, event.ID},
+		typeIndex:  {event.Type, bucket, timestamp, event.ID},
+		timeIndex:  {bucket, timestamp, event.ID},
+	}
+	if event.Resource != "" {
+		entries[resourceIndex] = []string{event.Resource, bucket, timestamp, event.ID}
+	}
+
+	for _, index := range []string{actorIndex, resourceIndex, typeIndex, timeIndex} {
+		attributes, ok := entries[index]
+		if !ok {
+			continue
+		}
+		key, err := ctx.GetStub().CreateCompositeKey(index, attributes)
+		if err != nil {
+			return fmt.Errorf("помилка створення ключа індексу %s: %v", index, err)
+		}
+		if err := ctx.GetStub().PutState(key, []byte(event.ID)); err != nil {
+			return fmt.Errorf("помилка збереження індексу %s: %v", index, err)
+		}
+	}
+	return nil
+}
+
+// ReindexEvents записує в індекси до batchSize подій, починаючи з ключа startKey, і повертає
+// ключ, з якого слід продовжити, або порожній рядок, якщо події закінчилися.
+// Потрібна для подій, записаних до появи індексів; повторний запис індексу нічого не змінює.
+func (s *SmartContract) ReindexEvents(ctx contractapi.TransactionContextInterface, startKey string, batchSize int) (string, error) {
+	if batchSize <= 0 || batchSize > maxPageSize {
+		return "", fmt.Errorf("розмір пакета має бути від 1 до %d", maxPageSize)
+	}
+	if startKey == "" {
+		startKey = eventPrefix
+	}
+	if startKey < eventPrefix || startKey >= eventRangeEnd {
+		return "", fmt.Errorf("ключ %s поза діапазоном подій", startKey)
+	}
+
+	iterator, err := ctx.GetStub().GetStateByRange(startKey, eventRangeEnd)
+	if err != nil {
+		return "", fmt.Errorf("помилка запиту подій: %v", err)
+	}
+	defer iterator.Close()
+
+	for indexed := 0; iterator.HasNext(); indexed++ {
+		kv, err := iterator.Next()
+		if err != nil {
+			return "", fmt.Errorf("помилка читання події: %v", err)
+		}
+		if indexed == batchSize {
+			return kv.Key, nil
+		}
+
+		event, err := decodeEvent(kv.Key, kv.Value)
+		if err != nil {
+			return "", err
+		}
+		if err := indexEvent(ctx, event); err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// timeBucket повертає добу, до якої належить час події
+func timeBucket(timestamp int64) string {
+	return time.Unix(timestamp, 0).UTC().Format(timeBucketLayout)
+}
+
+// timestampKey кодує час події так, щоб лексикографічний порядок ключів збігався з числовим
+func timestampKey(timestamp int64) string {
+	return fmt.Sprintf("%020d", timestamp)
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"blockchain-security/chaincode/common/clock"
+)
+
+// Розміри сторінки результатів запиту подій
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// Найбільша кількість діб, які запит за індексом обходить окремими запитами.
+// Довший інтервал читається з усіх записів індексу і фільтрується за часом.
+const maxQueryBuckets = 31
+
+// Фрагмент помилки, якою пір відхиляє запит CouchDB, якщо стан зберігається в LevelDB
+const richQueryUnsupported = "not supported for leveldb"
+
+// Способи виконання запиту подій
+const (
+	strategyIndex = "index" // складений ключ індексу
+	strategyRich  = "rich"  // запит CouchDB
+)
+
+// richQueryIndexes індекси CouchDB з META-INF/statedb/couchdb/indexes за полем документа події.
+// Назва дизайн-документа індексу - назва індексу з суфіксом Doc.
+var richQueryIndexes = map[string]string{
+	"actor":    "indexActor",
+	"resource": "indexResource",
+	"type":     "indexType",
+}
+
+// EventPage сторінка результатів запиту подій
+type EventPage struct {
+	Events   []*SecurityEvent `json:"events"`
+	Bookmark string           `json:"bookmark"` // закладка наступної сторінки; порожня, якщо подій більше немає
+	Strategy string           `json:"strategy"` // index або rich
+}
+
+// queryPlan спосіб виконання запиту, обраний за фільтром
+type queryPlan struct {
+	strategy string
+	index    string   // індекс запиту; для запиту CouchDB - запасний індекс
+	field    string   // поле документа події, за яким побудовано індекс
+	value    string   // значення поля; порожнє для індексу часу
+	buckets  []string // доби інтервалу запиту; nil - без обмеження за добами
+}
+
+// queryCursor вміст закладки: місце, з якого продовжується запит
+type queryCursor struct {
+	Strategy string `json:"strategy"`
+	Bucket   string `json:"bucket,omitempty"`
+	Position string `json:"position,omitempty"` // закладка Fabric або ключ наступної події
+}
+
+// QueryEventsPage повертає сторінку подій, що відповідають фільтру, і закладку наступної сторінки.
+// Запит з одним із полів actor, resource, eventType виконується за індексом цього поля, з кількома -
+// запитом CouchDB, а якщо стан зберігається в LevelDB, - за індексом першого з них. Запит без
+// цих полів читає індекс часу: по добах, якщо інтервал охоплює не більше maxQueryBuckets діб,
+// інакше - усі записи індексу від найранішого. Події сторінки впорядковані за часом. Закладка дійсна лише з тим самим фільтром; сторінка може містити менше
+// pageSize подій і тоді, коли наступні події є.
+func (s *SmartContract) QueryEventsPage(ctx contractapi.TransactionContextInterface, queryString string) (*EventPage, error) {
+	var query EventQuery
+	if err := json.Unmarshal([]byte(queryString), &query); err != nil {
+		return nil, fmt.Errorf("помилка при розборі параметрів запиту: %v", err)
+	}
+	return s.queryEventsPage(ctx, &query)
+}
+
+// queryEventsPage виконує запит подій способом, обраним за фільтром
+func (s *SmartContract) queryEventsPage(ctx contractapi.TransactionContextInterface, query *EventQuery) (*EventPage, error) {
+	pageSize := query.PageSize
+	if pageSize == 0 {
+		pageSize = query.Limit
+	}
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		return nil, fmt.Errorf("розмір сторінки не може перевищувати %d", maxPageSize)
+	}
+
+	plan, err := planQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := decodeCursor(query.Bookmark)
+	if err != nil {
+		return nil, err
+	}
+	if cursor != nil {
+		// Перша сторінка запиту CouchDB могла бути прочитана із запасного індексу
+		if plan.strategy == strategyRich && cursor.Strategy == strategyIndex {
+			plan.strategy = strategyIndex
+		}
+		if cursor.Strategy != plan.strategy {
+			return nil, fmt.Errorf("закладка не відповідає фільтру запиту")
+		}
+	}
+
+	switch plan.strategy {
+	case strategyRich:
+		page, err := s.richQueryPage(ctx, query, plan, cursor, pageSize)
+		if err == nil || cursor != nil || !strings.Contains(err.Error(), richQueryUnsupported) {
+			return page, err
+		}
+		// LevelDB не підтримує запити CouchDB
+		return s.indexQueryPage(ctx, query, plan, nil, pageSize)
+	default:
+		return s.indexQueryPage(ctx, query, plan, cursor, pageSize)
+	}
+}
+
+// planQuery обирає спосіб виконання запиту. Індекс поля обирається в порядку
+// актор, ресурс, тип: перші з них зазвичай вибірковіші.
+func planQuery(ctx contractapi.TransactionContextInterface, query *EventQuery) (*queryPlan, error) {
+	fields := []struct {
+		index string
+		field string
+		value string
+	}{
+		{actorIndex, "actor", query.Actor},
+		{resourceIndex, "resource", query.Resource},
+		{typeIndex, "type", query.EventType},
+	}
+
+	var plan *queryPlan
+	filters := 0
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		filters++
+		if plan == nil {
+			plan = &queryPlan{strategy: strategyIndex, index: f.index, field: f.field, value: f.value}
+		}
+	}
+
+	buckets, err := queryBuckets(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case filters > 1:
+		plan.strategy = strategyRich
+	case filters == 0:
+		plan = &queryPlan{strategy: strategyIndex, index: timeIndex}
+	}
+	plan.buckets = buckets
+	return plan, nil
+}
+
+// queryBuckets повертає доби інтервалу запиту або nil, якщо інтервал не обмежено
+// чи він довший за maxQueryBuckets діб. Без кінця інтервалу ним є час транзакції.
+func queryBuckets(ctx contractapi.TransactionContextInterface, query *EventQuery) ([]string, error) {
+	if query.StartTime <= 0 && query.EndTime <= 0 {
+		return nil, nil
+	}
+
+	start, end := query.StartTime, query.EndTime
+	if start < 0 {
+		start = 0
+	}
+	if end <= 0 {
+		now, err := clock.Now(ctx.GetStub())
+		if err != nil {
+			return nil, err
+		}
+		end = now
+	}
+	if end < start {
+		return []string{}, nil
+	}
+
+	first, last := start/clock.SecondsPerDay, end/clock.SecondsPerDay
+	if last-first >= maxQueryBuckets {
+		return nil, nil
+	}
+	buckets := make([]string, 0, last-first+1)
+	for day := first; day <= last; day++ {
+		buckets = append(buckets, timeBucket(day*clock.SecondsPerDay))
+	}
+	return buckets, nil
+}
+
+// indexQueryPage читає сторінку подій зі складених ключів індексу, обходячи доби інтервалу по черзі
+func (s *SmartContract) indexQueryPage(ctx contractapi.TransactionContextInterface, query *EventQuery, plan *queryPlan, cursor *queryCursor, pageSize int) (*EventPage, error) {
+	buckets := plan.buckets
+	if buckets == nil {
+		buckets = []string{""}
+	}
+	first, position := 0, ""
+	if cursor != nil {
+		first = -1
+		for i, bucket := range buckets {
+			if bucket == cursor.Bucket {
+				first = i
+			}
+		}
+		if first < 0 {
+			return nil, fmt.Errorf("закладка не відповідає фільтру запиту")
+		}
+		position = cursor.Position
+	}
+
+	page := &EventPage{Events: []*SecurityEvent{}, Strategy: strategyIndex}
+	fetched := 0
+	for _, bucket := range buckets[first:] {
+		var attributes []string
+		if plan.value != "" {
+			attributes = append(attributes, plan.value)
+		}
+		if bucket != "" {
+			attributes = append(attributes, bucket)
+		}
+
+		requested := pageSize - fetched
+		iterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(plan.index, attributes, int32(requested), position)
+		if err != nil {
+			return nil, fmt.Errorf("помилка запиту індексу %s: %v", plan.index, err)
+		}
+		count, done, err := s.readIndexEntries(ctx, iterator, query, page)
+		iterator.Close()
+		if err != nil {
+			return nil, err
+		}
+		fetched += count
+
+		// Записи індексу впорядковані за часом, тож решта подій пізніші за кінець інтервалу
+		if done {
+			return page, nil
+		}
+		if count == requested {
+			page.Bookmark, err = encodeCursor(queryCursor{Strategy: strategyIndex, Bucket: bucket, Position: metadata.Bookmark})
+			return page, err
+		}
+		position = ""
+	}
+	return page, nil
+}
+
+// readIndexEntries додає до сторінки події записів індексу, що відповідають фільтру.
+// Повертає кількість прочитаних записів і ознаку того, що досягнуто кінця інтервалу запиту.
+func (s *SmartContract) readIndexEntries(ctx contractapi.TransactionContextInterface, iterator shim.StateQueryIteratorInterface, query *EventQuery, page *EventPage) (int, bool, error) {
+	count := 0
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return count, false, fmt.Errorf("помилка читання індексу: %v", err)
+		}
+		count++
+
+		event, err := s.GetEvent(ctx, string(kv.Value))
+		if err != nil {
+			return count, false, err
+		}
+		if query.EndTime > 0 && event.Timestamp > query.EndTime {
+			return count, true, nil
+		}
+		if query.matches(event) {
+			page.Events = append(page.Events, event)
+		}
+	}
+	return count, false, nil
+}
+
+// richQueryPage читає сторінку подій запитом CouchDB з індексом поля плану запиту
+func (s *SmartContract) richQueryPage(ctx contractapi.TransactionContextInterface, query *EventQuery, plan *queryPlan, cursor *queryCursor, pageSize int) (*EventPage, error) {
+	selector := map[string]interface{}{}
+	if query.Actor != "" {
+		selector["actor"] = query.Actor
+	}
+	if query.Resource != "" {
+		selector["resource"] = query.Resource
+	}
+	if query.EventType != "" {
+		selector["type"] = query.EventType
+	}
+	// Умова на час потрібна, щоб CouchDB міг використати індекс для сортування
+	timestamp := map[string]interface{}{"$gte": query.StartTime}
+	if query.EndTime > 0 {
+		timestamp["$lte"] = query.EndTime
+	}
+	selector["timestamp"] = timestamp
+
+	index := richQueryIndexes[plan.field]
+	queryJSON, err := json.Marshal(map[string]interface{}{
+		"selector":  selector,
+		"sort":      []map[string]string{{plan.field: "asc"}, {"timestamp": "asc"}},
+		"use_index": []string{"_design/" + index + "Doc", index},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	position := ""
+	if cursor != nil {
+		position = cursor.Position
+	}
+	iterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(string(queryJSON), int32(pageSize), position)
+	if err != nil {
+		return nil, fmt.Errorf("помилка запиту CouchDB: %v", err)
+	}
+	defer iterator.Close()
+
+	page := &EventPage{Events: []*SecurityEvent{}, Strategy: strategyRich}
+	fetched := 0
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("помилка читання події: %v", err)
+		}
+		fetched++
+		event, err := decodeEvent(kv.Key, kv.Value)
+		if err != nil {
+			return nil, err
+		}
+		if query.matches(event) {
+			page.Events = append(page.Events, event)
+		}
+	}
+	if fetched == pageSize {
+		page.Bookmark, err = encodeCursor(queryCursor{Strategy: strategyRich, Position: metadata.Bookmark})
+	}
+	return page, err
+}
+
+// decodeEvent десеріалізує подію, збережену під ключем key
+func decodeEvent(key string, value []byte) (*SecurityEvent, error) {
+	var event SecurityEvent
+	if err := json.Unmarshal(value, &event); err != nil {
+		return nil, fmt.Errorf("помилка десеріалізації події %s: %v", key, err)
+	}
+	return &event, nil
+}
+
+// encodeCursor кодує закладку запиту
+func encodeCursor(cursor queryCursor) (string, error) {
+	cursorJSON, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(cursorJSON), nil
+}
+
+// decodeCursor розбирає закладку запиту; порожня закладка означає першу сторінку
+func decodeCursor(bookmark string) (*queryCursor, error) {
+	if bookmark == "" {
+		return nil, nil
+	}
+	cursorJSON, err := base64.StdEncoding.DecodeString(bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("некоректна закладка: %v", err)
+	}
+	var cursor queryCursor
+	if err := json.Unmarshal(cursorJSON, &cursor); err != nil {
+		return nil, fmt.Errorf("некоректна закладка: %v", err)
+	}
+	return &cursor, nil
+}
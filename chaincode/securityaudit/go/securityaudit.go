@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"blockchain-security/chaincode/common/clock"
+)
+
+// SmartContract представляє смарт-контракт аудиту безпеки
+type SmartContract struct {
+	contractapi.Contract
+}
+
+// SecurityEvent структура події безпеки
+type SecurityEvent struct {
+	ID        string            `json:"id"`
+	Type      string            `json:"type"` // access_check, login, key_operation
+	Timestamp int64             `json:"timestamp"`
+	Actor     string            `json:"actor"`
+	Resource  string            `json:"resource"`
+	Action    string            `json:"action"`
+	Result    string            `json:"result"` // granted, denied, success, failure
+	Metadata  map[string]string `json:"metadata"`
+	Stream    string            `json:"stream"`         // потік ланцюжка хешів: тип події і, якщо задано, ресурс
+	Sequence  uint64            `json:"sequence"`       // номер події в потоці, починаючи з 1
+	PrevHash  string            `json:"prevHash"`       // хеш попередньої події потоку; порожній для першої
+	Hash      string            `json:"hash,omitempty"` // SHA-256 події без цього поля, hex
+}
+
+// EventQuery параметри фільтрації подій
+type EventQuery struct {
+	StartTime int64  `json:"startTime"`
+	EndTime   int64  `json:"endTime"`
+	EventType string `json:"eventType"`
+	Actor     string `json:"actor"`
+	Resource  string `json:"resource"`
+	Limit     int    `json:"limit"` // найбільша кількість подій QueryEvents; розмір сторінки, якщо pageSize не задано
+	PageSize  int    `json:"pageSize"`
+	Bookmark  string `json:"bookmark"` // закладка з попередньої сторінки
+}
+
+// Префікси для ключів у world state
+const (
+	eventPrefix    = "event:"
+	eventRangeEnd  = "event~"
+	auditEventName = "SecurityAuditEvent"
+)
+
+// Роздільник типу події і ресурсу в назві потоку
+const streamSeparator = "/"
+
+// InitLedger ініціалізує стан смарт-контракту
+func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
+	fmt.Println("Контракт аудиту безпеки ініціалізовано")
+	return nil
+}
+
+// RecordEvent записує подію безпеки в кінець ланцюжка хешів її потоку та публікує її як подію чейнкоду.
+// Потік утворюють події одного типу з одним ресурсом, тому в межах блоку впорядковується
+// лише запис подій про той самий ресурс, а події про різні ресурси не конфліктують.
+func (s *SmartContract) RecordEvent(ctx contractapi.TransactionContextInterface, eventType string, actor string, resource string, action string, result string, metadata string) error {
+	if eventType == "" || actor == "" || action == "" {
+		return fmt.Errorf("тип події, актор та дія є обов'язковими")
+	}
+	if strings.Contains(eventType, streamSeparator) {
+		return fmt.Errorf("тип події не може містити %q", streamSeparator)
+	}
+
+	// Ідентифікатор події збігається з ідентифікатором транзакції
+	eventID := ctx.GetStub().GetTxID()
+
+	// Парсимо метадані з JSON рядка
+	metadataMap := make(map[string]string)
+	if metadata != "" {
+		if err := json.Unmarshal([]byte(metadata), &metadataMap); err != nil {
+			return fmt.Errorf("помилка при розборі метаданих: %v", err)
+		}
+	}
+
+	// Час події береться з транзакції, щоб усі піри отримали однаковий запис
+	now, err := clock.Now(ctx.GetStub())
+	if err != nil {
+		return err
+	}
+
+	event := SecurityEvent{
+		ID:        eventID,
+		Type:      eventType,
+		Timestamp: now,
+		Actor:     actor,
+		Resource:  resource,
+		Action:    action,
+		Result:    result,
+		Metadata:  metadataMap,
+		Stream:    eventStream(eventType, resource),
+	}
+
+	// Подія посилається на хеш попередньої події свого потоку
+	if err := chainEvent(ctx, &event); err != nil {
+		return err
+	}
+
+	// Серіалізуємо подію
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	// Зберігаємо в state database
+	if err := ctx.GetStub().PutState(eventPrefix+eventID, eventJSON); err != nil {
+		return fmt.Errorf("помилка збереження події: %v", err)
+	}
+	if err := storeChainEvent(ctx, &event); err != nil {
+		return err
+	}
+	if err := indexEvent(ctx, &event); err != nil {
+		return err
+	}
+
+	// Публікуємо подію для зовнішніх слухачів
+	return ctx.GetStub().SetEvent(auditEventName, eventJSON)
+}
+
+// GetEvent повертає подію за ідентифікатором
+func (s *SmartContract) GetEvent(ctx contractapi.TransactionContextInterface, eventID string) (*SecurityEvent, error) {
+	eventJSON, err := ctx.GetStub().GetState(eventPrefix + eventID)
+	if err != nil {
+		return nil, fmt.Errorf("помилка читання події: %v", err)
+	}
+	if eventJSON == nil {
+		return nil, fmt.Errorf("подія %s не існує", eventID)
+	}
+
+	var event SecurityEvent
+	if err := json.Unmarshal(eventJSON, &event); err != nil {
+		return nil, fmt.Errorf("помилка десеріалізації події: %v", err)
+	}
+	return &event, nil
+}
+
+// QueryEvents повертає події, що відповідають фільтру, впорядковані за часом. Limit обмежує
+// кількість подій, нульовий - без обмеження. Сторінки QueryEventsPage читаються за закладками,
+// доки не набереться limit подій або події не закінчаться.
+func (s *SmartContract) QueryEvents(ctx contractapi.TransactionContextInterface, queryString string) ([]*SecurityEvent, error) {
+	var query EventQuery
+	if err := json.Unmarshal([]byte(queryString), &query); err != nil {
+		return nil, fmt.Errorf("помилка при розборі параметрів запиту: %v", err)
+	}
+	limit := query.Limit
+	query.PageSize = maxPageSize
+	if limit > 0 && limit < maxPageSize {
+		query.PageSize = limit
+	}
+
+	events := []*SecurityEvent{}
+	for {
+		page, err := s.queryEventsPage(ctx, &query)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, page.Events...)
+		if page.Bookmark == "" || (limit > 0 && len(events) >= limit) {
+			break
+		}
+		query.Bookmark = page.Bookmark
+	}
+
+	// Сторінки впорядковані за часом і продовжують одна одну, тому зайві події лише відкидаються
+	if limit > 0 && len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+// matches перевіряє, чи відповідає подія фільтру
+func (q *EventQuery) matches(event *SecurityEvent) bool {
+	if event.Timestamp < q.StartTime {
+		return false
+	}
+	if q.EndTime > 0 && event.Timestamp > q.EndTime {
+		return false
+	}
+	if q.EventType != "" && event.Type != q.EventType {
+		return false
+	}
+	if q.Actor != "" && event.Actor != q.Actor {
+		return false
+	}
+	if q.Resource != "" && event.Resource != q.Resource {
+		return false
+	}
+	return true
+}
+
+func main() {
+	chaincode, err := contractapi.NewChaincode(new(SmartContract))
+	if err != nil {
+		fmt.Printf("Помилка створення чейнкоду: %s", err.Error())
+		return
+	}
+
+	if err := chaincode.Start(); err != nil {
+		fmt.Printf("Помилка запуску чейнкоду: %s", err.Error())
+	}
+}
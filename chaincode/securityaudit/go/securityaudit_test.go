@@ -2,14 +2,22 @@
 package main
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+
+	"blockchain-security/chaincode/common/clock"
+	"blockchain-security/chaincode/common/merkle"
 )
 
 // MockStub імітує ChainCodeStubInterface
@@ -38,16 +46,40 @@ func (s *MockStub) GetTxID() string {
 	return args.String(0)
 }
 
+func (s *MockStub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	args := s.Called()
+	return args.Get(0).(*timestamp.Timestamp), args.Error(1)
+}
+
 func (s *MockStub) SetEvent(name string, payload []byte) error {
 	args := s.Called(name, payload)
 	return args.Error(0)
 }
 
+// CreateCompositeKey не записується у виклики мока, щоб не зсувати індекси Calls
+func (s *MockStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return shim.CreateCompositeKey(objectType, attributes)
+}
+
 func (s *MockStub) GetStateByRange(startKey string, endKey string) (shim.StateQueryIteratorInterface, error) {
 	args := s.Called(startKey, endKey)
 	return args.Get(0).(shim.StateQueryIteratorInterface), args.Error(1)
 }
 
+func (s *MockStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	args := s.Called(objectType, keys, pageSize, bookmark)
+	iterator, _ := args.Get(0).(shim.StateQueryIteratorInterface)
+	metadata, _ := args.Get(1).(*pb.QueryResponseMetadata)
+	return iterator, metadata, args.Error(2)
+}
+
+func (s *MockStub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	args := s.Called(query, pageSize, bookmark)
+	iterator, _ := args.Get(0).(shim.StateQueryIteratorInterface)
+	metadata, _ := args.Get(1).(*pb.QueryResponseMetadata)
+	return iterator, metadata, args.Error(2)
+}
+
 // MockQueryIterator імітує StateQueryIteratorInterface
 type MockQueryIterator struct {
 	mock.Mock
@@ -65,11 +97,11 @@ func (i *MockQueryIterator) HasNext() bool {
 	return i.Index < len(i.Results)
 }
 
-func (i *MockQueryIterator) Next() (*shim.KV, error) {
+func (i *MockQueryIterator) Next() (*queryresult.KV, error) {
 	if i.Index < len(i.Results) {
 		result := i.Results[i.Index]
 		i.Index++
-		return &shim.KV{
+		return &queryresult.KV{
 			Key:   result.Key,
 			Value: result.Value,
 		}, nil
@@ -92,6 +124,12 @@ func (c *MockContext) GetStub() shim.ChaincodeStubInterface {
 	return args.Get(0).(shim.ChaincodeStubInterface)
 }
 
+// chainKey повертає складений ключ запису ланцюжка подій
+func chainKey(objectType string, attributes ...string) string {
+	key, _ := shim.CreateCompositeKey(objectType, attributes)
+	return key
+}
+
 // Тестування RecordEvent
 func TestRecordEvent(t *testing.T) {
 	// Ініціалізація мок-об'єктів
@@ -109,6 +147,8 @@ func TestRecordEvent(t *testing.T) {
 	
 	// Очікуємо виклики методів
 	mockStub.On("GetTxID").Return("tx123")
+	mockStub.On("GetTxTimestamp").Return(&timestamp.Timestamp{Seconds: 1714564800}, nil)
+	mockStub.On("GetState", chainKey("audithead", "access_check/resource123")).Return([]byte(nil), nil)
 	mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
 	mockStub.On("SetEvent", "SecurityAuditEvent", mock.Anything).Return(nil)
 
@@ -122,7 +162,7 @@ func TestRecordEvent(t *testing.T) {
 	mockContext.AssertExpectations(t)
 	
 	// Перевірка, що PutState був викликаний з коректними даними
-	call := mockStub.Calls[1] // Другий виклик - це PutState
+	call := mockStub.Calls[3] // Четвертий виклик - це PutState
 	actualKey := call.Arguments[0].(string)
 	actualValue := call.Arguments[1].([]byte)
 	
@@ -141,7 +181,7 @@ func TestRecordEvent(t *testing.T) {
 	assert.Equal(t, resource, event.Resource)
 	assert.Equal(t, action, event.Action)
 	assert.Equal(t, result, event.Result)
-	assert.NotZero(t, event.Timestamp)
+	assert.Equal(t, int64(1714564800), event.Timestamp)
 	
 	// Перевірка метаданих
 	var expectedMetadata map[string]string
@@ -150,6 +190,35 @@ func TestRecordEvent(t *testing.T) {
 	assert.Equal(t, expectedMetadata, event.Metadata)
 }
 
+// Тестування детермінованості RecordEvent на різних пірах
+func TestRecordEventDeterministic(t *testing.T) {
+	txTimestamp := &timestamp.Timestamp{Seconds: 1714564800, Nanos: 123}
+
+	// Кожен пір виконує транзакцію незалежно, але з однаковим заголовком
+	endorse := func() []byte {
+		mockStub := new(MockStub)
+		mockContext := new(MockContext)
+		mockContext.On("GetStub").Return(mockStub)
+		mockStub.On("GetTxID").Return("tx123")
+		mockStub.On("GetTxTimestamp").Return(txTimestamp, nil)
+		mockStub.On("GetState", chainKey("audithead", "login/system")).Return([]byte(nil), nil)
+		mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+		mockStub.On("SetEvent", "SecurityAuditEvent", mock.Anything).Return(nil)
+
+		contract := new(SmartContract)
+		err := contract.RecordEvent(mockContext, "login", "admin", "system", "login", "success", `{"source":"web"}`)
+		assert.Nil(t, err)
+
+		return mockStub.Calls[3].Arguments[1].([]byte)
+	}
+
+	first := endorse()
+	time.Sleep(1100 * time.Millisecond)
+	second := endorse()
+
+	assert.Equal(t, first, second)
+}
+
 // Тестування QueryEvents
 func TestQueryEvents(t *testing.T) {
 	// Ініціалізація мок-об'єктів
@@ -157,7 +226,7 @@ func TestQueryEvents(t *testing.T) {
 	mockContext := new(MockContext)
 	mockContext.On("GetStub").Return(mockStub)
 
-	// Підготовка тестових даних для відповіді від GetStateByRange
+	// Підготовка тестових даних для відповіді від індексу часу
 	events := []SecurityEvent{
 		{
 			ID:        "event1",
@@ -181,14 +250,15 @@ func TestQueryEvents(t *testing.T) {
 		},
 	}
 	
-	// Створення серіалізованих подій для мок-відповіді
+	// Записи індексу часу містять ідентифікатори подій
 	var mockResults []KVPair
-	for i, event := range events {
+	for _, event := range events {
 		eventJSON, _ := json.Marshal(event)
 		mockResults = append(mockResults, KVPair{
-			Key:   fmt.Sprintf("event:%d", i),
-			Value: eventJSON,
+			Key:   event.ID,
+			Value: []byte(event.ID),
 		})
+		mockStub.On("GetState", "event:"+event.ID).Return(eventJSON, nil)
 	}
 	
 	// Створення мок-ітератора
@@ -196,8 +266,8 @@ func TestQueryEvents(t *testing.T) {
 		Results: mockResults,
 	}
 	
-	// Налаштування поведінки мок-об'єкта
-	mockStub.On("GetStateByRange", "event:", "event~").Return(mockIterator, nil)
+	// Інтервал довший за maxQueryBuckets діб читається з усіх записів індексу часу
+	mockStub.On("GetStateByPartialCompositeKeyWithPagination", "eventtime", []string(nil), int32(10), "").Return(mockIterator, &pb.QueryResponseMetadata{FetchedRecordsCount: 2}, nil)
 	
 	// Створення параметрів запиту
 	queryString := `{"startTime": 0, "endTime": 9999999999, "limit": 10}`
@@ -228,6 +298,9 @@ func TestQueryEventsWithFilters(t *testing.T) {
 		expectedEvents int
 		eventType      string
 		actor          string
+		index          string   // індекс, за яким виконується запит з одним полем
+		prefix         []string // атрибути складеного ключа запиту за індексом
+		selector       string   // запит CouchDB для кількох полів
 	}{
 		{
 			name:           "Фільтр за типом події",
@@ -235,6 +308,8 @@ func TestQueryEventsWithFilters(t *testing.T) {
 			expectedEvents: 1,
 			eventType:      "login",
 			actor:          "",
+			index:          "eventtype",
+			prefix:         []string{"login"},
 		},
 		{
 			name:           "Фільтр за користувачем",
@@ -242,6 +317,8 @@ func TestQueryEventsWithFilters(t *testing.T) {
 			expectedEvents: 1,
 			eventType:      "",
 			actor:          "admin",
+			index:          "eventactor",
+			prefix:         []string{"admin"},
 		},
 		{
 			name:           "Комбінований фільтр",
@@ -249,6 +326,7 @@ func TestQueryEventsWithFilters(t *testing.T) {
 			expectedEvents: 1,
 			eventType:      "access_check",
 			actor:          "user123",
+			selector:       `{"selector":{"actor":"user123","timestamp":{"$gte":0,"$lte":9999999999},"type":"access_check"},"sort":[{"actor":"asc"},{"timestamp":"asc"}],"use_index":["_design/indexActorDoc","indexActor"]}`,
 		},
 	}
 	
@@ -284,24 +362,24 @@ func TestQueryEventsWithFilters(t *testing.T) {
                 },
             }
             
-            // Створення серіалізованих подій для мок-відповіді
-            var mockResults []KVPair
-            for i, event := range events {
+            // Записи індексу містять ідентифікатори подій, запит CouchDB повертає самі події;
+            // мок повертає всі події, відбір за фільтром перевіряється в контракті
+            var indexResults, richResults []KVPair
+            for _, event := range events {
                 eventJSON, _ := json.Marshal(event)
-                mockResults = append(mockResults, KVPair{
-                    Key:   fmt.Sprintf("event:%d", i),
-                    Value: eventJSON,
-                })
+                indexResults = append(indexResults, KVPair{Key: event.ID, Value: []byte(event.ID)})
+                richResults = append(richResults, KVPair{Key: "event:" + event.ID, Value: eventJSON})
+                mockStub.On("GetState", "event:"+event.ID).Return(eventJSON, nil).Maybe()
             }
+            metadata := &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(events))}
             
-            // Створення мок-ітератора
-            mockIterator := &MockQueryIterator{
-                Results: mockResults,
+            // Запит з одним полем читає індекс цього поля, з кількома - виконується в CouchDB
+            if tc.selector != "" {
+                mockStub.On("GetQueryResultWithPagination", tc.selector, int32(10), "").Return(&MockQueryIterator{Results: richResults}, metadata, nil)
+            } else {
+                mockStub.On("GetStateByPartialCompositeKeyWithPagination", tc.index, tc.prefix, int32(10), "").Return(&MockQueryIterator{Results: indexResults}, metadata, nil)
             }
             
-            // Налаштування поведінки мок-об'єкта
-            mockStub.On("GetStateByRange", "event:", "event~").Return(mockIterator, nil)
-            
             // Виклик методу з параметрами фільтрації
             contract := new(SmartContract)
             result, err := contract.QueryEvents(mockContext, tc.queryParams)
@@ -328,4 +406,603 @@ func TestQueryEventsWithFilters(t *testing.T) {
             mockContext.AssertExpectations(t)
         })
     }
-}
\ No newline at end of file
+}
+
+// buildChain будує стан потоку login/system з n подій так, як його записує RecordEvent
+func buildChain(t *testing.T, n int) (map[string][]byte, []SecurityEvent) {
+	state := map[string][]byte{}
+	events := make([]SecurityEvent, 0, n)
+	prevHash := ""
+	var hashes [][]byte
+	for i := 1; i <= n; i++ {
+		event := SecurityEvent{
+			ID:        fmt.Sprintf("tx%d", i),
+			Type:      "login",
+			Timestamp: 1714564800 + int64(i),
+			Actor:     "admin",
+			Resource:  "system",
+			Action:    "login",
+			Result:    "success",
+			Metadata:  map[string]string{},
+			Stream:    "login/system",
+			Sequence:  uint64(i),
+			PrevHash:  prevHash,
+		}
+		hash, err := eventHash(&event)
+		assert.Nil(t, err)
+		event.Hash = hash
+		prevHash = hash
+
+		state["event:"+event.ID], _ = json.Marshal(event)
+		state[chainKey("auditchain", "login/system", sequenceKey(event.Sequence))], _ = json.Marshal(AuditChainLink{EventID: event.ID, Hash: hash})
+		hashBytes, _ := hex.DecodeString(hash)
+		hashes = append(hashes, hashBytes)
+		if i%checkpointInterval == 0 {
+			state[chainKey("auditcheckpoint", "login/system", sequenceKey(event.Sequence))], _ = json.Marshal(AuditCheckpoint{
+				Stream:       "login/system",
+				FromSequence: uint64(i - checkpointInterval + 1),
+				ToSequence:   uint64(i),
+				MerkleRoot:   hex.EncodeToString(merkle.RootHash(hashes)),
+				CreatedAt:    event.Timestamp,
+			})
+			hashes = nil
+		}
+		events = append(events, event)
+	}
+	state[chainKey("audithead", "login/system")], _ = json.Marshal(AuditChainHead{Stream: "login/system", Sequence: uint64(n), Hash: prevHash, EventID: fmt.Sprintf("tx%d", n)})
+
+	// Вузли повних піддерев дерева Меркла потоку
+	var leaves [][]byte
+	for _, event := range events {
+		hashBytes, _ := hex.DecodeString(event.Hash)
+		leaves = append(leaves, hashBytes)
+	}
+	for level := 0; 1<<level <= n; level++ {
+		size := 1 << level
+		for index := 0; (index+1)*size <= n; index++ {
+			state[chainKey("auditnode", "login/system", fmt.Sprintf("%02d", level), sequenceKey(uint64(index)))] = merkle.RootHash(leaves[index*size : (index+1)*size])
+		}
+	}
+	return state, events
+}
+
+// newStateStub створює мок зі станом, який повертає GetState
+func newStateStub(state map[string][]byte) (*MockStub, *MockContext) {
+	mockStub := new(MockStub)
+	mockContext := new(MockContext)
+	mockContext.On("GetStub").Return(mockStub)
+	for key, value := range state {
+		mockStub.On("GetState", key).Return(value, nil)
+	}
+	mockStub.On("GetState", mock.Anything).Return([]byte(nil), nil)
+	return mockStub, mockContext
+}
+
+// Тестування зв'язку нової події з попередньою подією потоку
+func TestRecordEventChain(t *testing.T) {
+	state, events := buildChain(t, 3)
+	mockStub, mockContext := newStateStub(state)
+	mockStub.On("GetTxID").Return("tx4")
+	mockStub.On("GetTxTimestamp").Return(&timestamp.Timestamp{Seconds: 1714564900}, nil)
+	mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+	mockStub.On("SetEvent", "SecurityAuditEvent", mock.Anything).Return(nil)
+
+	contract := new(SmartContract)
+	err := contract.RecordEvent(mockContext, "login", "admin", "system", "login", "success", "")
+	assert.Nil(t, err)
+
+	// Подія отримує наступний номер і хеш попередньої події потоку
+	var event SecurityEvent
+	assert.Nil(t, json.Unmarshal(mockStub.Calls[3].Arguments[1].([]byte), &event))
+	assert.Equal(t, "login/system", event.Stream)
+	assert.Equal(t, uint64(4), event.Sequence)
+	assert.Equal(t, events[2].Hash, event.PrevHash)
+	hash, err := eventHash(&event)
+	assert.Nil(t, err)
+	assert.Equal(t, hash, event.Hash)
+
+	// Запис ланцюжка і нова остання подія потоку
+	assert.Equal(t, chainKey("auditchain", "login/system", sequenceKey(4)), mockStub.Calls[4].Arguments[0])
+	assert.Equal(t, chainKey("audithead", "login/system"), mockStub.Calls[5].Arguments[0])
+	var head AuditChainHead
+	assert.Nil(t, json.Unmarshal(mockStub.Calls[5].Arguments[1].([]byte), &head))
+	assert.Equal(t, AuditChainHead{Stream: "login/system", Sequence: 4, Hash: hash, EventID: "tx4"}, head)
+}
+
+// Тестування розподілу подій за потоками
+func TestRecordEventStreams(t *testing.T) {
+	testCases := []struct {
+		name      string
+		eventType string
+		resource  string
+		stream    string
+		wantErr   bool
+	}{
+		{name: "Подія про ресурс", eventType: "key_operation", resource: "key1", stream: "key_operation/key1"},
+		{name: "Подія про інший ресурс", eventType: "key_operation", resource: "key2", stream: "key_operation/key2"},
+		{name: "Подія без ресурсу", eventType: "login", stream: "login"},
+		{name: "Тип події з роздільником", eventType: "key/operation", resource: "key1", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStub, mockContext := newStateStub(map[string][]byte{})
+			mockStub.On("GetTxID").Return("tx1")
+			mockStub.On("GetTxTimestamp").Return(&timestamp.Timestamp{Seconds: 1714564900}, nil)
+			mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+			mockStub.On("SetEvent", "SecurityAuditEvent", mock.Anything).Return(nil)
+
+			contract := new(SmartContract)
+			err := contract.RecordEvent(mockContext, tc.eventType, "admin", tc.resource, "rotate", "success", "")
+			if tc.wantErr {
+				assert.NotNil(t, err)
+				mockStub.AssertNotCalled(t, "PutState", mock.Anything, mock.Anything)
+				return
+			}
+			assert.Nil(t, err)
+
+			// Кожен потік має власну останню подію, тому записи про різні ресурси не конфліктують
+			mockStub.AssertCalled(t, "GetState", chainKey("audithead", tc.stream))
+			assert.Equal(t, chainKey("audithead", tc.stream), mockStub.Calls[5].Arguments[0])
+			var head AuditChainHead
+			assert.Nil(t, json.Unmarshal(mockStub.Calls[5].Arguments[1].([]byte), &head))
+			assert.Equal(t, tc.stream, head.Stream)
+			assert.Equal(t, uint64(1), head.Sequence)
+		})
+	}
+}
+
+// Тестування контрольної точки на межі інтервалу
+func TestRecordEventCheckpoint(t *testing.T) {
+	state, _ := buildChain(t, checkpointInterval-1)
+	mockStub, mockContext := newStateStub(state)
+	mockStub.On("GetTxID").Return("tx100")
+	mockStub.On("GetTxTimestamp").Return(&timestamp.Timestamp{Seconds: 1714564900}, nil)
+	mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+	mockStub.On("SetEvent", "SecurityAuditEvent", mock.Anything).Return(nil)
+
+	contract := new(SmartContract)
+	err := contract.RecordEvent(mockContext, "login", "admin", "system", "login", "success", "")
+	assert.Nil(t, err)
+
+	// Після запису в стан потік з контрольною точкою проходить перевірку
+	for _, call := range mockStub.Calls {
+		if call.Method == "PutState" {
+			state[call.Arguments[0].(string)] = call.Arguments[1].([]byte)
+		}
+	}
+	checkpointJSON := state[chainKey("auditcheckpoint", "login/system", sequenceKey(checkpointInterval))]
+	var checkpoint AuditCheckpoint
+	assert.Nil(t, json.Unmarshal(checkpointJSON, &checkpoint))
+	assert.Equal(t, uint64(1), checkpoint.FromSequence)
+	assert.Equal(t, uint64(checkpointInterval), checkpoint.ToSequence)
+
+	_, mockContext = newStateStub(state)
+	result, err := contract.VerifyAuditChain(mockContext, "login/system", 0, 0)
+	assert.Nil(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, checkpointInterval, result.Verified)
+	assert.Equal(t, 1, result.CheckpointsVerified)
+}
+
+// Тестування виявлення першого розриву ланцюжка
+func TestVerifyAuditChain(t *testing.T) {
+	testCases := []struct {
+		name          string
+		tamper        func(state map[string][]byte, events []SecurityEvent)
+		from          uint64
+		valid         bool
+		breakSequence uint64
+	}{
+		{name: "Цілісний ланцюжок", tamper: func(map[string][]byte, []SecurityEvent) {}, valid: true},
+		{
+			name: "Змінено вміст події",
+			tamper: func(state map[string][]byte, events []SecurityEvent) {
+				event := events[4]
+				event.Result = "failure"
+				state["event:"+event.ID], _ = json.Marshal(event)
+			},
+			breakSequence: 5,
+		},
+		{
+			name: "Подію замінено з перерахованим хешем",
+			tamper: func(state map[string][]byte, events []SecurityEvent) {
+				event := events[4]
+				event.Actor = "intruder"
+				event.Hash, _ = eventHash(&event)
+				state["event:"+event.ID], _ = json.Marshal(event)
+				state[chainKey("auditchain", "login/system", sequenceKey(5))], _ = json.Marshal(AuditChainLink{EventID: event.ID, Hash: event.Hash})
+			},
+			breakSequence: 6,
+		},
+		{
+			name: "Подію видалено",
+			tamper: func(state map[string][]byte, events []SecurityEvent) {
+				delete(state, "event:"+events[6].ID)
+			},
+			breakSequence: 7,
+		},
+		{
+			name: "Розрив перед початком діапазону",
+			tamper: func(state map[string][]byte, events []SecurityEvent) {
+				delete(state, chainKey("auditchain", "login/system", sequenceKey(2)))
+			},
+			from:          3,
+			breakSequence: 2,
+		},
+		{
+			name: "Змінено останню подію потоку",
+			tamper: func(state map[string][]byte, events []SecurityEvent) {
+				state[chainKey("audithead", "login/system")], _ = json.Marshal(AuditChainHead{Stream: "login/system", Sequence: 10, Hash: events[8].Hash})
+			},
+			breakSequence: 10,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			state, events := buildChain(t, 10)
+			tc.tamper(state, events)
+			_, mockContext := newStateStub(state)
+
+			contract := new(SmartContract)
+			result, err := contract.VerifyAuditChain(mockContext, "login/system", tc.from, 0)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.valid, result.Valid)
+			assert.Equal(t, tc.breakSequence, result.BreakSequence)
+			assert.Equal(t, uint64(10), result.To)
+			if !tc.valid {
+				assert.NotEmpty(t, result.Reason)
+			}
+		})
+	}
+}
+
+// Тестування виявлення зміненої контрольної точки
+func TestVerifyAuditChainCheckpoint(t *testing.T) {
+	state, _ := buildChain(t, checkpointInterval+5)
+	state[chainKey("auditcheckpoint", "login/system", sequenceKey(checkpointInterval))], _ = json.Marshal(AuditCheckpoint{
+		Stream:       "login/system",
+		FromSequence: 1,
+		ToSequence:   checkpointInterval,
+		MerkleRoot:   hex.EncodeToString(merkle.RootHash(nil)),
+	})
+
+	contract := new(SmartContract)
+
+	// Діапазон, що не покриває інтервал контрольної точки повністю, її не перевіряє
+	_, mockContext := newStateStub(state)
+	result, err := contract.VerifyAuditChain(mockContext, "login/system", 2, 0)
+	assert.Nil(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, 0, result.CheckpointsVerified)
+
+	_, mockContext = newStateStub(state)
+	result, err = contract.VerifyAuditChain(mockContext, "login/system", 1, 0)
+	assert.Nil(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, uint64(checkpointInterval), result.BreakSequence)
+}
+
+// eventLeaves повертає хеші подій як дані листків дерева потоку
+func eventLeaves(events []SecurityEvent) [][]byte {
+	leaves := make([][]byte, len(events))
+	for i, event := range events {
+		leaves[i], _ = hex.DecodeString(event.Hash)
+	}
+	return leaves
+}
+
+// Тестування вузлів дерева, які завершує нова подія
+func TestRecordEventTree(t *testing.T) {
+	state, _ := buildChain(t, 3)
+	mockStub, mockContext := newStateStub(state)
+	mockStub.On("GetTxID").Return("tx4")
+	mockStub.On("GetTxTimestamp").Return(&timestamp.Timestamp{Seconds: 1714564900}, nil)
+	mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+	mockStub.On("SetEvent", "SecurityAuditEvent", mock.Anything).Return(nil)
+
+	contract := new(SmartContract)
+	err := contract.RecordEvent(mockContext, "login", "admin", "system", "login", "success", "")
+	assert.Nil(t, err)
+
+	for _, call := range mockStub.Calls {
+		if call.Method == "PutState" {
+			state[call.Arguments[0].(string)] = call.Arguments[1].([]byte)
+		}
+	}
+	var event SecurityEvent
+	assert.Nil(t, json.Unmarshal(state["event:tx4"], &event))
+	_, events := buildChain(t, 3)
+	leaves := eventLeaves(append(events, event))
+
+	// Четвертий листок завершує піддерева з двох і чотирьох листків
+	assert.Equal(t, merkle.RootHash(leaves[3:4]), state[chainKey("auditnode", "login/system", "00", sequenceKey(3))])
+	assert.Equal(t, merkle.RootHash(leaves[2:4]), state[chainKey("auditnode", "login/system", "01", sequenceKey(1))])
+	assert.Equal(t, merkle.RootHash(leaves), state[chainKey("auditnode", "login/system", "02", sequenceKey(0))])
+
+	_, mockContext = newStateStub(state)
+	head, err := contract.GetTreeHead(mockContext, "login/system")
+	assert.Nil(t, err)
+	assert.Equal(t, &TreeHead{Stream: "login/system", TreeSize: 4, RootHash: hex.EncodeToString(merkle.RootHash(leaves))}, head)
+}
+
+// Тестування доказу включення події
+func TestGetInclusionProof(t *testing.T) {
+	state, events := buildChain(t, 7)
+	_, mockContext := newStateStub(state)
+	leaves := eventLeaves(events)
+
+	contract := new(SmartContract)
+	proof, err := contract.GetInclusionProof(mockContext, "tx3", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), proof.LeafIndex)
+	assert.Equal(t, uint64(7), proof.TreeSize)
+	assert.Equal(t, events[2].Hash, proof.EventHash)
+	assert.Equal(t, hex.EncodeToString(merkle.RootHash(leaves)), proof.RootHash)
+	assert.Equal(t, []string{
+		hex.EncodeToString(merkle.RootHash(leaves[3:4])),
+		hex.EncodeToString(merkle.RootHash(leaves[0:2])),
+		hex.EncodeToString(merkle.RootHash(leaves[4:7])),
+	}, proof.AuditPath)
+
+	// Доказ для меншого дерева, яке бачив аудитор
+	proof, err = contract.GetInclusionProof(mockContext, "tx3", 4)
+	assert.Nil(t, err)
+	assert.Equal(t, hex.EncodeToString(merkle.RootHash(leaves[:4])), proof.RootHash)
+	assert.Len(t, proof.AuditPath, 2)
+
+	// Дерево, яке ще не містить події, або більше за потік
+	_, err = contract.GetInclusionProof(mockContext, "tx3", 2)
+	assert.NotNil(t, err)
+	_, err = contract.GetInclusionProof(mockContext, "tx3", 8)
+	assert.NotNil(t, err)
+	_, err = contract.GetInclusionProof(mockContext, "tx99", 0)
+	assert.NotNil(t, err)
+}
+
+// Тестування доказу узгодженості дерев потоку
+func TestGetConsistencyProof(t *testing.T) {
+	state, events := buildChain(t, 7)
+	_, mockContext := newStateStub(state)
+	leaves := eventLeaves(events)
+
+	contract := new(SmartContract)
+	proof, err := contract.GetConsistencyProof(mockContext, "login/system", 3, 7)
+	assert.Nil(t, err)
+	assert.Equal(t, hex.EncodeToString(merkle.RootHash(leaves[:3])), proof.OldRoot)
+	assert.Equal(t, hex.EncodeToString(merkle.RootHash(leaves)), proof.NewRoot)
+	assert.Equal(t, []string{
+		hex.EncodeToString(merkle.RootHash(leaves[2:3])),
+		hex.EncodeToString(merkle.RootHash(leaves[3:4])),
+		hex.EncodeToString(merkle.RootHash(leaves[0:2])),
+		hex.EncodeToString(merkle.RootHash(leaves[4:7])),
+	}, proof.Proof)
+
+	_, err = contract.GetConsistencyProof(mockContext, "login/system", 3, 8)
+	assert.NotNil(t, err)
+	_, err = contract.GetConsistencyProof(mockContext, "login/system", 5, 4)
+	assert.NotNil(t, err)
+}
+
+// Тестування запису подій в індекси
+func TestRecordEventIndexes(t *testing.T) {
+	mockStub := new(MockStub)
+	mockContext := new(MockContext)
+	mockContext.On("GetStub").Return(mockStub)
+	mockStub.On("GetTxID").Return("tx123")
+	mockStub.On("GetTxTimestamp").Return(&timestamp.Timestamp{Seconds: 1714564800}, nil)
+	mockStub.On("GetState", mock.Anything).Return([]byte(nil), nil)
+	mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+	mockStub.On("SetEvent", "SecurityAuditEvent", mock.Anything).Return(nil)
+
+	contract := new(SmartContract)
+	err := contract.RecordEvent(mockContext, "login", "admin", "system", "login", "success", "")
+	assert.Nil(t, err)
+
+	puts := map[string][]byte{}
+	for _, call := range mockStub.Calls {
+		if call.Method == "PutState" {
+			puts[call.Arguments[0].(string)] = call.Arguments[1].([]byte)
+		}
+	}
+	ts := fmt.Sprintf("%020d", 1714564800)
+	for _, key := range []string{
+		chainKey("eventactor", "admin", "2024-05-01", ts, "tx123"),
+		chainKey("eventresource", "system", "2024-05-01", ts, "tx123"),
+		chainKey("eventtype", "login", "2024-05-01", ts, "tx123"),
+		chainKey("eventtime", "2024-05-01", ts, "tx123"),
+	} {
+		assert.Equal(t, []byte("tx123"), puts[key])
+	}
+}
+
+// indexedEvents повертає стан з подіями та записи індексу з їх ідентифікаторами
+func indexedEvents(events ...SecurityEvent) (map[string][]byte, []KVPair) {
+	state := map[string][]byte{}
+	var entries []KVPair
+	for _, event := range events {
+		state["event:"+event.ID], _ = json.Marshal(event)
+		entries = append(entries, KVPair{Key: event.ID, Value: []byte(event.ID)})
+	}
+	return state, entries
+}
+
+// Тестування сторінок запиту за індексом з обходом діб
+func TestQueryEventsPageIndex(t *testing.T) {
+	day := int64(1714521600) // 2024-05-01 00:00 UTC
+	newEvent := func(id string, ts int64) SecurityEvent {
+		return SecurityEvent{ID: id, Type: "login", Timestamp: ts, Actor: "admin", Resource: "system", Action: "login", Result: "success"}
+	}
+	state, entries := indexedEvents(newEvent("a", day+10), newEvent("b", day+20), newEvent("c", day+30), newEvent("d", day+clock.SecondsPerDay+10))
+	mockStub, mockContext := newStateStub(state)
+	first := []string{"admin", "2024-05-01"}
+	second := []string{"admin", "2024-05-02"}
+	mockStub.On("GetStateByPartialCompositeKeyWithPagination", "eventactor", first, int32(2), "").Return(&MockQueryIterator{Results: entries[0:2]}, &pb.QueryResponseMetadata{FetchedRecordsCount: 2, Bookmark: "b1"}, nil)
+	mockStub.On("GetStateByPartialCompositeKeyWithPagination", "eventactor", first, int32(2), "b1").Return(&MockQueryIterator{Results: entries[2:3]}, &pb.QueryResponseMetadata{FetchedRecordsCount: 1}, nil)
+	mockStub.On("GetStateByPartialCompositeKeyWithPagination", "eventactor", second, int32(1), "").Return(&MockQueryIterator{Results: entries[3:4]}, &pb.QueryResponseMetadata{FetchedRecordsCount: 1, Bookmark: "b2"}, nil)
+	mockStub.On("GetStateByPartialCompositeKeyWithPagination", "eventactor", second, int32(2), "b2").Return(&MockQueryIterator{}, &pb.QueryResponseMetadata{}, nil)
+
+	contract := new(SmartContract)
+	var ids []string
+	bookmark := ""
+	for pages := 0; pages < 3; pages++ {
+		query := fmt.Sprintf(`{"actor": "admin", "startTime": %d, "endTime": %d, "pageSize": 2, "bookmark": %q}`, day, day+2*clock.SecondsPerDay-1, bookmark)
+		page, err := contract.QueryEventsPage(mockContext, query)
+		assert.Nil(t, err)
+		assert.Equal(t, "index", page.Strategy)
+		for _, event := range page.Events {
+			ids = append(ids, event.ID)
+		}
+		bookmark = page.Bookmark
+		if bookmark == "" {
+			break
+		}
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d"}, ids)
+	assert.Empty(t, bookmark)
+
+	// Закладка іншого фільтра
+	_, err := contract.QueryEventsPage(mockContext, `{"eventType": "login", "bookmark": "eyJzdHJhdGVneSI6InNjYW4ifQ=="}`)
+	assert.NotNil(t, err)
+	_, err = contract.QueryEventsPage(mockContext, `{"actor": "admin", "pageSize": 5000}`)
+	assert.NotNil(t, err)
+}
+
+// Тестування запасного індексу, якщо стан не підтримує запити CouchDB
+func TestQueryEventsPageRichFallback(t *testing.T) {
+	state, entries := indexedEvents(
+		SecurityEvent{ID: "a", Type: "login", Timestamp: 1714564800, Actor: "admin", Resource: "system"},
+		SecurityEvent{ID: "b", Type: "key_operation", Timestamp: 1714564810, Actor: "admin", Resource: "key1"},
+	)
+	mockStub, mockContext := newStateStub(state)
+	mockStub.On("GetQueryResultWithPagination", mock.Anything, int32(10), "").Return(nil, nil, fmt.Errorf("ExecuteQueryWithPagination not supported for leveldb"))
+	mockStub.On("GetStateByPartialCompositeKeyWithPagination", "eventactor", []string{"admin"}, int32(10), "").Return(&MockQueryIterator{Results: entries}, &pb.QueryResponseMetadata{FetchedRecordsCount: 2}, nil)
+
+	contract := new(SmartContract)
+	page, err := contract.QueryEventsPage(mockContext, `{"actor": "admin", "resource": "key1", "pageSize": 10}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "index", page.Strategy)
+	assert.Len(t, page.Events, 1)
+	assert.Equal(t, "b", page.Events[0].ID)
+	assert.Empty(t, page.Bookmark)
+
+	// Запит CouchDB фільтрує за всіма полями з індексом першого з них
+	var couchQuery map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(mockStub.Calls[0].Arguments[0].(string)), &couchQuery))
+	assert.Equal(t, map[string]interface{}{"actor": "admin", "resource": "key1", "timestamp": map[string]interface{}{"$gte": float64(0)}}, couchQuery["selector"])
+	assert.Equal(t, []interface{}{"_design/indexActorDoc", "indexActor"}, couchQuery["use_index"])
+}
+
+// Тестування помилки запиту CouchDB, не пов'язаної з LevelDB
+func TestQueryEventsPageRichError(t *testing.T) {
+	mockStub := new(MockStub)
+	mockContext := new(MockContext)
+	mockContext.On("GetStub").Return(mockStub)
+	mockStub.On("GetQueryResultWithPagination", mock.Anything, int32(10), "").Return(nil, nil, fmt.Errorf("no_usable_index: No index exists for this sort"))
+
+	contract := new(SmartContract)
+	_, err := contract.QueryEventsPage(mockContext, `{"actor": "admin", "resource": "key1", "pageSize": 10}`)
+
+	// Помилка CouchDB повертається, а не приховується запитом за індексом
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "no_usable_index")
+	mockStub.AssertNotCalled(t, "GetStateByPartialCompositeKeyWithPagination", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Тестування сторінок запиту без фільтра за полями за індексом часу
+func TestQueryEventsPageTimeIndex(t *testing.T) {
+	state, entries := indexedEvents(
+		SecurityEvent{ID: "a", Type: "login", Timestamp: 1714564800, Actor: "admin"},
+		SecurityEvent{ID: "b", Type: "login", Timestamp: 1714564801, Actor: "admin"},
+		SecurityEvent{ID: "c", Type: "login", Timestamp: 1714564802, Actor: "admin"},
+	)
+	mockStub, mockContext := newStateStub(state)
+	mockStub.On("GetStateByPartialCompositeKeyWithPagination", "eventtime", []string(nil), int32(2), "").Return(&MockQueryIterator{Results: entries[0:2]}, &pb.QueryResponseMetadata{FetchedRecordsCount: 2, Bookmark: "t1"}, nil)
+	mockStub.On("GetStateByPartialCompositeKeyWithPagination", "eventtime", []string(nil), int32(2), "t1").Return(&MockQueryIterator{Results: entries[2:]}, &pb.QueryResponseMetadata{FetchedRecordsCount: 1}, nil)
+
+	contract := new(SmartContract)
+	page, err := contract.QueryEventsPage(mockContext, `{"pageSize": 2}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "index", page.Strategy)
+	assert.Equal(t, "a", page.Events[0].ID)
+	assert.Equal(t, "b", page.Events[1].ID)
+	assert.NotEmpty(t, page.Bookmark)
+
+	page, err = contract.QueryEventsPage(mockContext, fmt.Sprintf(`{"pageSize": 2, "bookmark": %q}`, page.Bookmark))
+	assert.Nil(t, err)
+	assert.Len(t, page.Events, 1)
+	assert.Equal(t, "c", page.Events[0].ID)
+	assert.Empty(t, page.Bookmark)
+	mockStub.AssertNotCalled(t, "GetStateByRange", mock.Anything, mock.Anything)
+}
+
+// Тестування читання всіх сторінок запиту до досягнення ліміту
+func TestQueryEventsAllPages(t *testing.T) {
+	// Індекс часу: після limit подій наступні сторінки не читаються
+	state, entries := indexedEvents(
+		SecurityEvent{ID: "a", Type: "login", Timestamp: 1714564800, Actor: "admin"},
+		SecurityEvent{ID: "b", Type: "login", Timestamp: 1714564801, Actor: "admin"},
+	)
+	mockStub, mockContext := newStateStub(state)
+	mockStub.On("GetStateByPartialCompositeKeyWithPagination", "eventtime", []string(nil), int32(2), "").Return(&MockQueryIterator{Results: entries}, &pb.QueryResponseMetadata{FetchedRecordsCount: 2, Bookmark: "t1"}, nil)
+
+	contract := new(SmartContract)
+	events, err := contract.QueryEvents(mockContext, `{"startTime": 0, "endTime": 9999999999, "limit": 2}`)
+	assert.Nil(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, "a", events[0].ID)
+	assert.Equal(t, "b", events[1].ID)
+	mockStub.AssertNumberOfCalls(t, "GetStateByPartialCompositeKeyWithPagination", 1)
+
+	// Запит CouchDB: сторінка з подією поза фільтром не вичерпує ліміт
+	var pages [2][]KVPair
+	for i, event := range []SecurityEvent{
+		{ID: "f", Type: "login", Timestamp: 1714564800, Actor: "admin", Resource: "system"},
+		{ID: "g", Type: "login", Timestamp: 1714564810, Actor: "admin", Resource: "key1"},
+		{ID: "h", Type: "login", Timestamp: 1714564820, Actor: "admin", Resource: "system"},
+	} {
+		eventJSON, _ := json.Marshal(event)
+		pages[i/2] = append(pages[i/2], KVPair{Key: "event:" + event.ID, Value: eventJSON})
+	}
+	selector := `{"selector":{"actor":"admin","resource":"system","timestamp":{"$gte":0}},"sort":[{"actor":"asc"},{"timestamp":"asc"}],"use_index":["_design/indexActorDoc","indexActor"]}`
+	mockStub = new(MockStub)
+	mockContext = new(MockContext)
+	mockContext.On("GetStub").Return(mockStub)
+	mockStub.On("GetQueryResultWithPagination", selector, int32(2), "").Return(&MockQueryIterator{Results: pages[0]}, &pb.QueryResponseMetadata{FetchedRecordsCount: 2, Bookmark: "bm1"}, nil)
+	mockStub.On("GetQueryResultWithPagination", selector, int32(2), "bm1").Return(&MockQueryIterator{Results: pages[1]}, &pb.QueryResponseMetadata{FetchedRecordsCount: 1}, nil)
+
+	events, err = contract.QueryEvents(mockContext, `{"actor": "admin", "resource": "system", "limit": 2}`)
+	assert.Nil(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, "f", events[0].ID)
+	assert.Equal(t, "h", events[1].ID)
+	mockStub.AssertExpectations(t)
+}
+
+// Тестування запису в індекси подій, збережених до їх появи
+func TestReindexEvents(t *testing.T) {
+	var results []KVPair
+	for _, id := range []string{"a", "b", "c"} {
+		eventJSON, _ := json.Marshal(SecurityEvent{ID: id, Type: "login", Timestamp: 1714564800, Actor: "admin"})
+		results = append(results, KVPair{Key: "event:" + id, Value: eventJSON})
+	}
+	mockStub := new(MockStub)
+	mockContext := new(MockContext)
+	mockContext.On("GetStub").Return(mockStub)
+	mockStub.On("GetStateByRange", "event:", "event~").Return(&MockQueryIterator{Results: results}, nil)
+	mockStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+
+	contract := new(SmartContract)
+	next, err := contract.ReindexEvents(mockContext, "", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "event:c", next)
+	// Подія без ресурсу записується в три індекси
+	assert.Len(t, mockStub.Calls, 7)
+
+	_, err = contract.ReindexEvents(mockContext, "", 0)
+	assert.NotNil(t, err)
+	_, err = contract.ReindexEvents(mockContext, "config:", 10)
+	assert.NotNil(t, err)
+}
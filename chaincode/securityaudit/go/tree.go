@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"blockchain-security/chaincode/common/merkle"
+)
+
+// Тип складених ключів вузлів дерева Меркла: потік, рівень, індекс -> хеш вузла (32 байти)
+const treeNodeObjectType = "auditnode"
+
+// TreeHead корінь дерева Меркла потоку; відповідає TreeHead пакета client/auditproof
+type TreeHead struct {
+	Stream   string `json:"stream"`
+	TreeSize uint64 `json:"treeSize"`
+	RootHash string `json:"rootHash"`
+}
+
+// EventInclusionProof доказ включення події в дерево її потоку
+type EventInclusionProof struct {
+	EventID   string   `json:"eventId"`
+	Stream    string   `json:"stream"`
+	LeafIndex uint64   `json:"leafIndex"` // номер події в потоці мінус один
+	TreeSize  uint64   `json:"treeSize"`
+	EventHash string   `json:"eventHash"`
+	AuditPath []string `json:"auditPath"` // хеші від листка до кореня, hex
+	RootHash  string   `json:"rootHash"`
+}
+
+// TreeConsistencyProof доказ того, що дерево з OldSize подій є префіксом дерева з NewSize подій
+type TreeConsistencyProof struct {
+	Stream  string   `json:"stream"`
+	OldSize uint64   `json:"oldSize"`
+	NewSize uint64   `json:"newSize"`
+	Proof   []string `json:"proof"` // hex
+	OldRoot string   `json:"oldRoot"`
+	NewRoot string   `json:"newRoot"`
+}
+
+// GetTreeHead повертає корінь дерева Меркла над усіма подіями потоку.
+// Смарт-контракт не підписує корінь: підписаний корінь видає сервіс, якому довіряють аудитори.
+func (s *SmartContract) GetTreeHead(ctx contractapi.TransactionContextInterface, stream string) (*TreeHead, error) {
+	head, err := readChainHead(ctx, stream)
+	if err != nil {
+		return nil, err
+	}
+	root, err := treeRoot(ctx, stream, head.Sequence)
+	if err != nil {
+		return nil, err
+	}
+	return &TreeHead{Stream: stream, TreeSize: head.Sequence, RootHash: hex.EncodeToString(root)}, nil
+}
+
+// GetInclusionProof повертає доказ включення події в дерево її потоку з treeSize подій.
+// Нульовий treeSize означає поточний розмір дерева.
+func (s *SmartContract) GetInclusionProof(ctx contractapi.TransactionContextInterface, eventID string, treeSize uint64) (*EventInclusionProof, error) {
+	event, err := s.GetEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if event.Sequence == 0 {
+		return nil, fmt.Errorf("подія %s записана до ведення ланцюжка і не входить у дерево", eventID)
+	}
+
+	head, err := readChainHead(ctx, event.Stream)
+	if err != nil {
+		return nil, err
+	}
+	if treeSize == 0 {
+		treeSize = head.Sequence
+	}
+	if treeSize > head.Sequence || treeSize < event.Sequence {
+		return nil, fmt.Errorf("дерево потоку %s з %d подій не містить події %d", event.Stream, treeSize, event.Sequence)
+	}
+
+	subtree := treeSubtreeHash(ctx, event.Stream)
+	path, err := merkle.InclusionProof(event.Sequence-1, treeSize, subtree)
+	if err != nil {
+		return nil, err
+	}
+	root, err := subtree(0, treeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EventInclusionProof{
+		EventID:   event.ID,
+		Stream:    event.Stream,
+		LeafIndex: event.Sequence - 1,
+		TreeSize:  treeSize,
+		EventHash: event.Hash,
+		AuditPath: encodeHashes(path),
+		RootHash:  hex.EncodeToString(root),
+	}, nil
+}
+
+// GetConsistencyProof повертає доказ узгодженості дерев потоку з oldSize і newSize подій
+func (s *SmartContract) GetConsistencyProof(ctx contractapi.TransactionContextInterface, stream string, oldSize uint64, newSize uint64) (*TreeConsistencyProof, error) {
+	head, err := readChainHead(ctx, stream)
+	if err != nil {
+		return nil, err
+	}
+	if newSize > head.Sequence {
+		return nil, fmt.Errorf("потік %s містить лише %d подій", stream, head.Sequence)
+	}
+
+	subtree := treeSubtreeHash(ctx, stream)
+	proof, err := merkle.ConsistencyProof(oldSize, newSize, subtree)
+	if err != nil {
+		return nil, err
+	}
+	oldRoot, err := treeRoot(ctx, stream, oldSize)
+	if err != nil {
+		return nil, err
+	}
+	newRoot, err := treeRoot(ctx, stream, newSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TreeConsistencyProof{
+		Stream:  stream,
+		OldSize: oldSize,
+		NewSize: newSize,
+		Proof:   encodeHashes(proof),
+		OldRoot: hex.EncodeToString(oldRoot),
+		NewRoot: hex.EncodeToString(newRoot),
+	}, nil
+}
+
+// appendTreeLeaf додає хеш події листком index у дерево потоку і зберігає вузли повних піддерев,
+// які він завершує. Лівий сусід кожного такого вузла записаний попередніми транзакціями.
+func appendTreeLeaf(ctx contractapi.TransactionContextInterface, stream string, index uint64, eventHash string) error {
+	data, err := hex.DecodeString(eventHash)
+	if err != nil {
+		return fmt.Errorf("некоректний хеш події: %v", err)
+	}
+
+	hash := merkle.LeafHash(data)
+	if err := putTreeNode(ctx, stream, 0, index, hash); err != nil {
+		return err
+	}
+	for level := uint(0); index%2 == 1; level++ {
+		left, err := readTreeNode(ctx, stream, level, index-1)
+		if err != nil {
+			return err
+		}
+		hash = merkle.NodeHash(left, hash)
+		index /= 2
+		if err := putTreeNode(ctx, stream, level+1, index, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// treeRoot повертає корінь дерева потоку з size подій
+func treeRoot(ctx contractapi.TransactionContextInterface, stream string, size uint64) ([]byte, error) {
+	if size == 0 {
+		return merkle.RootHash(nil), nil
+	}
+	return treeSubtreeHash(ctx, stream)(0, size)
+}
+
+// treeSubtreeHash повертає функцію обчислення коренів піддерев потоку зі збережених вузлів
+func treeSubtreeHash(ctx contractapi.TransactionContextInterface, stream string) merkle.SubtreeHashFunc {
+	return merkle.StoredSubtreeHash(func(level uint, index uint64) ([]byte, error) {
+		return readTreeNode(ctx, stream, level, index)
+	})
+}
+
+// readTreeNode читає вузол дерева потоку
+func readTreeNode(ctx contractapi.TransactionContextInterface, stream string, level uint, index uint64) ([]byte, error) {
+	key, err := treeNodeKey(ctx, stream, level, index)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("помилка читання вузла дерева: %v", err)
+	}
+	if hash == nil {
+		return nil, fmt.Errorf("вузол дерева потоку %s (рівень %d, індекс %d) відсутній", stream, level, index)
+	}
+	return hash, nil
+}
+
+// putTreeNode зберігає вузол дерева потоку
+func putTreeNode(ctx contractapi.TransactionContextInterface, stream string, level uint, index uint64, hash []byte) error {
+	key, err := treeNodeKey(ctx, stream, level, index)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(key, hash); err != nil {
+		return fmt.Errorf("помилка збереження вузла дерева: %v", err)
+	}
+	return nil
+}
+
+// treeNodeKey повертає складений ключ вузла дерева потоку
+func treeNodeKey(ctx contractapi.TransactionContextInterface, stream string, level uint, index uint64) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(treeNodeObjectType, []string{stream, fmt.Sprintf("%02d", level), sequenceKey(index)})
+	if err != nil {
+		return "", fmt.Errorf("помилка створення ключа вузла дерева: %v", err)
+	}
+	return key, nil
+}
+
+// encodeHashes кодує хеші доказу в hex
+func encodeHashes(hashes [][]byte) []string {
+	encoded := make([]string, len(hashes))
+	for i, hash := range hashes {
+		encoded[i] = hex.EncodeToString(hash)
+	}
+	return encoded
+}
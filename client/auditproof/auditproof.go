@@ -0,0 +1,283 @@
+// Package auditproof - автономна перевірка доказів журналу аудиту.
+//
+// Смарт-контракт securityaudit веде для кожного потоку подій дерево Меркла
+// за схемою RFC 6962, листками якого є хеші подій у порядку запису. Аудитор
+// отримує підписаний корінь дерева (SignedTreeHead) від сервісу, якому
+// довіряє, і перевіряє окрему подію доказом включення (GetInclusionProof),
+// а те, що журнал лише доповнювався між двома коренями, - доказом
+// узгодженості (GetConsistencyProof). Пакет не залежить від Fabric і
+// перевіряє докази алгоритмами RFC 9162 (розділи 2.1.3.2 і 2.1.4.2).
+package auditproof
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// Префікси доменів хешування RFC 6962
+const (
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+)
+
+// Event подія безпеки; поля та їх порядок відповідають SecurityEvent смарт-контракту securityaudit
+type Event struct {
+	ID        string            `json:"id"`
+	Type      string            `json:"type"`
+	Timestamp int64             `json:"timestamp"`
+	Actor     string            `json:"actor"`
+	Resource  string            `json:"resource"`
+	Action    string            `json:"action"`
+	Result    string            `json:"result"`
+	Metadata  map[string]string `json:"metadata"`
+	Stream    string            `json:"stream"`
+	Sequence  uint64            `json:"sequence"`
+	PrevHash  string            `json:"prevHash"`
+	Hash      string            `json:"hash,omitempty"`
+}
+
+// InclusionProof доказ включення події, який повертає GetInclusionProof
+type InclusionProof struct {
+	EventID   string   `json:"eventId"`
+	Stream    string   `json:"stream"`
+	LeafIndex uint64   `json:"leafIndex"`
+	TreeSize  uint64   `json:"treeSize"`
+	EventHash string   `json:"eventHash"`
+	AuditPath []string `json:"auditPath"`
+	RootHash  string   `json:"rootHash"`
+}
+
+// ConsistencyProof доказ узгодженості дерев, який повертає GetConsistencyProof
+type ConsistencyProof struct {
+	Stream  string   `json:"stream"`
+	OldSize uint64   `json:"oldSize"`
+	NewSize uint64   `json:"newSize"`
+	Proof   []string `json:"proof"`
+	OldRoot string   `json:"oldRoot"`
+	NewRoot string   `json:"newRoot"`
+}
+
+// EventHash обчислює хеш події так само, як смарт-контракт: SHA-256 JSON події без поля hash
+func EventHash(event *Event) (string, error) {
+	unsigned := *event
+	unsigned.Hash = ""
+	eventJSON, err := json.Marshal(unsigned)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(eventJSON)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// VerifyEvent перевіряє, що подія входить у журнал з підписаним коренем head.
+// Хеш події обчислюється заново, тому змінена подія не пройде перевірку навіть
+// з доказом, отриманим для оригіналу. Корінь із самого доказу не використовується.
+func VerifyEvent(event *Event, proof *InclusionProof, head *SignedTreeHead, publicKey crypto.PublicKey) error {
+	if err := head.Verify(publicKey); err != nil {
+		return err
+	}
+
+	hash, err := EventHash(event)
+	if err != nil {
+		return err
+	}
+	if hash != event.Hash || hash != proof.EventHash {
+		return fmt.Errorf("хеш події %s не збігається з доказом", event.ID)
+	}
+	if event.ID != proof.EventID || event.Stream != proof.Stream || event.Sequence != proof.LeafIndex+1 {
+		return fmt.Errorf("доказ належить іншій події")
+	}
+	if proof.Stream != head.TreeHead.Stream || proof.TreeSize != head.TreeHead.TreeSize {
+		return fmt.Errorf("доказ побудовано для дерева потоку %s з %d подій, а корінь підписано для потоку %s з %d подій",
+			proof.Stream, proof.TreeSize, head.TreeHead.Stream, head.TreeHead.TreeSize)
+	}
+
+	leaf, err := hex.DecodeString(hash)
+	if err != nil {
+		return err
+	}
+	path, err := decodeHashes(proof.AuditPath)
+	if err != nil {
+		return err
+	}
+	root, err := hex.DecodeString(head.TreeHead.RootHash)
+	if err != nil {
+		return fmt.Errorf("некоректний корінь дерева: %v", err)
+	}
+	return VerifyInclusion(proof.LeafIndex, proof.TreeSize, leaf, path, root)
+}
+
+// VerifyTreeHeads перевіряє, що журнал з підписаним коренем newHead є доповненням журналу з коренем oldHead
+func VerifyTreeHeads(oldHead *SignedTreeHead, newHead *SignedTreeHead, proof *ConsistencyProof, publicKey crypto.PublicKey) error {
+	if err := oldHead.Verify(publicKey); err != nil {
+		return err
+	}
+	if err := newHead.Verify(publicKey); err != nil {
+		return err
+	}
+	if oldHead.TreeHead.Stream != newHead.TreeHead.Stream || proof.Stream != newHead.TreeHead.Stream {
+		return fmt.Errorf("корені та доказ належать різним потокам")
+	}
+	if proof.OldSize != oldHead.TreeHead.TreeSize || proof.NewSize != newHead.TreeHead.TreeSize {
+		return fmt.Errorf("доказ побудовано для дерев з %d і %d подій", proof.OldSize, proof.NewSize)
+	}
+
+	hashes, err := decodeHashes(proof.Proof)
+	if err != nil {
+		return err
+	}
+	oldRoot, err := hex.DecodeString(oldHead.TreeHead.RootHash)
+	if err != nil {
+		return fmt.Errorf("некоректний корінь дерева: %v", err)
+	}
+	newRoot, err := hex.DecodeString(newHead.TreeHead.RootHash)
+	if err != nil {
+		return fmt.Errorf("некоректний корінь дерева: %v", err)
+	}
+	return VerifyConsistency(proof.OldSize, proof.NewSize, oldRoot, newRoot, hashes)
+}
+
+// VerifyInclusion перевіряє доказ включення листка з даними leaf під індексом index
+// у дерево з size листків і коренем root
+func VerifyInclusion(index uint64, size uint64, leaf []byte, path [][]byte, root []byte) error {
+	if index >= size {
+		return fmt.Errorf("листок %d поза деревом з %d листків", index, size)
+	}
+
+	fn, sn := index, size-1
+	r := leafHash(leaf)
+	for _, p := range path {
+		if sn == 0 {
+			return fmt.Errorf("доказ включення задовгий")
+		}
+		if fn%2 == 1 || fn == sn {
+			r = nodeHash(p, r)
+			for fn%2 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = nodeHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+
+	if sn != 0 {
+		return fmt.Errorf("доказ включення закороткий")
+	}
+	if !bytes.Equal(r, root) {
+		return fmt.Errorf("обчислений корінь не збігається з коренем дерева")
+	}
+	return nil
+}
+
+// VerifyConsistency перевіряє доказ того, що дерево з oldSize листків і коренем oldRoot
+// є префіксом дерева з newSize листків і коренем newRoot
+func VerifyConsistency(oldSize uint64, newSize uint64, oldRoot []byte, newRoot []byte, proof [][]byte) error {
+	switch {
+	case oldSize > newSize:
+		return fmt.Errorf("старе дерево з %d листків більше за нове з %d", oldSize, newSize)
+	case oldSize == newSize:
+		if len(proof) != 0 {
+			return fmt.Errorf("доказ для дерев однакового розміру має бути порожнім")
+		}
+		if !bytes.Equal(oldRoot, newRoot) {
+			return fmt.Errorf("корені дерев однакового розміру різні")
+		}
+		return nil
+	case oldSize == 0:
+		// Порожнє дерево є префіксом будь-якого дерева
+		if len(proof) != 0 {
+			return fmt.Errorf("доказ для порожнього дерева має бути порожнім")
+		}
+		if !bytes.Equal(oldRoot, emptyRoot()) {
+			return fmt.Errorf("корінь порожнього дерева некоректний")
+		}
+		return nil
+	}
+	if len(proof) == 0 {
+		return fmt.Errorf("доказ узгодженості порожній")
+	}
+
+	// Якщо старе дерево повне, його корінь є першим вузлом доказу
+	if oldSize&(oldSize-1) == 0 {
+		proof = append([][]byte{oldRoot}, proof...)
+	}
+
+	fn, sn := oldSize-1, newSize-1
+	for fn%2 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+
+	fr, sr := proof[0], proof[0]
+	for _, c := range proof[1:] {
+		if sn == 0 {
+			return fmt.Errorf("доказ узгодженості задовгий")
+		}
+		if fn%2 == 1 || fn == sn {
+			fr = nodeHash(c, fr)
+			sr = nodeHash(c, sr)
+			for fn%2 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			sr = nodeHash(sr, c)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+
+	if sn != 0 {
+		return fmt.Errorf("доказ узгодженості закороткий")
+	}
+	if !bytes.Equal(fr, oldRoot) {
+		return fmt.Errorf("обчислений корінь старого дерева не збігається")
+	}
+	if !bytes.Equal(sr, newRoot) {
+		return fmt.Errorf("обчислений корінь нового дерева не збігається")
+	}
+	return nil
+}
+
+// leafHash повертає хеш листка з даними data
+func leafHash(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{leafPrefix})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// nodeHash повертає хеш внутрішнього вузла
+func nodeHash(left []byte, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{nodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// emptyRoot повертає корінь порожнього дерева
+func emptyRoot() []byte {
+	empty := sha256.Sum256(nil)
+	return empty[:]
+}
+
+// decodeHashes декодує hex-хеші доказу
+func decodeHashes(encoded []string) ([][]byte, error) {
+	hashes := make([][]byte, len(encoded))
+	for i, value := range encoded {
+		hash, err := hex.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("некоректний хеш доказу: %v", err)
+		}
+		hashes[i] = hash
+	}
+	return hashes, nil
+}
@@ -0,0 +1,185 @@
+// Файл: client/auditproof/auditproof_test.go
+package auditproof
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"blockchain-security/chaincode/common/merkle"
+)
+
+// testTree повертає дані листків і функцію коренів піддерев над ними
+func testTree(n int) ([][]byte, merkle.SubtreeHashFunc) {
+	leaves := make([][]byte, n)
+	for i := range leaves {
+		hash := sha256.Sum256([]byte(fmt.Sprintf("event-%d", i)))
+		leaves[i] = hash[:]
+	}
+	subtree := func(start uint64, end uint64) ([]byte, error) {
+		return merkle.RootHash(leaves[start:end]), nil
+	}
+	return leaves, subtree
+}
+
+// Тестування перевірки доказів включення, побудованих смарт-контрактом
+func TestVerifyInclusion(t *testing.T) {
+	for size := uint64(1); size <= 17; size++ {
+		leaves, subtree := testTree(int(size))
+		root := merkle.RootHash(leaves)
+		for index := uint64(0); index < size; index++ {
+			path, err := merkle.InclusionProof(index, size, subtree)
+			assert.Nil(t, err)
+			assert.Nil(t, VerifyInclusion(index, size, leaves[index], path, root), "листок %d дерева з %d листків", index, size)
+
+			// Інший листок або змінений вузол доказу не проходять перевірку
+			if len(path) > 0 {
+				assert.NotNil(t, VerifyInclusion(index, size, leaves[(index+1)%size], path, root))
+				tampered := append([][]byte{}, path...)
+				tampered[0] = leaves[index]
+				assert.NotNil(t, VerifyInclusion(index, size, leaves[index], tampered, root))
+				assert.NotNil(t, VerifyInclusion(index, size, leaves[index], path[:len(path)-1], root))
+			}
+			assert.NotNil(t, VerifyInclusion(index, size, leaves[index], append(path, root), root))
+		}
+	}
+
+	leaves, _ := testTree(1)
+	assert.NotNil(t, VerifyInclusion(1, 1, leaves[0], nil, merkle.RootHash(leaves)))
+}
+
+// Тестування перевірки доказів узгодженості, побудованих смарт-контрактом
+func TestVerifyConsistency(t *testing.T) {
+	leaves, subtree := testTree(17)
+	for newSize := uint64(1); newSize <= 17; newSize++ {
+		newRoot := merkle.RootHash(leaves[:newSize])
+		for oldSize := uint64(0); oldSize <= newSize; oldSize++ {
+			oldRoot := merkle.RootHash(leaves[:oldSize])
+			proof, err := merkle.ConsistencyProof(oldSize, newSize, subtree)
+			assert.Nil(t, err)
+			assert.Nil(t, VerifyConsistency(oldSize, newSize, oldRoot, newRoot, proof), "дерева з %d і %d листків", oldSize, newSize)
+
+			// Корінь іншого журналу того самого розміру не проходить перевірку
+			if oldSize > 0 && oldSize < newSize {
+				forged := append([][]byte{}, leaves[:oldSize]...)
+				forged[0] = leaves[16]
+				assert.NotNil(t, VerifyConsistency(oldSize, newSize, merkle.RootHash(forged), newRoot, proof))
+				assert.NotNil(t, VerifyConsistency(oldSize, newSize, oldRoot, newRoot, proof[:len(proof)-1]))
+			}
+		}
+	}
+
+	assert.NotNil(t, VerifyConsistency(5, 4, nil, nil, nil))
+	assert.NotNil(t, VerifyConsistency(3, 3, merkle.RootHash(leaves[:3]), merkle.RootHash(leaves[1:4]), nil))
+}
+
+// Тестування підпису кореня дерева ключами різних типів
+func TestSignTreeHead(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+
+	head := TreeHead{Stream: "login", TreeSize: 7, RootHash: hex.EncodeToString(emptyRoot()), Timestamp: 1714564800}
+	for _, signer := range []crypto.Signer{rsaKey, ecdsaKey, ed25519Key} {
+		signed, err := SignTreeHead(head, signer)
+		assert.Nil(t, err)
+		assert.Nil(t, signed.Verify(signer.Public()))
+
+		// Змінений корінь або чужий ключ
+		tampered := *signed
+		tampered.TreeHead.TreeSize = 8
+		assert.NotNil(t, tampered.Verify(signer.Public()))
+		assert.NotNil(t, signed.Verify(&ecdsa.PublicKey{Curve: elliptic.P256(), X: ecdsaKey.Y, Y: ecdsaKey.X}))
+	}
+}
+
+// Тестування перевірки події за доказом і підписаним коренем
+func TestVerifyEvent(t *testing.T) {
+	signer, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	// Потік з трьох подій, пов'язаних ланцюжком хешів
+	var events []*Event
+	var leaves [][]byte
+	prevHash := ""
+	for i := 1; i <= 3; i++ {
+		event := &Event{
+			ID:        fmt.Sprintf("tx%d", i),
+			Type:      "login",
+			Timestamp: 1714564800 + int64(i),
+			Actor:     "admin",
+			Resource:  "system",
+			Action:    "login",
+			Result:    "success",
+			Metadata:  map[string]string{},
+			Stream:    "login",
+			Sequence:  uint64(i),
+			PrevHash:  prevHash,
+		}
+		event.Hash, err = EventHash(event)
+		assert.Nil(t, err)
+		prevHash = event.Hash
+		leaf, _ := hex.DecodeString(event.Hash)
+		events = append(events, event)
+		leaves = append(leaves, leaf)
+	}
+	subtree := func(start uint64, end uint64) ([]byte, error) {
+		return merkle.RootHash(leaves[start:end]), nil
+	}
+
+	root := hex.EncodeToString(merkle.RootHash(leaves))
+	head, err := SignTreeHead(TreeHead{Stream: "login", TreeSize: 3, RootHash: root, Timestamp: 1714564900}, signer)
+	assert.Nil(t, err)
+
+	path, err := merkle.InclusionProof(1, 3, subtree)
+	assert.Nil(t, err)
+	proof := &InclusionProof{
+		EventID:   "tx2",
+		Stream:    "login",
+		LeafIndex: 1,
+		TreeSize:  3,
+		EventHash: events[1].Hash,
+		RootHash:  root,
+	}
+	for _, hash := range path {
+		proof.AuditPath = append(proof.AuditPath, hex.EncodeToString(hash))
+	}
+	assert.Nil(t, VerifyEvent(events[1], proof, head, &signer.PublicKey))
+
+	// Змінена подія
+	tampered := *events[1]
+	tampered.Result = "failure"
+	assert.NotNil(t, VerifyEvent(&tampered, proof, head, &signer.PublicKey))
+
+	// Доказ іншої події
+	assert.NotNil(t, VerifyEvent(events[0], proof, head, &signer.PublicKey))
+
+	// Корінь, підписаний іншим ключем
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	assert.NotNil(t, VerifyEvent(events[1], proof, head, &other.PublicKey))
+
+	// Узгодженість кореня дерева з двох подій з поточним
+	consistency, err := merkle.ConsistencyProof(2, 3, subtree)
+	assert.Nil(t, err)
+	oldHead, err := SignTreeHead(TreeHead{Stream: "login", TreeSize: 2, RootHash: hex.EncodeToString(merkle.RootHash(leaves[:2])), Timestamp: 1714564850}, signer)
+	assert.Nil(t, err)
+	consistencyProof := &ConsistencyProof{Stream: "login", OldSize: 2, NewSize: 3}
+	for _, hash := range consistency {
+		consistencyProof.Proof = append(consistencyProof.Proof, hex.EncodeToString(hash))
+	}
+	assert.Nil(t, VerifyTreeHeads(oldHead, head, consistencyProof, &signer.PublicKey))
+	assert.NotNil(t, VerifyTreeHeads(head, oldHead, consistencyProof, &signer.PublicKey))
+}
@@ -0,0 +1,87 @@
+package auditproof
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+)
+
+// TreeHead корінь дерева потоку, який повертає GetTreeHead, з часом його отримання сервісом підпису
+type TreeHead struct {
+	Stream    string `json:"stream"`
+	TreeSize  uint64 `json:"treeSize"`
+	RootHash  string `json:"rootHash"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// SignedTreeHead корінь дерева з підписом сервісу, якому довіряють аудитори
+type SignedTreeHead struct {
+	TreeHead  TreeHead `json:"treeHead"`
+	Signature []byte   `json:"signature"`
+}
+
+// SignTreeHead підписує корінь дерева ключем сервісу.
+// Підтримуються ключі RSA (PKCS #1 v1.5), ECDSA з SHA-256 та Ed25519.
+func SignTreeHead(head TreeHead, signer crypto.Signer) (*SignedTreeHead, error) {
+	message, err := json.Marshal(head)
+	if err != nil {
+		return nil, err
+	}
+
+	hashFunc, err := signatureHash(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	digest := message
+	if hashFunc != crypto.Hash(0) {
+		sum := sha256.Sum256(message)
+		digest = sum[:]
+	}
+	signature, err := signer.Sign(rand.Reader, digest, hashFunc)
+	if err != nil {
+		return nil, fmt.Errorf("помилка підпису кореня дерева: %v", err)
+	}
+	return &SignedTreeHead{TreeHead: head, Signature: signature}, nil
+}
+
+// Verify перевіряє підпис кореня дерева відкритим ключем сервісу
+func (s *SignedTreeHead) Verify(publicKey crypto.PublicKey) error {
+	message, err := json.Marshal(s.TreeHead)
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(message)
+
+	valid := false
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], s.Signature) == nil
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(key, digest[:], s.Signature)
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, message, s.Signature)
+	default:
+		return fmt.Errorf("непідтримуваний ключ сервісу підпису %T", publicKey)
+	}
+	if !valid {
+		return fmt.Errorf("недійсний підпис кореня дерева потоку %s", s.TreeHead.Stream)
+	}
+	return nil
+}
+
+// signatureHash повертає хеш, з яким підписується корінь дерева ключем publicKey
+func signatureHash(publicKey crypto.PublicKey) (crypto.Hash, error) {
+	switch publicKey.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return crypto.SHA256, nil
+	case ed25519.PublicKey:
+		// Ed25519 підписує повідомлення без попереднього хешування
+		return crypto.Hash(0), nil
+	}
+	return 0, fmt.Errorf("непідтримуваний ключ сервісу підпису %T", publicKey)
+}
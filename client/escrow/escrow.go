@@ -0,0 +1,295 @@
+// Package escrow - клієнтська бібліотека депонування симетричних ключів.
+//
+// Секрет ключа розділяється схемою Шаміра на частки, кожна частка
+// шифрується відкритим ключем одного з власників CryptoKey і передається
+// у транзакцію DepositKeyEscrow смарт-контракту keymanagement. Для
+// відновлення власники розшифровують свої частки поза мережею, а RecoverKey
+// збирає секрет і перевіряє його за зобов'язанням, збереженим у реєстрі.
+package escrow
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Схеми шифрування часток
+const (
+	SchemeRSAOAEP   = "RSA-OAEP"   // RSA-OAEP з SHA-256
+	SchemeECIESP256 = "ECIES-P256" // ECDH P-256, SHA-256, AES-256-GCM
+)
+
+// Розмір солі зобов'язання в байтах
+const saltSize = 16
+
+// ErrCommitmentMismatch повертається, якщо відновлений секрет не відповідає зобов'язанню
+var ErrCommitmentMismatch = errors.New("відновлений секрет не відповідає зобов'язанню")
+
+// EncryptedShare частка секрету, зашифрована для одного з власників ключа
+type EncryptedShare struct {
+	OwnerID        string `json:"ownerId"`
+	Index          int    `json:"index"`
+	Scheme         string `json:"scheme"`
+	EncryptedShare string `json:"encryptedShare"` // base64
+}
+
+// Deposit аргумент транзакції DepositKeyEscrow; збігається з полями запису KeyEscrow
+type Deposit struct {
+	Threshold  int              `json:"threshold"`
+	Commitment string           `json:"commitment"` // hex SHA-256(salt || secret)
+	Salt       string           `json:"salt"`       // hex
+	Shares     []EncryptedShare `json:"shares"`
+}
+
+// PrepareDeposit розділяє секрет на частки для всіх отримувачів і шифрує кожну їхнім відкритим ключем
+func PrepareDeposit(secret []byte, threshold int, recipients map[string]crypto.PublicKey) (*Deposit, error) {
+	// Індекси часток призначаються у стабільному порядку ідентичностей власників
+	ownerIDs := make([]string, 0, len(recipients))
+	for ownerID := range recipients {
+		ownerIDs = append(ownerIDs, ownerID)
+	}
+	sort.Strings(ownerIDs)
+
+	shares, err := Split(secret, len(ownerIDs), threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("помилка генерації солі: %v", err)
+	}
+
+	deposit := &Deposit{
+		Threshold:  threshold,
+		Commitment: Commitment(salt, secret),
+		Salt:       hex.EncodeToString(salt),
+	}
+	for i, ownerID := range ownerIDs {
+		encrypted, err := EncryptShare(ownerID, shares[i], recipients[ownerID])
+		if err != nil {
+			return nil, err
+		}
+		deposit.Shares = append(deposit.Shares, encrypted)
+	}
+
+	return deposit, nil
+}
+
+// RecoverKey відновлює секрет з розшифрованих часток і перевіряє його за зобов'язанням депозиту
+func RecoverKey(deposit *Deposit, shares []Share) ([]byte, error) {
+	if len(shares) < deposit.Threshold {
+		return nil, fmt.Errorf("потрібно щонайменше %d часток, отримано %d", deposit.Threshold, len(shares))
+	}
+
+	secret, err := Combine(shares)
+	if err != nil {
+		return nil, err
+	}
+
+	salt, err := hex.DecodeString(deposit.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("некоректна сіль зобов'язання: %v", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(Commitment(salt, secret)), []byte(deposit.Commitment)) != 1 {
+		return nil, ErrCommitmentMismatch
+	}
+
+	return secret, nil
+}
+
+// Commitment обчислює зобов'язання до секрету: hex SHA-256(salt || secret)
+func Commitment(salt []byte, secret []byte) string {
+	hash := sha256.New()
+	hash.Write(salt)
+	hash.Write(secret)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// EncryptShare шифрує частку відкритим ключем власника (RSA або ECDSA P-256)
+func EncryptShare(ownerID string, share Share, publicKey crypto.PublicKey) (EncryptedShare, error) {
+	label := shareLabel(ownerID, share.Index)
+
+	var scheme string
+	var ciphertext []byte
+	var err error
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		scheme = SchemeRSAOAEP
+		ciphertext, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, key, share.Bytes(), label)
+	case *ecdsa.PublicKey:
+		scheme = SchemeECIESP256
+		ciphertext, err = eciesEncrypt(key, share.Bytes(), label)
+	default:
+		return EncryptedShare{}, fmt.Errorf("непідтримуваний відкритий ключ власника %s: %T", ownerID, publicKey)
+	}
+	if err != nil {
+		return EncryptedShare{}, fmt.Errorf("помилка шифрування частки для %s: %v", ownerID, err)
+	}
+
+	return EncryptedShare{
+		OwnerID:        ownerID,
+		Index:          int(share.Index),
+		Scheme:         scheme,
+		EncryptedShare: base64.StdEncoding.EncodeToString(ciphertext),
+	}, nil
+}
+
+// DecryptShare розшифровує частку закритим ключем власника
+func DecryptShare(encrypted EncryptedShare, privateKey crypto.PrivateKey) (Share, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(encrypted.EncryptedShare)
+	if err != nil {
+		return Share{}, fmt.Errorf("частка має бути в base64: %v", err)
+	}
+	if encrypted.Index < 1 || encrypted.Index > 255 {
+		return Share{}, fmt.Errorf("некоректний індекс частки %d", encrypted.Index)
+	}
+	label := shareLabel(encrypted.OwnerID, byte(encrypted.Index))
+
+	var plaintext []byte
+	switch encrypted.Scheme {
+	case SchemeRSAOAEP:
+		key, ok := privateKey.(*rsa.PrivateKey)
+		if !ok {
+			return Share{}, fmt.Errorf("схема %s потребує закритого ключа RSA", encrypted.Scheme)
+		}
+		plaintext, err = rsa.DecryptOAEP(sha256.New(), nil, key, ciphertext, label)
+	case SchemeECIESP256:
+		key, ok := privateKey.(*ecdsa.PrivateKey)
+		if !ok {
+			return Share{}, fmt.Errorf("схема %s потребує закритого ключа ECDSA", encrypted.Scheme)
+		}
+		plaintext, err = eciesDecrypt(key, ciphertext, label)
+	default:
+		return Share{}, fmt.Errorf("невідома схема шифрування частки: %s", encrypted.Scheme)
+	}
+	if err != nil {
+		return Share{}, fmt.Errorf("помилка розшифрування частки: %v", err)
+	}
+
+	share, err := ParseShare(plaintext)
+	if err != nil {
+		return Share{}, err
+	}
+	if int(share.Index) != encrypted.Index {
+		return Share{}, errors.New("індекс частки не відповідає запису")
+	}
+	return share, nil
+}
+
+// ParsePublicKey розбирає відкритий ключ власника з PEM: PUBLIC KEY або CERTIFICATE
+func ParsePublicKey(pemData []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("очікується PEM")
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	default:
+		return nil, fmt.Errorf("непідтримуваний блок PEM: %s", block.Type)
+	}
+}
+
+// shareLabel прив'язує шифротекст до власника та індексу частки
+func shareLabel(ownerID string, index byte) []byte {
+	return []byte(fmt.Sprintf("key-escrow:%s:%d", ownerID, index))
+}
+
+// eciesEncrypt шифрує дані ефемерним ECDH P-256 і AES-256-GCM.
+// Формат: відкритий ефемерний ключ (65 байт) || nonce || шифротекст.
+func eciesEncrypt(publicKey *ecdsa.PublicKey, plaintext []byte, label []byte) ([]byte, error) {
+	recipient, err := publicKey.ECDH()
+	if err != nil {
+		return nil, err
+	}
+	if recipient.Curve() != ecdh.P256() {
+		return nil, errors.New("підтримується лише крива P-256")
+	}
+
+	ephemeral, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := eciesCipher(shared, ephemeral.PublicKey(), recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := append(ephemeral.PublicKey().Bytes(), nonce...)
+	return aead.Seal(out, nonce, plaintext, label), nil
+}
+
+// eciesDecrypt розшифровує результат eciesEncrypt
+func eciesDecrypt(privateKey *ecdsa.PrivateKey, ciphertext []byte, label []byte) ([]byte, error) {
+	recipient, err := privateKey.ECDH()
+	if err != nil {
+		return nil, err
+	}
+
+	const ephemeralSize = 65
+	if len(ciphertext) < ephemeralSize {
+		return nil, errors.New("шифротекст закороткий")
+	}
+	ephemeral, err := ecdh.P256().NewPublicKey(ciphertext[:ephemeralSize])
+	if err != nil {
+		return nil, err
+	}
+	shared, err := recipient.ECDH(ephemeral)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := eciesCipher(shared, ephemeral, recipient.PublicKey())
+	if err != nil {
+		return nil, err
+	}
+
+	rest := ciphertext[ephemeralSize:]
+	if len(rest) < aead.NonceSize() {
+		return nil, errors.New("шифротекст закороткий")
+	}
+	return aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], label)
+}
+
+// eciesCipher виводить ключ AES-256-GCM зі спільного секрету ECDH та обох відкритих ключів
+func eciesCipher(shared []byte, ephemeral *ecdh.PublicKey, recipient *ecdh.PublicKey) (cipher.AEAD, error) {
+	kdf := sha256.New()
+	kdf.Write(shared)
+	kdf.Write(ephemeral.Bytes())
+	kdf.Write(recipient.Bytes())
+
+	block, err := aes.NewCipher(kdf.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
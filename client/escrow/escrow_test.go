@@ -0,0 +1,112 @@
+// Файл: client/escrow/escrow_test.go
+package escrow
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Тестування повного циклу депонування та відновлення ключа
+func TestPrepareDepositRecoverKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	ecKey1, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	ecKey2, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	privateKeys := map[string]crypto.PrivateKey{
+		"Org1MSP::user1": rsaKey,
+		"Org2MSP::user2": ecKey1,
+		"Org3MSP::user3": ecKey2,
+	}
+	recipients := map[string]crypto.PublicKey{
+		"Org1MSP::user1": &rsaKey.PublicKey,
+		"Org2MSP::user2": &ecKey1.PublicKey,
+		"Org3MSP::user3": &ecKey2.PublicKey,
+	}
+
+	secret := make([]byte, 32)
+	_, err = rand.Read(secret)
+	assert.Nil(t, err)
+
+	deposit, err := PrepareDeposit(secret, 2, recipients)
+	assert.Nil(t, err)
+	assert.Len(t, deposit.Shares, 3)
+	assert.Equal(t, SchemeRSAOAEP, deposit.Shares[0].Scheme)
+	assert.Equal(t, SchemeECIESP256, deposit.Shares[1].Scheme)
+
+	// Двоє власників розшифровують свої частки поза мережею
+	var shares []Share
+	for _, encrypted := range deposit.Shares[1:] {
+		share, err := DecryptShare(encrypted, privateKeys[encrypted.OwnerID])
+		assert.Nil(t, err)
+		shares = append(shares, share)
+	}
+
+	recovered, err := RecoverKey(deposit, shares)
+	assert.Nil(t, err)
+	assert.Equal(t, secret, recovered)
+
+	// Однієї частки недостатньо
+	_, err = RecoverKey(deposit, shares[:1])
+	assert.NotNil(t, err)
+}
+
+// Тестування виявлення підміненої частки за зобов'язанням
+func TestRecoverKeyCommitmentMismatch(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	deposit, err := PrepareDeposit([]byte("0123456789abcdef"), 2, map[string]crypto.PublicKey{
+		"Org1MSP::user1": &ecKey.PublicKey,
+		"Org2MSP::user2": &ecKey.PublicKey,
+	})
+	assert.Nil(t, err)
+
+	first, err := DecryptShare(deposit.Shares[0], ecKey)
+	assert.Nil(t, err)
+	second, err := DecryptShare(deposit.Shares[1], ecKey)
+	assert.Nil(t, err)
+	second.Value[0] ^= 0xff
+
+	_, err = RecoverKey(deposit, []Share{first, second})
+	assert.ErrorIs(t, err, ErrCommitmentMismatch)
+}
+
+// Тестування прив'язки шифротексту до власника частки
+func TestDecryptShareWrongOwner(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	encrypted, err := EncryptShare("Org1MSP::user1", Share{Index: 1, Value: []byte("share")}, &ecKey.PublicKey)
+	assert.Nil(t, err)
+
+	// Частку, переписану на іншого власника, розшифрувати не вдасться
+	encrypted.OwnerID = "Org2MSP::user2"
+	_, err = DecryptShare(encrypted, ecKey)
+	assert.NotNil(t, err)
+}
+
+// Тестування розбору відкритого ключа власника з PEM
+func TestParsePublicKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	assert.Nil(t, err)
+
+	publicKey, err := ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	assert.Nil(t, err)
+	assert.True(t, ecKey.PublicKey.Equal(publicKey))
+
+	_, err = ParsePublicKey([]byte("not a pem"))
+	assert.NotNil(t, err)
+}
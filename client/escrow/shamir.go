@@ -0,0 +1,147 @@
+package escrow
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// Share частка секрету у схемі Шаміра над полем GF(2^8)
+type Share struct {
+	Index byte   // абсциса частки, 1..255
+	Value []byte // значення полінома для кожного байта секрету
+}
+
+// Bytes серіалізує частку як індекс, за яким ідуть значення
+func (s Share) Bytes() []byte {
+	return append([]byte{s.Index}, s.Value...)
+}
+
+// ParseShare відновлює частку з результату Bytes
+func ParseShare(data []byte) (Share, error) {
+	if len(data) < 2 || data[0] == 0 {
+		return Share{}, errors.New("некоректна частка секрету")
+	}
+	return Share{Index: data[0], Value: append([]byte(nil), data[1:]...)}, nil
+}
+
+// Split розділяє секрет на n часток, будь-які threshold з яких відновлюють його
+func Split(secret []byte, n int, threshold int) ([]Share, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("секрет не може бути порожнім")
+	}
+	if n < 1 || n > 255 {
+		return nil, fmt.Errorf("кількість часток має бути від 1 до 255, отримано %d", n)
+	}
+	if threshold < 1 || threshold > n {
+		return nil, fmt.Errorf("поріг має бути від 1 до %d, отримано %d", n, threshold)
+	}
+
+	shares := make([]Share, n)
+	for i := range shares {
+		shares[i] = Share{Index: byte(i + 1), Value: make([]byte, len(secret))}
+	}
+
+	// Для кожного байта секрету будуємо випадковий поліном степеня threshold-1
+	coefficients := make([]byte, threshold)
+	for b, secretByte := range secret {
+		coefficients[0] = secretByte
+		if _, err := rand.Read(coefficients[1:]); err != nil {
+			return nil, fmt.Errorf("помилка генерації коефіцієнтів: %v", err)
+		}
+		for i := range shares {
+			shares[i].Value[b] = evaluate(coefficients, shares[i].Index)
+		}
+	}
+
+	return shares, nil
+}
+
+// Combine відновлює секрет інтерполяцією Лагранжа в нулі.
+// Якщо часток менше за поріг, результат буде іншим секретом без ознак помилки,
+// тому відновлений секрет слід перевіряти за зобов'язанням.
+func Combine(shares []Share) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("немає часток для відновлення")
+	}
+
+	size := len(shares[0].Value)
+	seen := make(map[byte]bool, len(shares))
+	for _, share := range shares {
+		if share.Index == 0 {
+			return nil, errors.New("частка з нульовим індексом")
+		}
+		if seen[share.Index] {
+			return nil, fmt.Errorf("частка %d повторюється", share.Index)
+		}
+		if len(share.Value) != size {
+			return nil, errors.New("частки мають різну довжину")
+		}
+		seen[share.Index] = true
+	}
+
+	secret := make([]byte, size)
+	for i, share := range shares {
+		// Базисний поліном Лагранжа l_i(0) = prod(x_j / (x_j - x_i)); у GF(2^8) віднімання - це XOR
+		basis := byte(1)
+		for j, other := range shares {
+			if i == j {
+				continue
+			}
+			basis = gfMul(basis, gfDiv(other.Index, other.Index^share.Index))
+		}
+		for b := range secret {
+			secret[b] ^= gfMul(share.Value[b], basis)
+		}
+	}
+
+	return secret, nil
+}
+
+// evaluate обчислює поліном у точці x за схемою Горнера
+func evaluate(coefficients []byte, x byte) byte {
+	result := byte(0)
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		result = gfMul(result, x) ^ coefficients[i]
+	}
+	return result
+}
+
+// Таблиці логарифмів і степенів GF(2^8) з поліномом x^8+x^4+x^3+x+1 і генератором 3
+var (
+	gfExp [510]byte
+	gfLog [256]byte
+)
+
+func init() {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		gfExp[i] = x
+		gfLog[x] = byte(i)
+		// Множення на генератор 3: x*2 XOR x
+		doubled := x << 1
+		if x&0x80 != 0 {
+			doubled ^= 0x1b
+		}
+		x = doubled ^ x
+	}
+	for i := 255; i < len(gfExp); i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+}
+
+// gfMul множить елементи GF(2^8)
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+// gfDiv ділить елементи GF(2^8); дільник не може бути нулем
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+255-int(gfLog[b])]
+}
@@ -0,0 +1,84 @@
+// Файл: client/escrow/shamir_test.go
+package escrow
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Тестування відновлення секрету з будь-якої підмножини часток розміром з поріг
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	shares, err := Split(secret, 5, 3)
+	assert.Nil(t, err)
+	assert.Len(t, shares, 5)
+
+	subsets := [][]int{{0, 1, 2}, {2, 3, 4}, {0, 2, 4}, {4, 1, 3}, {0, 1, 2, 3, 4}}
+	for _, subset := range subsets {
+		selected := make([]Share, 0, len(subset))
+		for _, i := range subset {
+			selected = append(selected, shares[i])
+		}
+		recovered, err := Combine(selected)
+		assert.Nil(t, err)
+		assert.Equal(t, secret, recovered)
+	}
+}
+
+// Тестування того, що часток менше за поріг недостатньо
+func TestCombineBelowThreshold(t *testing.T) {
+	secret := []byte("0123456789abcdef")
+
+	shares, err := Split(secret, 5, 3)
+	assert.Nil(t, err)
+
+	recovered, err := Combine(shares[:2])
+	assert.Nil(t, err)
+	assert.False(t, bytes.Equal(secret, recovered))
+}
+
+// Тестування параметрів Split
+func TestSplitValidation(t *testing.T) {
+	testCases := []struct {
+		name      string
+		secret    []byte
+		n         int
+		threshold int
+	}{
+		{name: "Порожній секрет", secret: nil, n: 3, threshold: 2},
+		{name: "Поріг більший за кількість часток", secret: []byte("s"), n: 2, threshold: 3},
+		{name: "Нульовий поріг", secret: []byte("s"), n: 2, threshold: 0},
+		{name: "Забагато часток", secret: []byte("s"), n: 256, threshold: 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Split(tc.secret, tc.n, tc.threshold)
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+// Тестування відмови при повторених частках
+func TestCombineDuplicateShares(t *testing.T) {
+	shares, err := Split([]byte("secret"), 3, 2)
+	assert.Nil(t, err)
+
+	_, err = Combine([]Share{shares[0], shares[0]})
+	assert.NotNil(t, err)
+}
+
+// Тестування серіалізації частки
+func TestShareBytes(t *testing.T) {
+	share := Share{Index: 7, Value: []byte{1, 2, 3}}
+
+	parsed, err := ParseShare(share.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, share, parsed)
+
+	_, err = ParseShare([]byte{0, 1})
+	assert.NotNil(t, err)
+}
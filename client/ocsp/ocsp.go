@@ -0,0 +1,266 @@
+package ocsp
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"time"
+)
+
+// Стан відповіді OCSPResponseStatus (RFC 6960, розділ 4.2.1)
+const (
+	statusSuccessful       = 0
+	statusMalformedRequest = 1
+	statusInternalError    = 2
+	statusTryLater         = 3
+)
+
+// Ідентифікатори об'єктів OCSP, алгоритмів хешування та підпису
+var (
+	oidBasicResponse            = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+	oidNonce                    = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 2}
+	oidSHA1                     = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256                   = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384                   = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512                   = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	oidSignatureSHA256WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSignatureECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidSignatureECDSAWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
+	oidSignatureECDSAWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+	oidSignatureEd25519         = asn1.ObjectIdentifier{1, 3, 101, 112}
+)
+
+// certID ідентифікатор сертифіката в запиті та відповіді
+type certID struct {
+	HashAlgorithm  pkix.AlgorithmIdentifier
+	IssuerNameHash []byte
+	IssuerKeyHash  []byte
+	SerialNumber   *big.Int
+}
+
+// singleRequest запит стану одного сертифіката
+type singleRequest struct {
+	Cert       certID
+	Extensions []pkix.Extension `asn1:"explicit,tag:0,optional"`
+}
+
+// tbsRequest тіло запиту OCSP
+type tbsRequest struct {
+	Version       int           `asn1:"explicit,tag:0,default:0,optional"`
+	RequestorName asn1.RawValue `asn1:"explicit,tag:1,optional"`
+	RequestList   []singleRequest
+	Extensions    []pkix.Extension `asn1:"explicit,tag:2,optional"`
+}
+
+// ocspRequest запит OCSP; підпис запиту відповідач не перевіряє
+type ocspRequest struct {
+	TBSRequest tbsRequest
+	Signature  asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+// revokedInfo відомості про відкликання сертифіката
+type revokedInfo struct {
+	RevocationTime time.Time       `asn1:"generalized"`
+	Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+}
+
+// singleResponse стан одного сертифіката; заповнюється рівно одне з полів Good, Revoked, Unknown
+type singleResponse struct {
+	Cert       certID
+	Good       asn1.Flag   `asn1:"tag:0,optional"`
+	Revoked    revokedInfo `asn1:"tag:1,optional"`
+	Unknown    asn1.Flag   `asn1:"tag:2,optional"`
+	ThisUpdate time.Time   `asn1:"generalized"`
+	NextUpdate time.Time   `asn1:"generalized,explicit,tag:0,optional"`
+}
+
+// responseData тіло базової відповіді, яке підписує відповідач
+type responseData struct {
+	Version     int           `asn1:"explicit,tag:0,default:0,optional"`
+	ResponderID asn1.RawValue // byKey [2]: SHA-1 відкритого ключа відповідача
+	ProducedAt  time.Time     `asn1:"generalized"`
+	Responses   []singleResponse
+	Extensions  []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+// basicResponse підписана базова відповідь OCSP
+type basicResponse struct {
+	TBSResponseData    asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+// responseBytes тип і вміст відповіді
+type responseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+// ocspResponse відповідь OCSP
+type ocspResponse struct {
+	Status   asn1.Enumerated
+	Response responseBytes `asn1:"explicit,tag:0,optional"`
+}
+
+// subjectPublicKeyInfo відкритий ключ сертифіката у DER
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// CreateRequest формує запит OCSP стану сертифіката cert, виданого issuer.
+// Ідентифікатор сертифіката обчислюється за SHA-1, як цього очікує більшість клієнтів.
+func CreateRequest(cert *x509.Certificate, issuer *x509.Certificate) ([]byte, error) {
+	nameHash, keyHash, err := issuerHashes(issuer, oidSHA1)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(ocspRequest{TBSRequest: tbsRequest{RequestList: []singleRequest{{
+		Cert: certID{
+			HashAlgorithm:  pkix.AlgorithmIdentifier{Algorithm: oidSHA1, Parameters: asn1.NullRawValue},
+			IssuerNameHash: nameHash,
+			IssuerKeyHash:  keyHash,
+			SerialNumber:   cert.SerialNumber,
+		},
+	}}}})
+}
+
+// parseRequest розбирає запит OCSP у DER
+func parseRequest(der []byte) (*ocspRequest, error) {
+	var request ocspRequest
+	rest, err := asn1.Unmarshal(der, &request)
+	if err != nil {
+		return nil, fmt.Errorf("некоректний запит OCSP: %v", err)
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("зайві дані після запиту OCSP")
+	}
+	if len(request.TBSRequest.RequestList) == 0 {
+		return nil, errors.New("запит OCSP не містить сертифікатів")
+	}
+	return &request, nil
+}
+
+// issuerHashes обчислює хеші імені та відкритого ключа видавця алгоритмом з CertID
+func issuerHashes(issuer *x509.Certificate, algorithm asn1.ObjectIdentifier) ([]byte, []byte, error) {
+	var spki subjectPublicKeyInfo
+	if _, err := asn1.Unmarshal(issuer.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return nil, nil, fmt.Errorf("некоректний відкритий ключ видавця: %v", err)
+	}
+
+	var h hash.Hash
+	switch {
+	case algorithm.Equal(oidSHA1):
+		h = sha1.New()
+	case algorithm.Equal(oidSHA256):
+		h = sha256.New()
+	case algorithm.Equal(oidSHA384):
+		h = sha512.New384()
+	case algorithm.Equal(oidSHA512):
+		h = sha512.New()
+	default:
+		return nil, nil, fmt.Errorf("непідтримуваний алгоритм хешування %v", algorithm)
+	}
+
+	h.Write(issuer.RawSubject)
+	nameHash := h.Sum(nil)
+	h.Reset()
+	h.Write(spki.PublicKey.RightAlign())
+	return nameHash, h.Sum(nil), nil
+}
+
+// responderID повертає ResponderID byKey для сертифіката відповідача
+func responderID(cert *x509.Certificate) (asn1.RawValue, error) {
+	var spki subjectPublicKeyInfo
+	if _, err := asn1.Unmarshal(cert.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return asn1.RawValue{}, fmt.Errorf("некоректний відкритий ключ відповідача: %v", err)
+	}
+	keyHash := sha1.Sum(spki.PublicKey.RightAlign())
+	value, err := asn1.Marshal(keyHash[:])
+	if err != nil {
+		return asn1.RawValue{}, err
+	}
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2, IsCompound: true, Bytes: value}, nil
+}
+
+// signResponse підписує тіло відповіді і повертає відповідь OCSP у DER
+func signResponse(data responseData, signer crypto.Signer, certificates []*x509.Certificate) ([]byte, error) {
+	tbs, err := asn1.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm, hashFunc, err := signatureAlgorithm(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	digest := tbs
+	if hashFunc != crypto.Hash(0) {
+		h := hashFunc.New()
+		h.Write(tbs)
+		digest = h.Sum(nil)
+	}
+	signature, err := signer.Sign(rand.Reader, digest, hashFunc)
+	if err != nil {
+		return nil, fmt.Errorf("помилка підпису відповіді OCSP: %v", err)
+	}
+
+	basic := basicResponse{
+		TBSResponseData:    asn1.RawValue{FullBytes: tbs},
+		SignatureAlgorithm: algorithm,
+		Signature:          asn1.BitString{Bytes: signature, BitLength: len(signature) * 8},
+	}
+	for _, cert := range certificates {
+		basic.Certificates = append(basic.Certificates, asn1.RawValue{FullBytes: cert.Raw})
+	}
+	basicDER, err := asn1.Marshal(basic)
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(ocspResponse{
+		Status:   statusSuccessful,
+		Response: responseBytes{ResponseType: oidBasicResponse, Response: basicDER},
+	})
+}
+
+// errorResponse повертає відповідь OCSP без тіла з вказаним станом
+func errorResponse(status int) []byte {
+	der, _ := asn1.Marshal(ocspResponse{Status: asn1.Enumerated(status)})
+	return der
+}
+
+// signatureAlgorithm повертає алгоритм підпису відповіді та хеш для відкритого ключа відповідача
+func signatureAlgorithm(publicKey crypto.PublicKey) (pkix.AlgorithmIdentifier, crypto.Hash, error) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return pkix.AlgorithmIdentifier{Algorithm: oidSignatureSHA256WithRSA, Parameters: asn1.NullRawValue}, crypto.SHA256, nil
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return pkix.AlgorithmIdentifier{Algorithm: oidSignatureECDSAWithSHA256}, crypto.SHA256, nil
+		case elliptic.P384():
+			return pkix.AlgorithmIdentifier{Algorithm: oidSignatureECDSAWithSHA384}, crypto.SHA384, nil
+		case elliptic.P521():
+			return pkix.AlgorithmIdentifier{Algorithm: oidSignatureECDSAWithSHA512}, crypto.SHA512, nil
+		}
+	case ed25519.PublicKey:
+		// Ed25519 підписує повідомлення без попереднього хешування
+		return pkix.AlgorithmIdentifier{Algorithm: oidSignatureEd25519}, crypto.Hash(0), nil
+	}
+	return pkix.AlgorithmIdentifier{}, 0, fmt.Errorf("непідтримуваний ключ відповідача %T", publicKey)
+}
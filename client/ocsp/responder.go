@@ -0,0 +1,268 @@
+// Package ocsp - відповідач OCSP (RFC 6960) для сертифікатів, закріплених за ключами.
+//
+// Відповідач приймає запити OCSP по HTTP (GET і POST, RFC 6960, додаток A),
+// визначає стан кожного сертифіката запитом GetCertificateStatus до смарт-контракту
+// keymanagement і підписує відповідь налаштованим ключем відповідача. Стан
+// сертифіката враховує стан його CryptoKey: сертифікат скомпрометованого,
+// відкликаного або знищеного ключа вважається відкликаним. Контракт передається
+// через інтерфейс Contract, який реалізує, зокрема, *client.Contract з
+// fabric-gateway, тому відповідач можна запускати локально з імітацією контракту.
+package ocsp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"blockchain-security/chaincode/common/crl"
+)
+
+// Параметри HTTP-транспорту OCSP
+const (
+	requestContentType  = "application/ocsp-request"
+	responseContentType = "application/ocsp-response"
+	maxRequestSize      = 64 << 10
+	maxRequestsPerQuery = 16
+)
+
+// Строк дії відповіді за замовчуванням
+const defaultValidity = time.Hour
+
+// Стани сертифіката в реєстрі
+const (
+	certStatusValid   = "valid"
+	certStatusRevoked = "revoked"
+)
+
+// Contract запити смарт-контракту keymanagement, доступні відповідачу
+type Contract interface {
+	EvaluateTransaction(name string, args ...string) ([]byte, error)
+}
+
+// CertificateStatus стан сертифіката; відповідає запису CertificateStatus смарт-контракту
+type CertificateStatus struct {
+	IssuerID         string `json:"issuerId"`
+	SerialNumber     string `json:"serialNumber"`
+	Status           string `json:"status"` // valid, revoked, unknown
+	RevokedAt        int64  `json:"revokedAt,omitempty"`
+	RevocationReason string `json:"revocationReason,omitempty"`
+	CheckedAt        int64  `json:"checkedAt"`
+}
+
+// Responder відповідач OCSP для сертифікатів одного видавця
+type Responder struct {
+	Validity time.Duration    // строк дії відповіді (nextUpdate - thisUpdate)
+	Now      func() time.Time // джерело часу; за замовчуванням time.Now
+
+	keys        Contract
+	issuer      *x509.Certificate
+	issuerID    string
+	certificate *x509.Certificate
+	signer      crypto.Signer
+	responderID asn1.RawValue
+}
+
+// NewResponder створює відповідача для сертифікатів видавця issuer.
+// Відповіді підписує signer; certificate - сертифікат відповідача: сам видавець
+// або делегований відповідач, сертифікат якого видав issuer з id-kp-OCSPSigning.
+func NewResponder(keys Contract, issuer *x509.Certificate, certificate *x509.Certificate, signer crypto.Signer) (*Responder, error) {
+	certificateKey, err := x509.MarshalPKIXPublicKey(certificate.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("некоректний сертифікат відповідача: %v", err)
+	}
+	signerKey, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return nil, fmt.Errorf("некоректний ключ відповідача: %v", err)
+	}
+	if !bytes.Equal(certificateKey, signerKey) {
+		return nil, errors.New("ключ відповідача не відповідає його сертифікату")
+	}
+	if _, _, err := signatureAlgorithm(signer.Public()); err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(certificate.Raw, issuer.Raw) {
+		if !hasExtKeyUsage(certificate, x509.ExtKeyUsageOCSPSigning) {
+			return nil, errors.New("сертифікат делегованого відповідача не містить id-kp-OCSPSigning")
+		}
+		if err := certificate.CheckSignatureFrom(issuer); err != nil {
+			return nil, fmt.Errorf("сертифікат відповідача видано не видавцем: %v", err)
+		}
+	}
+
+	id, err := responderID(certificate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ідентифікатор видавця в реєстрі - SHA-256 від DER його сертифіката
+	issuerHash := sha256.Sum256(issuer.Raw)
+	return &Responder{
+		Validity:    defaultValidity,
+		Now:         time.Now,
+		keys:        keys,
+		issuer:      issuer,
+		issuerID:    hex.EncodeToString(issuerHash[:]),
+		certificate: certificate,
+		signer:      signer,
+		responderID: id,
+	}, nil
+}
+
+// ServeHTTP обробляє запит OCSP, переданий методом POST або в шляху запиту GET
+func (r *Responder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var der []byte
+	switch req.Method {
+	case http.MethodGet:
+		encoded, err := url.PathUnescape(strings.TrimPrefix(req.URL.EscapedPath(), "/"))
+		if err == nil {
+			der, err = base64.StdEncoding.DecodeString(encoded)
+		}
+		if err != nil {
+			r.write(w, errorResponse(statusMalformedRequest), false)
+			return
+		}
+	case http.MethodPost:
+		if req.Header.Get("Content-Type") != requestContentType {
+			http.Error(w, "очікується "+requestContentType, http.StatusUnsupportedMediaType)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(req.Body, maxRequestSize+1))
+		if err != nil || len(body) > maxRequestSize {
+			r.write(w, errorResponse(statusMalformedRequest), false)
+			return
+		}
+		der = body
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "метод не підтримується", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response, successful := r.respond(der)
+	r.write(w, response, successful && req.Method == http.MethodGet)
+}
+
+// respond формує підписану відповідь на запит OCSP у DER.
+// Повертає відповідь і ознаку того, що вона містить стан сертифікатів.
+func (r *Responder) respond(der []byte) ([]byte, bool) {
+	request, err := parseRequest(der)
+	if err != nil || len(request.TBSRequest.RequestList) > maxRequestsPerQuery {
+		return errorResponse(statusMalformedRequest), false
+	}
+
+	now := r.now()
+	responses := make([]singleResponse, 0, len(request.TBSRequest.RequestList))
+	for _, single := range request.TBSRequest.RequestList {
+		response, err := r.certificateStatus(single.Cert, now)
+		if err != nil {
+			log.Printf("помилка визначення стану сертифіката: %v", err)
+			return errorResponse(statusTryLater), false
+		}
+		responses = append(responses, response)
+	}
+
+	data := responseData{ResponderID: r.responderID, ProducedAt: now, Responses: responses}
+	for _, extension := range request.TBSRequest.Extensions {
+		if extension.Id.Equal(oidNonce) {
+			data.Extensions = []pkix.Extension{{Id: oidNonce, Value: extension.Value}}
+		}
+	}
+
+	var certificates []*x509.Certificate
+	if !bytes.Equal(r.certificate.Raw, r.issuer.Raw) {
+		certificates = append(certificates, r.certificate)
+	}
+	response, err := signResponse(data, r.signer, certificates)
+	if err != nil {
+		log.Printf("помилка формування відповіді OCSP: %v", err)
+		return errorResponse(statusInternalError), false
+	}
+	return response, true
+}
+
+// certificateStatus визначає стан сертифіката за станом реєстру.
+// Сертифікати інших видавців мають стан unknown без звернення до реєстру.
+func (r *Responder) certificateStatus(id certID, now time.Time) (singleResponse, error) {
+	response := singleResponse{Cert: id, ThisUpdate: now, NextUpdate: now.Add(r.validity())}
+
+	nameHash, keyHash, err := issuerHashes(r.issuer, id.HashAlgorithm.Algorithm)
+	if err != nil || !bytes.Equal(nameHash, id.IssuerNameHash) || !bytes.Equal(keyHash, id.IssuerKeyHash) || id.SerialNumber.Sign() < 0 {
+		response.Unknown = true
+		return response, nil
+	}
+
+	result, err := r.keys.EvaluateTransaction("GetCertificateStatus", r.issuerID, id.SerialNumber.Text(16))
+	if err != nil {
+		return singleResponse{}, fmt.Errorf("помилка запиту стану сертифіката %s: %v", id.SerialNumber.Text(16), err)
+	}
+	var status CertificateStatus
+	if err := json.Unmarshal(result, &status); err != nil {
+		return singleResponse{}, fmt.Errorf("помилка розбору стану сертифіката: %v", err)
+	}
+
+	switch status.Status {
+	case certStatusValid:
+		response.Good = true
+	case certStatusRevoked:
+		reason, known := crl.ReasonCodes[status.RevocationReason]
+		if !known {
+			return singleResponse{}, fmt.Errorf("невідома причина відкликання: %s", status.RevocationReason)
+		}
+		response.Revoked = revokedInfo{RevocationTime: time.Unix(status.RevokedAt, 0).UTC(), Reason: asn1.Enumerated(reason)}
+	default:
+		response.Unknown = true
+	}
+	return response, nil
+}
+
+// write надсилає відповідь OCSP; успішну відповідь на GET дозволено кешувати до nextUpdate
+func (r *Responder) write(w http.ResponseWriter, response []byte, cacheable bool) {
+	w.Header().Set("Content-Type", responseContentType)
+	if cacheable {
+		w.Header().Set("Cache-Control", "max-age="+strconv.Itoa(int(r.validity().Seconds()))+", public, no-transform, must-revalidate")
+	}
+	w.Write(response)
+}
+
+// now повертає поточний час з точністю до секунди, як його кодує GeneralizedTime
+func (r *Responder) now() time.Time {
+	now := time.Now
+	if r.Now != nil {
+		now = r.Now
+	}
+	return now().UTC().Truncate(time.Second)
+}
+
+// validity повертає строк дії відповіді
+func (r *Responder) validity() time.Duration {
+	if r.Validity <= 0 {
+		return defaultValidity
+	}
+	return r.Validity
+}
+
+// hasExtKeyUsage перевіряє наявність розширеного призначення ключа в сертифікаті
+func hasExtKeyUsage(cert *x509.Certificate, usage x509.ExtKeyUsage) bool {
+	for _, item := range cert.ExtKeyUsage {
+		if item == usage {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,245 @@
+// Файл: client/ocsp/responder_test.go
+package ocsp
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeContract повертає підготовлені стани сертифікатів за серійним номером
+type fakeContract struct {
+	calls     [][]string
+	responses map[string][]byte
+	err       error
+}
+
+func (c *fakeContract) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	c.calls = append(c.calls, append([]string{name}, args...))
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.responses[args[1]], nil
+}
+
+// testPKI видавець, делегований відповідач і сертифікати кінцевих суб'єктів
+type testPKI struct {
+	issuer       *x509.Certificate
+	issuerKey    *ecdsa.PrivateKey
+	responder    *x509.Certificate
+	responderKey *ecdsa.PrivateKey
+	issuerID     string
+	certificates map[int64]*x509.Certificate
+}
+
+// newCertificate видає сертифікат за шаблоном; без батьківського сертифіката - самопідписаний
+func newCertificate(t *testing.T, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	template.NotBefore = time.Unix(1600000000, 0)
+	template.NotAfter = time.Unix(1700000000, 0)
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	return cert, key
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	pki := &testPKI{certificates: map[int64]*x509.Certificate{}}
+	pki.issuer, pki.issuerKey = newCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Org1 Issuing CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, nil, nil)
+	pki.responder, pki.responderKey = newCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Org1 OCSP Responder"},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+	}, pki.issuer, pki.issuerKey)
+	for _, serial := range []int64{0x1001, 0x1002, 0x1003} {
+		pki.certificates[serial], _ = newCertificate(t, &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "service.org1"},
+		}, pki.issuer, pki.issuerKey)
+	}
+	issuerHash := sha256.Sum256(pki.issuer.Raw)
+	pki.issuerID = hex.EncodeToString(issuerHash[:])
+	return pki
+}
+
+// ledgerStates стани сертифікатів у реєстрі: 1001 чинний, 1002 відкликаний, 1003 не зареєстрований
+func ledgerStates(issuerID string) map[string][]byte {
+	return map[string][]byte{
+		"1001": []byte(`{"issuerId":"` + issuerID + `","serialNumber":"1001","status":"valid","checkedAt":1630000000}`),
+		"1002": []byte(`{"issuerId":"` + issuerID + `","serialNumber":"1002","status":"revoked","revokedAt":1625000000,"revocationReason":"keyCompromise","checkedAt":1630000000}`),
+		"1003": []byte(`{"issuerId":"` + issuerID + `","serialNumber":"1003","status":"unknown","checkedAt":1630000000}`),
+	}
+}
+
+// parseTestResponse розбирає відповідь OCSP і перевіряє її підпис сертифікатом відповідача
+func parseTestResponse(t *testing.T, der []byte, responder *x509.Certificate) (asn1.Enumerated, *responseData) {
+	var response ocspResponse
+	_, err := asn1.Unmarshal(der, &response)
+	assert.Nil(t, err)
+	if response.Status != statusSuccessful {
+		return response.Status, nil
+	}
+	assert.True(t, response.Response.ResponseType.Equal(oidBasicResponse))
+
+	var basic basicResponse
+	_, err = asn1.Unmarshal(response.Response.Response, &basic)
+	assert.Nil(t, err)
+	assert.Nil(t, responder.CheckSignature(x509.ECDSAWithSHA256, basic.TBSResponseData.FullBytes, basic.Signature.RightAlign()))
+
+	var data responseData
+	_, err = asn1.Unmarshal(basic.TBSResponseData.FullBytes, &data)
+	assert.Nil(t, err)
+	return response.Status, &data
+}
+
+// Тестування відповіді на запит POST зі станами чинного, відкликаного та невідомого сертифікатів
+func TestResponderPost(t *testing.T) {
+	pki := newTestPKI(t)
+	keys := &fakeContract{responses: ledgerStates(pki.issuerID)}
+
+	responder, err := NewResponder(keys, pki.issuer, pki.responder, pki.responderKey)
+	assert.Nil(t, err)
+	responder.Now = func() time.Time { return time.Unix(1630000000, 0) }
+	server := httptest.NewServer(responder)
+	defer server.Close()
+
+	expected := map[int64]string{0x1001: "good", 0x1002: "revoked", 0x1003: "unknown"}
+	for serial, want := range expected {
+		request, err := CreateRequest(pki.certificates[serial], pki.issuer)
+		assert.Nil(t, err)
+
+		resp, err := http.Post(server.URL, "application/ocsp-request", bytes.NewReader(request))
+		assert.Nil(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "application/ocsp-response", resp.Header.Get("Content-Type"))
+
+		status, data := parseTestResponse(t, body, pki.responder)
+		assert.Equal(t, asn1.Enumerated(statusSuccessful), status)
+		assert.Len(t, data.Responses, 1)
+		single := data.Responses[0]
+		assert.Equal(t, big.NewInt(serial), single.Cert.SerialNumber)
+		assert.Equal(t, int64(1630000000+3600), single.NextUpdate.Unix())
+
+		switch want {
+		case "good":
+			assert.True(t, bool(single.Good))
+		case "revoked":
+			assert.Equal(t, int64(1625000000), single.Revoked.RevocationTime.Unix())
+			assert.Equal(t, asn1.Enumerated(1), single.Revoked.Reason)
+		case "unknown":
+			assert.True(t, bool(single.Unknown))
+		}
+	}
+
+	// Стан запитується у реєстру за ідентифікатором видавця і серійним номером у hex
+	assert.Equal(t, []string{"GetCertificateStatus", pki.issuerID, "1001"}, keys.calls[0][:3])
+}
+
+// Тестування запиту GET з кодуванням запиту в шляху та повернення nonce
+func TestResponderGet(t *testing.T) {
+	pki := newTestPKI(t)
+	keys := &fakeContract{responses: ledgerStates(pki.issuerID)}
+
+	responder, err := NewResponder(keys, pki.issuer, pki.responder, pki.responderKey)
+	assert.Nil(t, err)
+	server := httptest.NewServer(responder)
+	defer server.Close()
+
+	request, err := CreateRequest(pki.certificates[0x1001], pki.issuer)
+	assert.Nil(t, err)
+	var parsed ocspRequest
+	_, err = asn1.Unmarshal(request, &parsed)
+	assert.Nil(t, err)
+	nonce, _ := asn1.Marshal([]byte("nonce-1234"))
+	parsed.TBSRequest.Extensions = []pkix.Extension{{Id: oidNonce, Value: nonce}}
+	request, err = asn1.Marshal(parsed)
+	assert.Nil(t, err)
+
+	resp, err := http.Get(server.URL + "/" + url.PathEscape(base64.StdEncoding.EncodeToString(request)))
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Contains(t, resp.Header.Get("Cache-Control"), "max-age=3600")
+
+	status, data := parseTestResponse(t, body, pki.responder)
+	assert.Equal(t, asn1.Enumerated(statusSuccessful), status)
+	assert.True(t, bool(data.Responses[0].Good))
+	assert.Len(t, data.Extensions, 1)
+	assert.Equal(t, nonce, data.Extensions[0].Value)
+}
+
+// Тестування сертифіката іншого видавця, некоректного запиту та недоступного реєстру
+func TestResponderErrors(t *testing.T) {
+	pki := newTestPKI(t)
+	other := newTestPKI(t)
+
+	responder, err := NewResponder(&fakeContract{responses: ledgerStates(pki.issuerID)}, pki.issuer, pki.issuer, pki.issuerKey)
+	assert.Nil(t, err)
+
+	// Сертифікат іншого видавця має стан unknown, реєстр не запитується
+	request, err := CreateRequest(other.certificates[0x1001], other.issuer)
+	assert.Nil(t, err)
+	response, successful := responder.respond(request)
+	assert.True(t, successful)
+	_, data := parseTestResponse(t, response, pki.issuer)
+	assert.True(t, bool(data.Responses[0].Unknown))
+
+	response, successful = responder.respond([]byte("not a request"))
+	assert.False(t, successful)
+	status, _ := parseTestResponse(t, response, pki.issuer)
+	assert.Equal(t, asn1.Enumerated(statusMalformedRequest), status)
+
+	unavailable, err := NewResponder(&fakeContract{err: errors.New("peer недоступний")}, pki.issuer, pki.issuer, pki.issuerKey)
+	assert.Nil(t, err)
+	request, err = CreateRequest(pki.certificates[0x1001], pki.issuer)
+	assert.Nil(t, err)
+	response, successful = unavailable.respond(request)
+	assert.False(t, successful)
+	status, _ = parseTestResponse(t, response, pki.issuer)
+	assert.Equal(t, asn1.Enumerated(statusTryLater), status)
+}
+
+// Тестування перевірки сертифіката делегованого відповідача
+func TestNewResponderValidation(t *testing.T) {
+	pki := newTestPKI(t)
+	keys := &fakeContract{}
+
+	// Сертифікат кінцевого суб'єкта не має id-kp-OCSPSigning
+	leaf, key := newCertificate(t, &x509.Certificate{SerialNumber: big.NewInt(9), KeyUsage: x509.KeyUsageDigitalSignature}, pki.issuer, pki.issuerKey)
+	_, err := NewResponder(keys, pki.issuer, leaf, key)
+	assert.NotNil(t, err)
+
+	// Ключ не відповідає сертифікату відповідача
+	_, err = NewResponder(keys, pki.issuer, pki.responder, pki.issuerKey)
+	assert.NotNil(t, err)
+}
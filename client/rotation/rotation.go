@@ -0,0 +1,175 @@
+// Package rotation - позамережевий виконавець планових ротацій ключів.
+//
+// Виконавець періодично запитує у смарт-контракту keymanagement ключі,
+// політика яких вимагає ротації (GetKeysDueForRotation), і ротує кожен з
+// них транзакцією RotateDueKey. Успішну ротацію фіксує в аудиті сам
+// keymanagement, а невдалу, яка не потрапляє в реєстр, виконавець записує
+// як подію смарт-контракту securityaudit. Ідентичність виконавця має бути
+// вказана у полі workers політики ротації ключа, інакше RotateDueKey
+// відхиляється. Контракти передаються через інтерфейс Contract, який
+// реалізує, зокрема, *client.Contract з fabric-gateway.
+package rotation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+// Розмір сторінки запиту ключів для ротації (не більше ліміту смарт-контракту)
+const duePageSize = 100
+
+// Параметри подій аудиту невдалих ротацій, які записує виконавець
+const (
+	auditEventType     = "key_operation"
+	auditAction        = "rotate"
+	auditResultFailure = "failure"
+)
+
+// Contract транзакції смарт-контракту, доступні виконавцю
+type Contract interface {
+	EvaluateTransaction(name string, args ...string) ([]byte, error)
+	SubmitTransaction(name string, args ...string) ([]byte, error)
+}
+
+// DueKey ключ, що підлягає ротації; відповідає запису RotationDue смарт-контракту
+type DueKey struct {
+	KeyID  string `json:"keyId"`
+	Reason string `json:"reason"`
+	DueAt  int64  `json:"dueAt"`
+}
+
+// duePage сторінка ключів для ротації; відповідає RotationDueResult смарт-контракту
+type duePage struct {
+	Records  []DueKey `json:"records"`
+	Bookmark string   `json:"bookmark"`
+}
+
+// Outcome результат ротації одного ключа
+type Outcome struct {
+	KeyID    string
+	Reason   string
+	NewKeyID string
+	Err      error // помилка ротації
+	AuditErr error // помилка запису події аудиту невдалої ротації
+}
+
+// Executor виконує планові ротації ключів
+type Executor struct {
+	Keys  Contract         // смарт-контракт keymanagement
+	Audit Contract         // смарт-контракт securityaudit
+	Actor string           // ідентичність виконавця у подіях аудиту
+	Now   func() time.Time // джерело часу для asOf; за замовчуванням time.Now
+}
+
+// NewExecutor створює виконавця ротацій
+func NewExecutor(keys Contract, audit Contract, actor string) *Executor {
+	return &Executor{Keys: keys, Audit: audit, Actor: actor, Now: time.Now}
+}
+
+// RunOnce ротує всі ключі, що підлягають ротації на поточний момент.
+// Помилка ротації одного ключа не зупиняє обробку інших і повертається в його Outcome.
+func (e *Executor) RunOnce(ctx context.Context) ([]Outcome, error) {
+	due, err := e.dueKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	outcomes := make([]Outcome, 0, len(due))
+	for _, key := range due {
+		if err := ctx.Err(); err != nil {
+			return outcomes, err
+		}
+
+		outcome := Outcome{KeyID: key.KeyID, Reason: key.Reason}
+		newKeyID, err := e.Keys.SubmitTransaction("RotateDueKey", key.KeyID)
+		if err != nil {
+			outcome.Err = err
+			outcome.AuditErr = e.recordFailure(key, err)
+		} else {
+			outcome.NewKeyID = string(newKeyID)
+		}
+
+		outcomes = append(outcomes, outcome)
+	}
+
+	return outcomes, nil
+}
+
+// Run запускає RunOnce з вказаним інтервалом до скасування контексту
+func (e *Executor) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		outcomes, err := e.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("помилка планової ротації: %v", err)
+		}
+		for _, outcome := range outcomes {
+			if outcome.Err != nil {
+				log.Printf("ротація ключа %s не виконана: %v", outcome.KeyID, outcome.Err)
+			}
+			if outcome.AuditErr != nil {
+				log.Printf("подію ротації ключа %s не записано: %v", outcome.KeyID, outcome.AuditErr)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// dueKeys запитує ключі, що підлягають ротації, посторінково до вичерпання закладок
+func (e *Executor) dueKeys() ([]DueKey, error) {
+	now := time.Now
+	if e.Now != nil {
+		now = e.Now
+	}
+	asOf := strconv.FormatInt(now().Unix(), 10)
+	pageSize := strconv.Itoa(duePageSize)
+
+	due := []DueKey{}
+	bookmark := ""
+	for {
+		result, err := e.Keys.EvaluateTransaction("GetKeysDueForRotation", asOf, pageSize, bookmark)
+		if err != nil {
+			return nil, fmt.Errorf("помилка запиту ключів для ротації: %v", err)
+		}
+
+		var page duePage
+		if err := json.Unmarshal(result, &page); err != nil {
+			return nil, fmt.Errorf("помилка розбору ключів для ротації: %v", err)
+		}
+		due = append(due, page.Records...)
+
+		if page.Bookmark == "" || page.Bookmark == bookmark {
+			return due, nil
+		}
+		bookmark = page.Bookmark
+	}
+}
+
+// recordFailure записує невдалу ротацію як подію аудиту. Відхилена транзакція
+// RotateDueKey не потрапляє в реєстр, тому її подію аудиту keymanagement не записує.
+func (e *Executor) recordFailure(key DueKey, rotateErr error) error {
+	metadata := map[string]string{
+		"reason": key.Reason,
+		"dueAt":  strconv.FormatInt(key.DueAt, 10),
+		"error":  rotateErr.Error(),
+	}
+
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.Audit.SubmitTransaction("RecordEvent", auditEventType, e.Actor, key.KeyID, auditAction, auditResultFailure, string(metadataJSON))
+	return err
+}
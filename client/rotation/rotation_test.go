@@ -0,0 +1,101 @@
+// Файл: client/rotation/rotation_test.go
+package rotation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeContract записує виклики транзакцій і повертає підготовлені відповіді
+type fakeContract struct {
+	calls     [][]string
+	responses map[string][]byte
+	errors    map[string]error
+}
+
+func (c *fakeContract) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	return c.SubmitTransaction(name, args...)
+}
+
+func (c *fakeContract) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	call := append([]string{name}, args...)
+	c.calls = append(c.calls, call)
+
+	key := strings.Join(call, ":")
+	if err := c.errors[key]; err != nil {
+		return nil, err
+	}
+	return c.responses[key], nil
+}
+
+// Тестування ротації ключів і запису невдалих ротацій в аудит
+func TestRunOnce(t *testing.T) {
+	keys := &fakeContract{
+		responses: map[string][]byte{
+			"GetKeysDueForRotation:1630000000:100:":               []byte(`{"records":[{"keyId":"key1","reason":"interval","dueAt":1629990000}],"bookmark":"cryptokey:key2"}`),
+			"GetKeysDueForRotation:1630000000:100:cryptokey:key2": []byte(`{"records":[{"keyId":"key2","reason":"usage","dueAt":1630000000}],"bookmark":""}`),
+			"RotateDueKey:key1":                                   []byte("key1-abcdef12"),
+		},
+		errors: map[string]error{
+			"RotateDueKey:key2": errors.New("ключ key2 не потребує ротації"),
+		},
+	}
+	audit := &fakeContract{}
+
+	executor := NewExecutor(keys, audit, "Org1MSP::rotator")
+	executor.Now = func() time.Time { return time.Unix(1630000000, 0) }
+
+	outcomes, err := executor.RunOnce(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, outcomes, 2)
+	assert.Equal(t, []string{"GetKeysDueForRotation", "1630000000", "100", "cryptokey:key2"}, keys.calls[1])
+	assert.Equal(t, "key1-abcdef12", outcomes[0].NewKeyID)
+	assert.Nil(t, outcomes[0].Err)
+	assert.NotNil(t, outcomes[1].Err)
+
+	// Успішну ротацію фіксує keymanagement, виконавець записує лише невдалу
+	assert.Len(t, audit.calls, 1)
+	assert.Equal(t, []string{"RecordEvent", "key_operation", "Org1MSP::rotator", "key2", "rotate", "failure"}, audit.calls[0][:6])
+
+	var metadata map[string]string
+	assert.Nil(t, json.Unmarshal([]byte(audit.calls[0][6]), &metadata))
+	assert.Equal(t, map[string]string{"reason": "usage", "dueAt": "1630000000", "error": "ключ key2 не потребує ротації"}, metadata)
+}
+
+// Тестування помилки запиту ключів для ротації
+func TestRunOnceQueryError(t *testing.T) {
+	keys := &fakeContract{errors: map[string]error{"GetKeysDueForRotation:1630000000:100:": errors.New("peer недоступний")}}
+	audit := &fakeContract{}
+
+	executor := NewExecutor(keys, audit, "Org1MSP::rotator")
+	executor.Now = func() time.Time { return time.Unix(1630000000, 0) }
+
+	_, err := executor.RunOnce(context.Background())
+	assert.NotNil(t, err)
+	assert.Empty(t, audit.calls)
+}
+
+// Тестування зупинки обробки після скасування контексту
+func TestRunOnceCancelled(t *testing.T) {
+	keys := &fakeContract{responses: map[string][]byte{
+		"GetKeysDueForRotation:1630000000:100:": []byte(`{"records":[{"keyId":"key1","reason":"interval"}]}`),
+	}}
+	audit := &fakeContract{}
+
+	executor := NewExecutor(keys, audit, "Org1MSP::rotator")
+	executor.Now = func() time.Time { return time.Unix(1630000000, 0) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	outcomes, err := executor.RunOnce(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, outcomes)
+	assert.Len(t, keys.calls, 1)
+}
@@ -0,0 +1,237 @@
+// Команда ocsp-responder - HTTP-сервіс відповідача OCSP для сертифікатів,
+// закріплених за ключами.
+//
+// Сервіс підключається до peer через Fabric Gateway, отримує стан сертифікатів
+// від смарт-контракту keymanagement і підписує відповіді ключем відповідача.
+// Параметри задаються прапорцями; значення за замовчуванням беруться зі змінних
+// середовища OCSP_*, наприклад:
+//
+//	ocsp-responder -msp-id Org1MSP \
+//		-cert user/signcerts/cert.pem -key user/keystore/priv_sk \
+//		-peer-tls-ca peer0/tls/ca.crt \
+//		-issuer-cert ca.pem -responder-cert ocsp.pem -responder-key ocsp-key.pem
+package main
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"blockchain-security/client/ocsp"
+)
+
+// config параметри запуску відповідача
+type config struct {
+	listen    string
+	validity  time.Duration
+	channel   string
+	chaincode string
+
+	// Підключення до Fabric Gateway
+	peerEndpoint string
+	peerHost     string
+	peerTLSCA    string
+	mspID        string
+	certPath     string
+	keyPath      string
+
+	// Матеріали відповідача OCSP
+	issuerCertPath    string
+	responderCertPath string
+	responderKeyPath  string
+}
+
+func main() {
+	cfg, err := parseConfig(os.Args[1:])
+	if err != nil {
+		log.Fatalf("Помилка параметрів: %v", err)
+	}
+	if err := run(cfg); err != nil {
+		log.Fatalf("Помилка відповідача OCSP: %v", err)
+	}
+}
+
+// parseConfig розбирає прапорці; незадані прапорці беруть значення зі змінних середовища
+func parseConfig(args []string) (*config, error) {
+	cfg := &config{}
+	flags := flag.NewFlagSet("ocsp-responder", flag.ContinueOnError)
+	flags.StringVar(&cfg.listen, "listen", env("OCSP_LISTEN", ":8080"), "адреса HTTP-сервера")
+	flags.DurationVar(&cfg.validity, "validity", time.Hour, "строк дії відповіді")
+	flags.StringVar(&cfg.channel, "channel", env("OCSP_CHANNEL", "security-channel"), "канал смарт-контракту")
+	flags.StringVar(&cfg.chaincode, "chaincode", env("OCSP_CHAINCODE", "keymanagement"), "назва смарт-контракту керування ключами")
+	flags.StringVar(&cfg.peerEndpoint, "peer", env("OCSP_PEER_ENDPOINT", "localhost:7051"), "адреса Fabric Gateway peer")
+	flags.StringVar(&cfg.peerHost, "peer-host", env("OCSP_PEER_HOST", "peer0.org1.example.com"), "ім'я peer у його сертифікаті TLS")
+	flags.StringVar(&cfg.peerTLSCA, "peer-tls-ca", env("OCSP_PEER_TLS_CA", ""), "кореневий сертифікат TLS peer (PEM)")
+	flags.StringVar(&cfg.mspID, "msp-id", env("OCSP_MSP_ID", "Org1MSP"), "MSP ID клієнта")
+	flags.StringVar(&cfg.certPath, "cert", env("OCSP_CLIENT_CERT", ""), "сертифікат клієнта Fabric (PEM)")
+	flags.StringVar(&cfg.keyPath, "key", env("OCSP_CLIENT_KEY", ""), "закритий ключ клієнта Fabric (PEM, PKCS #8)")
+	flags.StringVar(&cfg.issuerCertPath, "issuer-cert", env("OCSP_ISSUER_CERT", ""), "сертифікат видавця (PEM)")
+	flags.StringVar(&cfg.responderCertPath, "responder-cert", env("OCSP_RESPONDER_CERT", ""), "сертифікат відповідача (PEM)")
+	flags.StringVar(&cfg.responderKeyPath, "responder-key", env("OCSP_RESPONDER_KEY", ""), "закритий ключ відповідача (PEM, PKCS #8)")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	required := []struct{ name, value string }{
+		{"peer-tls-ca", cfg.peerTLSCA},
+		{"cert", cfg.certPath},
+		{"key", cfg.keyPath},
+		{"issuer-cert", cfg.issuerCertPath},
+		{"responder-cert", cfg.responderCertPath},
+		{"responder-key", cfg.responderKeyPath},
+	}
+	for _, option := range required {
+		if option.value == "" {
+			return nil, fmt.Errorf("не задано -%s", option.name)
+		}
+	}
+	if cfg.validity <= 0 {
+		return nil, errors.New("строк дії відповіді має бути додатним")
+	}
+	return cfg, nil
+}
+
+// run підключається до Fabric Gateway і обслуговує запити OCSP до завершення сервера
+func run(cfg *config) error {
+	issuer, err := readCertificate(cfg.issuerCertPath)
+	if err != nil {
+		return err
+	}
+	certificate, err := readCertificate(cfg.responderCertPath)
+	if err != nil {
+		return err
+	}
+	signer, err := readSigner(cfg.responderKeyPath)
+	if err != nil {
+		return err
+	}
+
+	connection, err := newGrpcConnection(cfg)
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+
+	gateway, err := connectGateway(cfg, connection)
+	if err != nil {
+		return err
+	}
+	defer gateway.Close()
+
+	contract := gateway.GetNetwork(cfg.channel).GetContract(cfg.chaincode)
+	responder, err := ocsp.NewResponder(contract, issuer, certificate, signer)
+	if err != nil {
+		return err
+	}
+	responder.Validity = cfg.validity
+
+	log.Printf("Відповідач OCSP слухає %s (канал %s, смарт-контракт %s)", cfg.listen, cfg.channel, cfg.chaincode)
+	return http.ListenAndServe(cfg.listen, responder)
+}
+
+// newGrpcConnection встановлює TLS-з'єднання gRPC з peer
+func newGrpcConnection(cfg *config) (*grpc.ClientConn, error) {
+	tlsCA, err := readCertificate(cfg.peerTLSCA)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(tlsCA)
+
+	transport := credentials.NewClientTLSFromCert(pool, cfg.peerHost)
+	connection, err := grpc.NewClient(cfg.peerEndpoint, grpc.WithTransportCredentials(transport))
+	if err != nil {
+		return nil, fmt.Errorf("помилка з'єднання з peer %s: %v", cfg.peerEndpoint, err)
+	}
+	return connection, nil
+}
+
+// connectGateway підключається до Fabric Gateway від імені клієнта з сертифікатом і ключем MSP
+func connectGateway(cfg *config, connection *grpc.ClientConn) (*client.Gateway, error) {
+	certificate, err := readCertificate(cfg.certPath)
+	if err != nil {
+		return nil, err
+	}
+	id, err := identity.NewX509Identity(cfg.mspID, certificate)
+	if err != nil {
+		return nil, fmt.Errorf("некоректна ідентичність клієнта: %v", err)
+	}
+
+	key, err := readPrivateKey(cfg.keyPath)
+	if err != nil {
+		return nil, err
+	}
+	sign, err := identity.NewPrivateKeySign(key)
+	if err != nil {
+		return nil, fmt.Errorf("некоректний ключ клієнта: %v", err)
+	}
+
+	gateway, err := client.Connect(
+		id,
+		client.WithSign(sign),
+		client.WithClientConnection(connection),
+		client.WithEvaluateTimeout(5*time.Second),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("помилка підключення до Fabric Gateway: %v", err)
+	}
+	return gateway, nil
+}
+
+// readCertificate читає сертифікат X.509 у форматі PEM
+func readCertificate(path string) (*x509.Certificate, error) {
+	certificatePEM, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("помилка читання сертифіката %s: %v", path, err)
+	}
+	certificate, err := identity.CertificateFromPEM(certificatePEM)
+	if err != nil {
+		return nil, fmt.Errorf("некоректний сертифікат %s: %v", path, err)
+	}
+	return certificate, nil
+}
+
+// readPrivateKey читає закритий ключ PKCS #8 у форматі PEM
+func readPrivateKey(path string) (crypto.PrivateKey, error) {
+	keyPEM, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("помилка читання ключа %s: %v", path, err)
+	}
+	key, err := identity.PrivateKeyFromPEM(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("некоректний ключ %s: %v", path, err)
+	}
+	return key, nil
+}
+
+// readSigner читає закритий ключ відповідача, яким підписуються відповіді
+func readSigner(path string) (crypto.Signer, error) {
+	key, err := readPrivateKey(path)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("ключ %s не підтримує підпис", path)
+	}
+	return signer, nil
+}
+
+// env повертає значення змінної середовища або fallback, якщо її не задано
+func env(name string, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}